@@ -4,16 +4,23 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtKey = []byte("Senha12345")
+// Validade padrao do access token (JWT_ACCESS_TTL). Depois disso o cliente usa o refresh token.
+const AccessTokenDuration = 1 * time.Hour
 
-func GerarToken(usuarioID string) (string, error) {
-	claims := jwt.MapClaims{
-		"usuario_id": usuarioID,
-		"exp":        time.Now().Add(1 * time.Hour).Unix(),
+// Gera o access token de uma sessao assinado com a chave atual
+func (keys *KeyManager) GerarToken(userID uuid.UUID, username, role string, sessionID uuid.UUID) (string, error) {
+	claims := &Claims{
+		Username: username,
+		Role:     role,
+		SID:      sessionID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(keys.TokenTTL)),
+		},
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return keys.Assinar(claims)
 }
@@ -0,0 +1,375 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Algoritmos de assinatura aceitos
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+// Intervalo minimo entre recargas causadas por um "kid" desconhecido
+const intervaloRecarga = 10 * time.Second
+
+// Trava usada com pg_advisory_xact_lock para que so uma replica gere a nova chave
+const travaRotacao = 727365
+
+var ErrChaveDesconhecida = errors.New("chave de assinatura desconhecida")
+
+// Chave de assinatura identificada pelo "kid"
+type chave struct {
+	kid      string
+	alg      string
+	privada  crypto.Signer
+	criadaEm time.Time
+	expiraEm time.Time
+}
+
+// Gerencia as chaves de assinatura dos tokens.
+//
+// As chaves ficam na tabela signing_keys para que todas as replicas assinem e
+// validem com o mesmo conjunto, com a parte privada cifrada (ver cifragem.go). A chave mais nova assina; as anteriores continuam
+// validando ate expirarem, o que acontece depois de um ciclo de rotacao mais a
+// validade maxima de um access token.
+type KeyManager struct {
+	DBConnection *sql.DB
+	Algoritmo    string
+	Rotacao      time.Duration // Idade a partir da qual uma nova chave e gerada
+	TokenTTL     time.Duration // Validade maxima dos tokens assinados
+
+	cifra         cipher.AEAD // Cifra das chaves privadas gravadas no banco
+	mu            sync.RWMutex
+	chaves        map[string]*chave
+	atual         *chave
+	ultimaRecarga time.Time
+}
+
+// Construtor de KeyManager. chaveCifragem (32 bytes, ver DecodificarChaveCifragem)
+// cifra as chaves privadas no banco. Nao acessa o banco; chame Iniciar antes de usar.
+func NewKeyManager(dbConnection *sql.DB, algoritmo string, rotacao, tokenTTL time.Duration, chaveCifragem []byte) (*KeyManager, error) {
+	if algoritmo != AlgEdDSA && algoritmo != AlgRS256 {
+		return nil, fmt.Errorf("algoritmo JWT não suportado: %q (use %s ou %s)", algoritmo, AlgEdDSA, AlgRS256)
+	}
+	if rotacao <= 0 {
+		return nil, errors.New("intervalo de rotação das chaves deve ser positivo")
+	}
+	cifra, err := novaCifra(chaveCifragem)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyManager{
+		DBConnection: dbConnection,
+		Algoritmo:    algoritmo,
+		Rotacao:      rotacao,
+		TokenTTL:     tokenTTL,
+		cifra:        cifra,
+		chaves:       map[string]*chave{},
+	}, nil
+}
+
+// Garante que exista uma chave valida e carrega todas do banco
+func (keys *KeyManager) Iniciar(ctx context.Context) error {
+	if err := keys.rotacionarSeNecessario(ctx); err != nil {
+		return err
+	}
+	return keys.carregar(ctx)
+}
+
+// Verifica a idade da chave atual periodicamente, gera outra quando necessario
+// e recarrega as chaves criadas por outras replicas
+func (keys *KeyManager) RotacionarPeriodicamente(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := keys.Iniciar(ctx); err != nil {
+				slog.ErrorContext(ctx, "KeyManager: Erro ao rotacionar chaves", "erro", err)
+			}
+		}
+	}
+}
+
+// Gera uma nova chave se a mais recente do algoritmo configurado ja passou do
+// intervalo de rotacao. Aproveita o lock para cifrar as chaves antigas em PEM puro.
+func (keys *KeyManager) rotacionarSeNecessario(ctx context.Context) error {
+	tx, err := keys.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// No SQLite a transacao ja comeca com o banco travado para escrita
+	if !database.SQLite(keys.DBConnection) {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, travaRotacao); err != nil {
+			return err
+		}
+	}
+
+	if err := keys.cifrarLegadas(ctx, tx); err != nil {
+		return err
+	}
+
+	var ultima sql.NullTime
+	err = tx.QueryRowContext(ctx, `SELECT criado_em FROM signing_keys WHERE alg = $1 ORDER BY criado_em DESC LIMIT 1`, keys.Algoritmo).Scan(&ultima)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if ultima.Valid && time.Since(ultima.Time) < keys.Rotacao {
+		return tx.Commit()
+	}
+
+	nova, err := gerarChave(keys.Algoritmo)
+	if err != nil {
+		return err
+	}
+	privadaPEM, err := codificarChave(nova.privada)
+	if err != nil {
+		return err
+	}
+	privadaCifrada, err := keys.cifrar(nova.kid, privadaPEM)
+	if err != nil {
+		return err
+	}
+
+	// A chave assina durante um ciclo e ainda valida os tokens emitidos no fim dele
+	nova.criadaEm = time.Now().UTC()
+	nova.expiraEm = nova.criadaEm.Add(keys.Rotacao + keys.TokenTTL)
+	_, err = tx.ExecContext(ctx, `INSERT INTO signing_keys (kid, alg, private_key, criado_em, expira_em) VALUES ($1, $2, $3, $4, $5)`,
+		nova.kid, nova.alg, privadaCifrada, nova.criadaEm, nova.expiraEm)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM signing_keys WHERE expira_em < now()`); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "KeyManager: Nova chave gerada", "kid", nova.kid, "alg", nova.alg)
+	return nil
+}
+
+// Carrega do banco todas as chaves ainda validas
+func (keys *KeyManager) carregar(ctx context.Context) error {
+	rows, err := keys.DBConnection.QueryContext(ctx, `SELECT kid, alg, private_key, criado_em, expira_em FROM signing_keys WHERE expira_em > now()`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	chaves := map[string]*chave{}
+	var atual *chave
+	for rows.Next() {
+		var c chave
+		var gravada string
+		if err := rows.Scan(&c.kid, &c.alg, &gravada, &c.criadaEm, &c.expiraEm); err != nil {
+			return err
+		}
+		privadaPEM, err := keys.decifrar(c.kid, gravada)
+		if err != nil {
+			return fmt.Errorf("chave %s: %w", c.kid, err)
+		}
+		c.privada, err = decodificarChave(privadaPEM)
+		if err != nil {
+			return fmt.Errorf("chave %s: %w", c.kid, err)
+		}
+		chaves[c.kid] = &c
+		if c.alg == keys.Algoritmo && (atual == nil || c.criadaEm.After(atual.criadaEm)) {
+			atual = &c
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if atual == nil {
+		return errors.New("nenhuma chave de assinatura disponível")
+	}
+
+	keys.mu.Lock()
+	keys.chaves = chaves
+	keys.atual = atual
+	keys.ultimaRecarga = time.Now()
+	keys.mu.Unlock()
+	return nil
+}
+
+// Assina as claims com a chave atual e coloca o "kid" no cabecalho
+func (keys *KeyManager) Assinar(claims jwt.Claims) (string, error) {
+	keys.mu.RLock()
+	atual := keys.atual
+	keys.mu.RUnlock()
+	if atual == nil {
+		return "", errors.New("nenhuma chave de assinatura carregada")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(atual.alg), claims)
+	token.Header["kid"] = atual.kid
+	return token.SignedString(atual.privada)
+}
+
+// Keyfunc para jwt.Parse: escolhe a chave publica pelo "kid" do cabecalho.
+// Um "kid" desconhecido pode ter sido gerado por outra replica, entao recarrega do banco.
+func (keys *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrChaveDesconhecida
+	}
+
+	c := keys.buscar(kid)
+	if c == nil && keys.podeRecarregar() {
+		if err := keys.carregar(context.Background()); err != nil {
+			return nil, err
+		}
+		c = keys.buscar(kid)
+	}
+	if c == nil || time.Now().After(c.expiraEm) {
+		return nil, ErrChaveDesconhecida
+	}
+	if token.Method.Alg() != c.alg {
+		return nil, fmt.Errorf("algoritmo %s não corresponde à chave %s", token.Method.Alg(), kid)
+	}
+	return c.privada.Public(), nil
+}
+
+// Algoritmos aceitos na validacao, para jwt.WithValidMethods
+func (keys *KeyManager) Algoritmos() []string {
+	return []string{AlgEdDSA, AlgRS256}
+}
+
+// Indica se ha uma chave pronta para assinar
+func (keys *KeyManager) Carregada() bool {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+	return keys.atual != nil
+}
+
+func (keys *KeyManager) buscar(kid string) *chave {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+	return keys.chaves[kid]
+}
+
+func (keys *KeyManager) podeRecarregar() bool {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+	return time.Since(keys.ultimaRecarga) > intervaloRecarga
+}
+
+// Chave publica no formato JWK (RFC 7517 / RFC 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// Conjunto de chaves publicado em /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Retorna as chaves publicas ainda validas, da mais nova para a mais antiga
+func (keys *KeyManager) JWKS() JWKSet {
+	keys.mu.RLock()
+	chaves := make([]*chave, 0, len(keys.chaves))
+	for _, c := range keys.chaves {
+		if time.Now().Before(c.expiraEm) {
+			chaves = append(chaves, c)
+		}
+	}
+	keys.mu.RUnlock()
+
+	sort.Slice(chaves, func(i, j int) bool { return chaves[i].criadaEm.After(chaves[j].criadaEm) })
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, c := range chaves {
+		jwk := JWK{Kid: c.kid, Alg: c.alg, Use: "sig"}
+		switch publica := c.privada.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publica)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publica.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publica.E)).Bytes())
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func gerarChave(alg string) (*chave, error) {
+	var privada crypto.Signer
+	var err error
+	switch alg {
+	case AlgEdDSA:
+		_, privada, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		privada, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		err = fmt.Errorf("algoritmo JWT não suportado: %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &chave{kid: uuid.NewString(), alg: alg, privada: privada}, nil
+}
+
+// Codifica a chave privada em PEM (PKCS#8)
+func codificarChave(privada crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(privada)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+func decodificarChave(privadaPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(privadaPEM))
+	if block == nil {
+		return nil, errors.New("PEM inválido")
+	}
+	privada, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := privada.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("tipo de chave não suportado")
+	}
+	return signer, nil
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// As chaves privadas ficam cifradas em signing_keys com AES-256-GCM, usando a
+// chave de JWT_KEY_ENCRYPTION_KEY, que nunca vai para o banco. O kid entra como
+// dado associado, entao uma chave cifrada copiada para outra linha nao decifra.
+// Formato gravado: "aesgcm:" + base64(nonce || texto cifrado).
+const prefixoCifrada = "aesgcm:"
+
+// Chaves gravadas antes da cifragem, em PEM puro. Sao aceitas na leitura e
+// cifradas na proxima verificacao de rotacao.
+const prefixoPEM = "-----BEGIN"
+
+// Tamanho da chave de cifragem: AES-256
+const TamanhoChaveCifragem = 32
+
+// Decodifica JWT_KEY_ENCRYPTION_KEY: 32 bytes em base64 (gere com "openssl rand -base64 32")
+func DecodificarChaveCifragem(texto string) ([]byte, error) {
+	chave, err := base64.StdEncoding.DecodeString(strings.TrimSpace(texto))
+	if err != nil {
+		return nil, errors.New("a chave de cifragem deve estar em base64")
+	}
+	if len(chave) != TamanhoChaveCifragem {
+		return nil, fmt.Errorf("a chave de cifragem deve ter %d bytes, tem %d", TamanhoChaveCifragem, len(chave))
+	}
+	return chave, nil
+}
+
+func novaCifra(chave []byte) (cipher.AEAD, error) {
+	if len(chave) != TamanhoChaveCifragem {
+		return nil, fmt.Errorf("a chave de cifragem deve ter %d bytes, tem %d", TamanhoChaveCifragem, len(chave))
+	}
+	bloco, err := aes.NewCipher(chave)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(bloco)
+}
+
+// Cifra o PEM da chave privada para gravar na linha do kid
+func (keys *KeyManager) cifrar(kid, privadaPEM string) (string, error) {
+	nonce := make([]byte, keys.cifra.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	cifrado := keys.cifra.Seal(nonce, nonce, []byte(privadaPEM), []byte(kid))
+	return prefixoCifrada + base64.StdEncoding.EncodeToString(cifrado), nil
+}
+
+// PEM da chave privada gravada na linha do kid
+func (keys *KeyManager) decifrar(kid, gravada string) (string, error) {
+	if strings.HasPrefix(gravada, prefixoPEM) {
+		return gravada, nil
+	}
+	texto, ok := strings.CutPrefix(gravada, prefixoCifrada)
+	if !ok {
+		return "", errors.New("formato da chave gravada desconhecido")
+	}
+	cifrado, err := base64.StdEncoding.DecodeString(texto)
+	if err != nil || len(cifrado) < keys.cifra.NonceSize() {
+		return "", errors.New("chave cifrada inválida")
+	}
+	nonce, cifrado := cifrado[:keys.cifra.NonceSize()], cifrado[keys.cifra.NonceSize():]
+	privadaPEM, err := keys.cifra.Open(nil, nonce, cifrado, []byte(kid))
+	if err != nil {
+		return "", errors.New("não foi possível decifrar a chave; confira JWT_KEY_ENCRYPTION_KEY")
+	}
+	return string(privadaPEM), nil
+}
+
+// Cifra as chaves gravadas em PEM puro, de antes da cifragem. Roda na
+// transacao da rotacao, com o lock que impede duas replicas de regravarem juntas.
+func (keys *KeyManager) cifrarLegadas(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT kid, private_key FROM signing_keys WHERE private_key LIKE $1`, prefixoPEM+"%")
+	if err != nil {
+		return err
+	}
+	legadas := map[string]string{}
+	for rows.Next() {
+		var kid, privadaPEM string
+		if err := rows.Scan(&kid, &privadaPEM); err != nil {
+			rows.Close()
+			return err
+		}
+		legadas[kid] = privadaPEM
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(legadas) > 0 {
+		slog.InfoContext(ctx, "KeyManager: Cifrando chaves gravadas em PEM puro", "chaves", len(legadas))
+	}
+	for kid, privadaPEM := range legadas {
+		cifrada, err := keys.cifrar(kid, privadaPEM)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE signing_keys SET private_key = $1 WHERE kid = $2`, cifrada, kid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/migrations"
+)
+
+func chaveCifragemTeste(b byte) []byte {
+	return bytes.Repeat([]byte{b}, TamanhoChaveCifragem)
+}
+
+func TestDecodificarChaveCifragem(t *testing.T) {
+	chave := chaveCifragemTeste(7)
+	if decodificada, err := DecodificarChaveCifragem(base64.StdEncoding.EncodeToString(chave) + "\n"); err != nil || !bytes.Equal(decodificada, chave) {
+		t.Fatalf("chave = %v, erro %v", decodificada, err)
+	}
+	for _, invalida := range []string{"", "nao e base64!", base64.StdEncoding.EncodeToString(chave[:16])} {
+		if _, err := DecodificarChaveCifragem(invalida); err == nil {
+			t.Errorf("chave %q aceita", invalida)
+		}
+	}
+}
+
+func TestCifrarChave(t *testing.T) {
+	keys, err := NewKeyManager(nil, AlgEdDSA, time.Hour, time.Hour, chaveCifragemTeste(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nova, err := gerarChave(AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privadaPEM, err := codificarChave(nova.privada)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cifrada, err := keys.cifrar(nova.kid, privadaPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(cifrada, prefixoCifrada) || strings.Contains(cifrada, "PRIVATE KEY") {
+		t.Fatalf("chave gravada sem cifrar: %s", cifrada)
+	}
+	if decifrada, err := keys.decifrar(nova.kid, cifrada); err != nil || decifrada != privadaPEM {
+		t.Fatalf("decifrar = %q, erro %v", decifrada, err)
+	}
+
+	// Outra linha ou outra chave de cifragem nao decifram
+	if _, err := keys.decifrar("outro-kid", cifrada); err == nil {
+		t.Fatal("chave decifrada com outro kid")
+	}
+	outra, _ := NewKeyManager(nil, AlgEdDSA, time.Hour, time.Hour, chaveCifragemTeste(2))
+	if _, err := outra.decifrar(nova.kid, cifrada); err == nil {
+		t.Fatal("chave decifrada com outra chave de cifragem")
+	}
+
+	// Chaves gravadas antes da cifragem continuam legiveis
+	if decifrada, err := keys.decifrar(nova.kid, privadaPEM); err != nil || decifrada != privadaPEM {
+		t.Fatalf("PEM puro = %q, erro %v", decifrada, err)
+	}
+	if _, err := NewKeyManager(nil, AlgEdDSA, time.Hour, time.Hour, []byte("curta")); err == nil {
+		t.Fatal("chave de cifragem curta aceita")
+	}
+}
+
+// Iniciar grava as chaves novas cifradas e cifra as antigas em PEM puro
+func TestKeyManagerCifraNoBanco(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.AbrirSQLite(filepath.Join(t.TempDir(), "chaves.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrador, err := migrations.NewMigrador(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrador.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	antiga, _ := gerarChave(AlgEdDSA)
+	antigaPEM, _ := codificarChave(antiga.privada)
+	_, err = db.ExecContext(ctx, `INSERT INTO signing_keys (kid, alg, private_key, criado_em, expira_em) VALUES ($1, $2, $3, $4, $5)`,
+		antiga.kid, AlgEdDSA, antigaPEM, time.Now().UTC().Add(-2*time.Hour), time.Now().UTC().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewKeyManager(db, AlgEdDSA, time.Hour, time.Hour, chaveCifragemTeste(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Iniciar(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT kid, private_key FROM signing_keys`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	total := 0
+	for rows.Next() {
+		var kid, gravada string
+		if err := rows.Scan(&kid, &gravada); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(gravada, prefixoCifrada) {
+			t.Errorf("chave %s gravada sem cifrar", kid)
+		}
+		total++
+	}
+	if total != 2 || len(keys.JWKS().Keys) != 2 {
+		t.Fatalf("%d chaves no banco e %d no JWKS, esperado 2 (a antiga e a rotacionada)", total, len(keys.JWKS().Keys))
+	}
+
+	// Sem a chave de cifragem certa a aplicacao nao sobe
+	errada, _ := NewKeyManager(db, AlgEdDSA, time.Hour, time.Hour, chaveCifragemTeste(4))
+	if err := errada.Iniciar(ctx); err == nil {
+		t.Fatal("chaves carregadas com a chave de cifragem errada")
+	}
+}
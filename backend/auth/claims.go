@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Claims do token de acesso. O ID do usuario vai no "sub" e o da sessao no "sid".
+type Claims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	SID      string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// Retorna o ID do usuario dono do token. Aceita receptor nil
+// para quando a requisicao nao passou pelo JWTMiddleware.
+func (claims *Claims) UserID() (uuid.UUID, error) {
+	if claims == nil {
+		return uuid.Nil, errors.New("requisição sem usuário autenticado")
+	}
+	return uuid.Parse(claims.Subject)
+}
+
+// Retorna o ID da sessao que emitiu o token
+func (claims *Claims) SessionID() (uuid.UUID, error) {
+	if claims == nil {
+		return uuid.Nil, errors.New("requisição sem usuário autenticado")
+	}
+	return uuid.Parse(claims.SID)
+}
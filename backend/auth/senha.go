@@ -0,0 +1,30 @@
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+// Custo do bcrypt usado para novas senhas
+const custoSenha = 12
+
+// Hash usado quando o usuario nao existe, para que o tempo de resposta do login
+// nao revele quais usuarios estao cadastrados
+var hashFalso, _ = bcrypt.GenerateFromPassword([]byte("senha-inexistente"), custoSenha)
+
+// Gera o hash bcrypt de uma senha em texto puro
+func HashSenha(senha string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(senha), custoSenha)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Compara a senha informada com o hash salvo no banco.
+// Se hash for vazio (usuario nao encontrado) compara com um hash falso e retorna false.
+func VerificarSenha(hash, senha string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(hashFalso, []byte(senha))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(senha)) == nil
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/google/uuid"
+)
+
+// Duracoes das sessoes. A sessao tem validade absoluta; cada refresh token
+// vale por DuracaoRefresh, limitado ao fim da sessao.
+const (
+	DuracaoSessao  = 30 * 24 * time.Hour
+	DuracaoRefresh = 7 * 24 * time.Hour
+)
+
+var (
+	ErrRefreshInvalido    = errors.New("refresh token inválido ou expirado")
+	ErrRefreshReutilizado = errors.New("refresh token reutilizado, sessão revogada")
+)
+
+// Guarda sessoes e refresh tokens no banco
+type SessionStore struct {
+	DBConnection *sql.DB
+}
+
+// Construtor de SessionStore
+func NewSessionStore(dbConnection *sql.DB) *SessionStore {
+	return &SessionStore{DBConnection: dbConnection}
+}
+
+// Gera um refresh token aleatorio e o hash que vai para o banco
+func gerarRefreshToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Abre uma nova sessao para o usuario e retorna o ID da sessao e o primeiro refresh token
+func (store *SessionStore) Criar(ctx context.Context, userID uuid.UUID) (uuid.UUID, string, error) {
+	token, hash, err := gerarRefreshToken()
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	tx, err := store.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	defer tx.Rollback()
+
+	agora := time.Now().UTC()
+	var sessionID uuid.UUID
+	err = tx.QueryRowContext(ctx, `INSERT INTO sessions (user_id, expira_em) VALUES ($1, $2) RETURNING id`,
+		userID, agora.Add(DuracaoSessao)).Scan(&sessionID)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO refresh_tokens (token_hash, session_id, expira_em) VALUES ($1, $2, $3)`,
+		hash, sessionID, agora.Add(DuracaoRefresh))
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return sessionID, token, tx.Commit()
+}
+
+// Troca um refresh token por um novo. O token antigo fica marcado como usado;
+// se ele for apresentado de novo a sessao inteira e revogada.
+func (store *SessionStore) Rotacionar(ctx context.Context, refreshToken string) (userID uuid.UUID, sessionID uuid.UUID, novoToken string, err error) {
+	tx, err := store.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "", err
+	}
+	defer tx.Rollback()
+
+	var tokenExpiraEm, sessaoExpiraEm time.Time
+	var usadoEm, revogadaEm sql.NullTime
+	query := `SELECT rt.session_id, rt.expira_em, rt.usado_em, s.user_id, s.expira_em, s.revogada_em
+		FROM refresh_tokens rt JOIN sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = $1`
+	// No SQLite a transacao ja comeca com o banco travado para escrita
+	if !database.SQLite(store.DBConnection) {
+		query += ` FOR UPDATE OF rt, s`
+	}
+	err = tx.QueryRowContext(ctx, query, hashRefreshToken(refreshToken)).
+		Scan(&sessionID, &tokenExpiraEm, &usadoEm, &userID, &sessaoExpiraEm, &revogadaEm)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, uuid.Nil, "", ErrRefreshInvalido
+	}
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "", err
+	}
+
+	if usadoEm.Valid {
+		if _, err := tx.ExecContext(ctx, `UPDATE sessions SET revogada_em = now() WHERE id = $1 AND revogada_em IS NULL`, sessionID); err != nil {
+			return uuid.Nil, uuid.Nil, "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return uuid.Nil, uuid.Nil, "", err
+		}
+		return uuid.Nil, uuid.Nil, "", ErrRefreshReutilizado
+	}
+
+	agora := time.Now().UTC()
+	if revogadaEm.Valid || agora.After(tokenExpiraEm) || agora.After(sessaoExpiraEm) {
+		return uuid.Nil, uuid.Nil, "", ErrRefreshInvalido
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET usado_em = now() WHERE token_hash = $1`, hashRefreshToken(refreshToken)); err != nil {
+		return uuid.Nil, uuid.Nil, "", err
+	}
+
+	novoToken, novoHash, err := gerarRefreshToken()
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "", err
+	}
+	expiraEm := agora.Add(DuracaoRefresh)
+	if expiraEm.After(sessaoExpiraEm) {
+		expiraEm = sessaoExpiraEm
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO refresh_tokens (token_hash, session_id, expira_em) VALUES ($1, $2, $3)`,
+		novoHash, sessionID, expiraEm)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "", err
+	}
+
+	return userID, sessionID, novoToken, tx.Commit()
+}
+
+// Revoga a sessao. Access tokens com esse "sid" deixam de ser aceitos imediatamente.
+func (store *SessionStore) Revogar(ctx context.Context, sessionID uuid.UUID) error {
+	_, err := store.DBConnection.ExecContext(ctx, `UPDATE sessions SET revogada_em = now() WHERE id = $1 AND revogada_em IS NULL`, sessionID)
+	return err
+}
+
+// Revoga todas as sessoes do usuario e apaga seus refresh tokens, dentro de tx.
+// Usado junto com a alteracao que invalida os tokens ja emitidos, como a troca
+// de papel: os access tokens param de valer na hora e o refresh nao os renova.
+func (store *SessionStore) RevogarDoUsuario(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE session_id IN (SELECT id FROM sessions WHERE user_id = $1)`, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE sessions SET revogada_em = now() WHERE user_id = $1 AND revogada_em IS NULL`, userID)
+	return err
+}
+
+// Indica se a sessao existe, nao foi revogada e nao expirou
+func (store *SessionStore) Ativa(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	var ativa bool
+	err := store.DBConnection.QueryRowContext(ctx, `SELECT revogada_em IS NULL AND expira_em > now() FROM sessions WHERE id = $1`, sessionID).Scan(&ativa)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return ativa, err
+}
+
+// Apaga sessoes expiradas ou revogadas ha mais de um dia, junto com seus refresh tokens
+func (store *SessionStore) RemoverExpiradas(ctx context.Context) (int64, error) {
+	limite := time.Now().UTC().Add(-24 * time.Hour)
+	result, err := store.DBConnection.ExecContext(ctx, `DELETE FROM sessions WHERE expira_em < $1 OR revogada_em < $1`, limite)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Roda RemoverExpiradas a cada intervalo ate o contexto ser cancelado
+func (store *SessionStore) LimparPeriodicamente(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removidas, err := store.RemoverExpiradas(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "SessionStore: Erro ao remover sessões expiradas", "erro", err)
+				continue
+			}
+			if removidas > 0 {
+				slog.InfoContext(ctx, "SessionStore: Sessões expiradas removidas", "total", removidas)
+			}
+		}
+	}
+}
@@ -0,0 +1,412 @@
+// Package config carrega a configuracao da API e prepara as dependencias
+// (banco, storage, repositorio) a partir dela.
+//
+// Os valores vem, em ordem de prioridade: das variaveis de ambiente, do arquivo
+// .env (que nao sobrescreve o ambiente), do arquivo YAML ou TOML indicado em
+// CONFIG_FILE e dos padroes abaixo. Tudo e validado de uma vez na subida, e os
+// erros citam o nome da variavel de ambiente.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/auth"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/logging"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/tracing"
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuracao completa da API
+type Config struct {
+	Servidor    ServidorConfig `yaml:"servidor" toml:"servidor"`
+	DB          DBConfig       `yaml:"db" toml:"db"`
+	JWT         JWTConfig      `yaml:"jwt" toml:"jwt"`
+	Storage     StorageConfig  `yaml:"storage" toml:"storage"`
+	Log         LogConfig      `yaml:"log" toml:"log"`
+	Tracing     TracingConfig  `yaml:"tracing" toml:"tracing"`
+	Repositorio string         `yaml:"repositorio" toml:"repositorio"` // RECEITAS_REPOSITORY
+	AutoMigrate bool           `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+type ServidorConfig struct {
+	Porta       string   `yaml:"porta" toml:"porta"`               // PORT
+	OrigensCORS []string `yaml:"origens_cors" toml:"origens_cors"` // CORS_ORIGINS, separadas por virgula
+	// Endereco do servidor interno que expoe /metrics, separado da API publica.
+	// O padrao so aceita conexoes locais; use ":9090" para o Prometheus de outra maquina.
+	EnderecoMetricas string `yaml:"endereco_metricas" toml:"endereco_metricas"` // METRICS_ADDR
+	// Limites de http.Server. A escrita inclui o tempo do handler, entao precisa
+	// cobrir o envio e o processamento das imagens.
+	TimeoutCabecalho time.Duration `yaml:"timeout_cabecalho" toml:"timeout_cabecalho"`
+	TimeoutLeitura   time.Duration `yaml:"timeout_leitura" toml:"timeout_leitura"`
+	TimeoutEscrita   time.Duration `yaml:"timeout_escrita" toml:"timeout_escrita"`
+	TimeoutOcioso    time.Duration `yaml:"timeout_ocioso" toml:"timeout_ocioso"`
+	// Quanto tempo o servidor segue atendendo com o /readyz em falha antes de
+	// desligar, e quanto depois espera as requisicoes em andamento terminarem
+	AtrasoDesligamento time.Duration `yaml:"atraso_desligamento" toml:"atraso_desligamento"`
+	PrazoDesligamento  time.Duration `yaml:"prazo_desligamento" toml:"prazo_desligamento"`
+}
+
+type DBConfig struct {
+	Driver  string `yaml:"driver" toml:"driver"` // DB_DRIVER: postgres ou sqlite
+	Host    string `yaml:"host" toml:"host"`
+	Porta   string `yaml:"porta" toml:"porta"`
+	Nome    string `yaml:"nome" toml:"nome"`
+	Schema  string `yaml:"schema" toml:"schema"`
+	Usuario string `yaml:"usuario" toml:"usuario"`
+	Senha   string `yaml:"senha" toml:"senha"`
+	SSLMode string `yaml:"sslmode" toml:"sslmode"`
+	Caminho string `yaml:"caminho" toml:"caminho"` // Arquivo do SQLite
+	// Pool de conexoes; zero usa o padrao do database/sql (sem limite)
+	MaxAbertas       int           `yaml:"max_abertas" toml:"max_abertas"`
+	MaxOciosas       int           `yaml:"max_ociosas" toml:"max_ociosas"`
+	VidaMaxima       time.Duration `yaml:"vida_maxima" toml:"vida_maxima"`
+	OciosidadeMaxima time.Duration `yaml:"ociosidade_maxima" toml:"ociosidade_maxima"`
+}
+
+type JWTConfig struct {
+	Algoritmo    string        `yaml:"algoritmo" toml:"algoritmo"`
+	Rotacao      time.Duration `yaml:"rotacao" toml:"rotacao"`             // Idade das chaves de assinatura
+	DuracaoToken time.Duration `yaml:"duracao_token" toml:"duracao_token"` // Validade do access token
+	// JWT_KEY_ENCRYPTION_KEY: 32 bytes em base64 que cifram as chaves privadas
+	// gravadas no banco. Obrigatoria; prefira a variavel de ambiente ao arquivo.
+	ChaveCifragem string `yaml:"chave_cifragem" toml:"chave_cifragem"`
+}
+
+type LogConfig struct {
+	Nivel   string `yaml:"nivel" toml:"nivel"`     // LOG_LEVEL: debug, info, warn ou error
+	Formato string `yaml:"formato" toml:"formato"` // LOG_FORMAT: json ou text
+}
+
+type TracingConfig struct {
+	Exportador  string `yaml:"exportador" toml:"exportador"`     // TRACING_EXPORTER: none, stdout ou otlp
+	Endpoint    string `yaml:"endpoint" toml:"endpoint"`         // TRACING_OTLP_ENDPOINT; vazio usa OTEL_EXPORTER_OTLP_*
+	NomeServico string `yaml:"nome_servico" toml:"nome_servico"` // OTEL_SERVICE_NAME
+}
+
+type StorageConfig struct {
+	Tipo         string `yaml:"tipo" toml:"tipo"` // STORAGE: local ou s3
+	Diretorio    string `yaml:"diretorio" toml:"diretorio"`
+	S3Endpoint   string `yaml:"s3_endpoint" toml:"s3_endpoint"`
+	S3Regiao     string `yaml:"s3_regiao" toml:"s3_regiao"`
+	S3Bucket     string `yaml:"s3_bucket" toml:"s3_bucket"`
+	S3AccessKey  string `yaml:"s3_access_key" toml:"s3_access_key"`
+	S3SecretKey  string `yaml:"s3_secret_key" toml:"s3_secret_key"`
+	S3URLPublica string `yaml:"s3_url_publica" toml:"s3_url_publica"`
+}
+
+// JWT_KEY_ENCRYPTION_KEY do .env do repositorio, so para desenvolvimento.
+// E recusada com GO_ENV=production, para um deploy sem a variavel nao subir com ela.
+const chaveCifragemDesenvolvimento = "ZGV2LXNvbWVudGUtbmFvLXVzZS1lbS1wcm9kdWNhbyE="
+
+// Valores usados quando nada foi informado
+func padrao() Config {
+	return Config{
+		Servidor: ServidorConfig{
+			Porta:             "5555",
+			OrigensCORS:       []string{"http://localhost:5173"},
+			EnderecoMetricas:  "localhost:9090",
+			TimeoutCabecalho:  5 * time.Second,
+			TimeoutLeitura:    30 * time.Second,
+			TimeoutEscrita:    60 * time.Second,
+			TimeoutOcioso:     2 * time.Minute,
+			PrazoDesligamento: 20 * time.Second,
+		},
+		DB: DBConfig{
+			Driver:  database.DriverPostgres,
+			Host:    "localhost",
+			Porta:   "5432",
+			SSLMode: "disable",
+			Caminho: "receitas.db",
+		},
+		JWT:         JWTConfig{Algoritmo: auth.AlgEdDSA, Rotacao: 30 * 24 * time.Hour, DuracaoToken: auth.AccessTokenDuration},
+		Storage:     StorageConfig{Tipo: "local", Diretorio: "uploads"},
+		Log:         LogConfig{Nivel: "info", Formato: logging.FormatoJSON},
+		Tracing:     TracingConfig{Exportador: tracing.ExportadorNenhum, NomeServico: "api-receitas-culinarias"},
+		AutoMigrate: true,
+	}
+}
+
+// Le e valida a configuracao. O .env e o arquivo de CONFIG_FILE sao opcionais.
+func Carregar() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("erro ao carregar o arquivo .env: %w", err)
+	}
+
+	cfg := padrao()
+	if arquivo := os.Getenv("CONFIG_FILE"); arquivo != "" {
+		if err := lerArquivo(arquivo, &cfg); err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", arquivo, err)
+		}
+	}
+
+	env := &ambiente{}
+	env.texto(&cfg.Servidor.Porta, "PORT")
+	env.lista(&cfg.Servidor.OrigensCORS, "CORS_ORIGINS")
+	env.texto(&cfg.Servidor.EnderecoMetricas, "METRICS_ADDR")
+	env.duracao(&cfg.Servidor.TimeoutCabecalho, "HTTP_READ_HEADER_TIMEOUT")
+	env.duracao(&cfg.Servidor.TimeoutLeitura, "HTTP_READ_TIMEOUT")
+	env.duracao(&cfg.Servidor.TimeoutEscrita, "HTTP_WRITE_TIMEOUT")
+	env.duracao(&cfg.Servidor.TimeoutOcioso, "HTTP_IDLE_TIMEOUT")
+	env.duracao(&cfg.Servidor.AtrasoDesligamento, "SHUTDOWN_DELAY")
+	env.duracao(&cfg.Servidor.PrazoDesligamento, "SHUTDOWN_TIMEOUT")
+	env.texto(&cfg.DB.Driver, "DB_DRIVER")
+	env.texto(&cfg.DB.Host, "DB_HOST")
+	env.texto(&cfg.DB.Porta, "DB_PORT")
+	env.texto(&cfg.DB.Nome, "DB_NAME")
+	env.texto(&cfg.DB.Schema, "DB_SCHEMA")
+	env.texto(&cfg.DB.Usuario, "DB_USERNAME")
+	env.texto(&cfg.DB.Senha, "DB_PASSWORD")
+	env.texto(&cfg.DB.SSLMode, "DB_SSLMODE")
+	env.texto(&cfg.DB.Caminho, "DB_PATH")
+	env.inteiro(&cfg.DB.MaxAbertas, "DB_MAX_OPEN_CONNS")
+	env.inteiro(&cfg.DB.MaxOciosas, "DB_MAX_IDLE_CONNS")
+	env.duracao(&cfg.DB.VidaMaxima, "DB_CONN_MAX_LIFETIME")
+	env.duracao(&cfg.DB.OciosidadeMaxima, "DB_CONN_MAX_IDLE_TIME")
+	env.texto(&cfg.JWT.Algoritmo, "JWT_ALG")
+	env.duracao(&cfg.JWT.Rotacao, "JWT_KEY_ROTATION")
+	env.duracao(&cfg.JWT.DuracaoToken, "JWT_ACCESS_TTL")
+	env.texto(&cfg.JWT.ChaveCifragem, "JWT_KEY_ENCRYPTION_KEY")
+	env.texto(&cfg.Storage.Tipo, "STORAGE")
+	env.texto(&cfg.Storage.Diretorio, "STORAGE_DIR")
+	env.texto(&cfg.Storage.S3Endpoint, "S3_ENDPOINT")
+	env.texto(&cfg.Storage.S3Regiao, "S3_REGION")
+	env.texto(&cfg.Storage.S3Bucket, "S3_BUCKET")
+	env.texto(&cfg.Storage.S3AccessKey, "S3_ACCESS_KEY")
+	env.texto(&cfg.Storage.S3SecretKey, "S3_SECRET_KEY")
+	env.texto(&cfg.Storage.S3URLPublica, "S3_PUBLIC_URL")
+	env.texto(&cfg.Log.Nivel, "LOG_LEVEL")
+	env.texto(&cfg.Log.Formato, "LOG_FORMAT")
+	env.texto(&cfg.Tracing.Exportador, "TRACING_EXPORTER")
+	env.texto(&cfg.Tracing.Endpoint, "TRACING_OTLP_ENDPOINT")
+	env.texto(&cfg.Tracing.NomeServico, "OTEL_SERVICE_NAME")
+	env.texto(&cfg.Repositorio, "RECEITAS_REPOSITORY")
+	env.booleano(&cfg.AutoMigrate, "AUTO_MIGRATE")
+
+	if err := errors.Join(append(env.erros, cfg.validar()...)...); err != nil {
+		return nil, fmt.Errorf("configuração inválida:\n%w", err)
+	}
+	return &cfg, nil
+}
+
+// Preenche cfg com o arquivo YAML ou TOML, conforme a extensao. Chaves
+// desconhecidas sao erro, para que um nome digitado errado nao passe em silencio.
+func lerArquivo(caminho string, cfg *Config) error {
+	switch strings.ToLower(filepath.Ext(caminho)) {
+	case ".yaml", ".yml":
+		arquivo, err := os.Open(caminho)
+		if err != nil {
+			return err
+		}
+		defer arquivo.Close()
+		decoder := yaml.NewDecoder(arquivo)
+		decoder.KnownFields(true)
+		// Arquivo vazio (io.EOF) fica com os padroes
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	case ".toml":
+		meta, err := toml.DecodeFile(caminho, cfg)
+		if err != nil {
+			return err
+		}
+		if desconhecidas := meta.Undecoded(); len(desconhecidas) > 0 {
+			return fmt.Errorf("chave desconhecida: %s", desconhecidas[0])
+		}
+		return nil
+	default:
+		return errors.New("formato não suportado (use .yaml, .yml ou .toml)")
+	}
+}
+
+// Le as variaveis de ambiente que estiverem preenchidas, juntando os erros de formato
+type ambiente struct {
+	erros []error
+}
+
+func (env *ambiente) texto(destino *string, nome string) {
+	if valor := os.Getenv(nome); valor != "" {
+		*destino = valor
+	}
+}
+
+func (env *ambiente) lista(destino *[]string, nome string) {
+	valor := os.Getenv(nome)
+	if valor == "" {
+		return
+	}
+	*destino = nil
+	for _, item := range strings.Split(valor, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*destino = append(*destino, item)
+		}
+	}
+}
+
+func (env *ambiente) inteiro(destino *int, nome string) {
+	valor := os.Getenv(nome)
+	if valor == "" {
+		return
+	}
+	n, err := strconv.Atoi(valor)
+	if err != nil {
+		env.erros = append(env.erros, fmt.Errorf("%s: número inválido %q", nome, valor))
+		return
+	}
+	*destino = n
+}
+
+func (env *ambiente) duracao(destino *time.Duration, nome string) {
+	valor := os.Getenv(nome)
+	if valor == "" {
+		return
+	}
+	d, err := time.ParseDuration(valor)
+	if err != nil {
+		env.erros = append(env.erros, fmt.Errorf("%s: duração inválida %q (ex.: 30s, 15m, 720h)", nome, valor))
+		return
+	}
+	*destino = d
+}
+
+func (env *ambiente) booleano(destino *bool, nome string) {
+	valor := os.Getenv(nome)
+	if valor == "" {
+		return
+	}
+	b, err := strconv.ParseBool(valor)
+	if err != nil {
+		env.erros = append(env.erros, fmt.Errorf("%s: use true ou false, recebido %q", nome, valor))
+		return
+	}
+	*destino = b
+}
+
+// Modos aceitos pelo lib/pq em sslmode
+var modosSSL = []string{"disable", "require", "verify-ca", "verify-full"}
+
+// Confere os valores obrigatorios e os combinados entre si
+func (cfg *Config) validar() []error {
+	var erros []error
+	obrigatorio := func(valor, nome string) {
+		if valor == "" {
+			erros = append(erros, fmt.Errorf("%s é obrigatório", nome))
+		}
+	}
+	naoNegativo := func(valor int64, nome string) {
+		if valor < 0 {
+			erros = append(erros, fmt.Errorf("%s não pode ser negativo", nome))
+		}
+	}
+	positivo := func(valor time.Duration, nome string) {
+		if valor <= 0 {
+			erros = append(erros, fmt.Errorf("%s deve ser positivo", nome))
+		}
+	}
+
+	if !portaValida(cfg.Servidor.Porta) {
+		erros = append(erros, fmt.Errorf("PORT inválida: %q", cfg.Servidor.Porta))
+	}
+	if _, porta, err := net.SplitHostPort(cfg.Servidor.EnderecoMetricas); err != nil || !portaValida(porta) || porta == cfg.Servidor.Porta {
+		erros = append(erros, fmt.Errorf("METRICS_ADDR inválido: %q (use host:porta, em uma porta diferente de PORT)", cfg.Servidor.EnderecoMetricas))
+	}
+	if len(cfg.Servidor.OrigensCORS) == 0 {
+		erros = append(erros, errors.New("CORS_ORIGINS precisa de pelo menos uma origem"))
+	}
+	positivo(cfg.Servidor.TimeoutCabecalho, "HTTP_READ_HEADER_TIMEOUT")
+	positivo(cfg.Servidor.TimeoutLeitura, "HTTP_READ_TIMEOUT")
+	positivo(cfg.Servidor.TimeoutEscrita, "HTTP_WRITE_TIMEOUT")
+	positivo(cfg.Servidor.TimeoutOcioso, "HTTP_IDLE_TIMEOUT")
+	naoNegativo(int64(cfg.Servidor.AtrasoDesligamento), "SHUTDOWN_DELAY")
+	positivo(cfg.Servidor.PrazoDesligamento, "SHUTDOWN_TIMEOUT")
+
+	switch cfg.DB.Driver {
+	case database.DriverPostgres:
+		obrigatorio(cfg.DB.Host, "DB_HOST")
+		obrigatorio(cfg.DB.Porta, "DB_PORT")
+		obrigatorio(cfg.DB.Nome, "DB_NAME")
+		obrigatorio(cfg.DB.Usuario, "DB_USERNAME")
+		if !slices.Contains(modosSSL, cfg.DB.SSLMode) {
+			erros = append(erros, fmt.Errorf("DB_SSLMODE inválido: %q (use %s)", cfg.DB.SSLMode, strings.Join(modosSSL, ", ")))
+		}
+	case database.DriverSQLite:
+		obrigatorio(cfg.DB.Caminho, "DB_PATH")
+	default:
+		erros = append(erros, fmt.Errorf("DB_DRIVER inválido: %q (use postgres ou sqlite)", cfg.DB.Driver))
+	}
+	naoNegativo(int64(cfg.DB.MaxAbertas), "DB_MAX_OPEN_CONNS")
+	naoNegativo(int64(cfg.DB.MaxOciosas), "DB_MAX_IDLE_CONNS")
+	naoNegativo(int64(cfg.DB.VidaMaxima), "DB_CONN_MAX_LIFETIME")
+	naoNegativo(int64(cfg.DB.OciosidadeMaxima), "DB_CONN_MAX_IDLE_TIME")
+
+	if cfg.JWT.Algoritmo != auth.AlgEdDSA && cfg.JWT.Algoritmo != auth.AlgRS256 {
+		erros = append(erros, fmt.Errorf("JWT_ALG inválido: %q (use %s ou %s)", cfg.JWT.Algoritmo, auth.AlgEdDSA, auth.AlgRS256))
+	}
+	positivo(cfg.JWT.Rotacao, "JWT_KEY_ROTATION")
+	positivo(cfg.JWT.DuracaoToken, "JWT_ACCESS_TTL")
+	if cfg.JWT.ChaveCifragem == "" {
+		erros = append(erros, errors.New("JWT_KEY_ENCRYPTION_KEY é obrigatório (gere com: openssl rand -base64 32)"))
+	} else if _, err := auth.DecodificarChaveCifragem(cfg.JWT.ChaveCifragem); err != nil {
+		erros = append(erros, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY inválido: %w", err))
+	} else if cfg.JWT.ChaveCifragem == chaveCifragemDesenvolvimento && os.Getenv("GO_ENV") == "production" {
+		erros = append(erros, errors.New("JWT_KEY_ENCRYPTION_KEY é a chave de desenvolvimento do .env; defina outra em produção"))
+	}
+
+	switch cfg.Storage.Tipo {
+	case "local":
+		obrigatorio(cfg.Storage.Diretorio, "STORAGE_DIR")
+	case "s3":
+		obrigatorio(cfg.Storage.S3Endpoint, "S3_ENDPOINT")
+		obrigatorio(cfg.Storage.S3Bucket, "S3_BUCKET")
+		obrigatorio(cfg.Storage.S3AccessKey, "S3_ACCESS_KEY")
+		obrigatorio(cfg.Storage.S3SecretKey, "S3_SECRET_KEY")
+	default:
+		erros = append(erros, fmt.Errorf("STORAGE inválido: %q (use local ou s3)", cfg.Storage.Tipo))
+	}
+
+	var nivel slog.Level
+	if err := nivel.UnmarshalText([]byte(cfg.Log.Nivel)); err != nil {
+		erros = append(erros, fmt.Errorf("LOG_LEVEL inválido: %q (use debug, info, warn ou error)", cfg.Log.Nivel))
+	}
+	if cfg.Log.Formato != logging.FormatoJSON && cfg.Log.Formato != logging.FormatoTexto {
+		erros = append(erros, fmt.Errorf("LOG_FORMAT inválido: %q (use %s ou %s)", cfg.Log.Formato, logging.FormatoJSON, logging.FormatoTexto))
+	}
+
+	switch cfg.Tracing.Exportador {
+	case tracing.ExportadorNenhum, tracing.ExportadorStdout, tracing.ExportadorOTLP:
+	default:
+		erros = append(erros, fmt.Errorf("TRACING_EXPORTER inválido: %q (use %s, %s ou %s)", cfg.Tracing.Exportador, tracing.ExportadorNenhum, tracing.ExportadorStdout, tracing.ExportadorOTLP))
+	}
+	obrigatorio(cfg.Tracing.NomeServico, "OTEL_SERVICE_NAME")
+
+	switch cfg.Repositorio {
+	case "", "memoria":
+	case database.DriverPostgres, database.DriverSQLite:
+		if cfg.Repositorio != cfg.DB.Driver {
+			erros = append(erros, fmt.Errorf("RECEITAS_REPOSITORY=%s exige DB_DRIVER=%s", cfg.Repositorio, cfg.Repositorio))
+		}
+	default:
+		erros = append(erros, fmt.Errorf("RECEITAS_REPOSITORY inválido: %q (use postgres, sqlite ou memoria)", cfg.Repositorio))
+	}
+	return erros
+}
+
+// Porta TCP em texto, de 1 a 65535
+func portaValida(texto string) bool {
+	porta, err := strconv.Atoi(texto)
+	return err == nil && porta >= 1 && porta <= 65535
+}
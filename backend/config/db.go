@@ -4,44 +4,71 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
+	"log/slog"
 
-	"github.com/joho/godotenv"
-	_ "github.com/lib/pq" // _ porque nao esta sendo usado diretamente, mas precisa ser importado para registrar o driver
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
 )
 
 // Retorna uma instancia do banco de dados
 // Cria a conexao com o banco de dados
-// e retorna o enderco de memoria aonde foi salva a variel do endereco da conexao
-func SetupDB() *sql.DB {
-	err := godotenv.Load() // Carrega as variaveis de ambiente do arquivo .env
+// e retorna o enderco de memoria aonde foi salva a variel do endereco da conexao.
+// cfg.Driver escolhe o banco: "postgres" ou "sqlite", que usa o arquivo cfg.Caminho.
+func SetupDB(cfg DBConfig) *sql.DB {
+	if cfg.Driver == database.DriverSQLite {
+		return setupSQLite(cfg)
+	}
+	return setupPostgres(cfg)
+}
+
+func setupPostgres(cfg DBConfig) *sql.DB {
+	connectionStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Porta, cfg.Usuario, cfg.Senha, cfg.Nome, cfg.SSLMode)
+	if cfg.Schema != "" {
+		connectionStr += " search_path=" + cfg.Schema
+	}
+
+	dbConnection, err := database.AbrirPostgres(connectionStr)
+
 	if err != nil {
-		log.Fatal("Erro ao carregar o arquivo .env")
+		log.Fatal(err)
 	}
 
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbUser := os.Getenv("DB_USERNAME")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-	dbSchema := os.Getenv("DB_SCHEMA")
+	configurarPool(dbConnection, cfg)
+	err = dbConnection.Ping()
 
-	connectionStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s search_path=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName, dbSchema)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	dbConnection, err := sql.Open("postgres", connectionStr)
+	slog.Info("Banco: Conexão com o PostgreSQL estabelecida", "host", cfg.Host, "porta", cfg.Porta, "banco", cfg.Nome, "schema", cfg.Schema)
+
+	return dbConnection
+}
 
+func setupSQLite(cfg DBConfig) *sql.DB {
+	dbConnection, err := database.AbrirSQLite(cfg.Caminho)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	configurarPool(dbConnection, cfg)
 	err = dbConnection.Ping()
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Println("Conexão com o banco de dados estabelecida com sucesso!")
+	slog.Info("Banco: SQLite aberto", "caminho", cfg.Caminho)
 
 	return dbConnection
 }
+
+// Limites do pool de conexoes; zero mantem o padrao do database/sql
+func configurarPool(dbConnection *sql.DB, cfg DBConfig) {
+	dbConnection.SetMaxOpenConns(cfg.MaxAbertas)
+	if cfg.MaxOciosas > 0 {
+		dbConnection.SetMaxIdleConns(cfg.MaxOciosas)
+	}
+	dbConnection.SetConnMaxLifetime(cfg.VidaMaxima)
+	dbConnection.SetConnMaxIdleTime(cfg.OciosidadeMaxima)
+}
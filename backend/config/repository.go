@@ -0,0 +1,22 @@
+package config
+
+import (
+	"database/sql"
+	"log/slog"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/repository"
+)
+
+// Retorna o repositorio das receitas conforme tipo (RECEITAS_REPOSITORY): vazio
+// usa o banco da conexao e "memoria" guarda tudo em memoria, perdido ao reiniciar.
+func SetupReceitaRepository(tipo string, db *sql.DB) repository.ReceitaRepository {
+	if tipo == "memoria" {
+		slog.Warn("Repositório: Receitas em memória, nada será gravado no banco", "repositorio", tipo)
+		return repository.NewMemoria()
+	}
+	if database.SQLite(db) {
+		return repository.NewSQLite(db)
+	}
+	return repository.NewPostgres(db)
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"log"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/storage"
+)
+
+// Retorna o storage das imagens conforme cfg.Tipo: "local" grava em
+// cfg.Diretorio e "s3" usa um bucket compativel com S3, como um MinIO local.
+func SetupStorage(cfg StorageConfig) storage.Storage {
+	if cfg.Tipo == "s3" {
+		s3, err := storage.NewS3(cfg.S3Endpoint, cfg.S3Regiao, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3URLPublica)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return s3
+	}
+
+	local, err := storage.NewLocal(cfg.Diretorio, "/midia")
+	if err != nil {
+		log.Fatalf("Erro ao preparar o diretório de uploads: %v", err)
+	}
+	return local
+}
@@ -0,0 +1,117 @@
+// Package database abre a conexao com o banco configurado e concentra o que
+// muda entre os dois suportados: o PostgreSQL de producao e o SQLite, que roda
+// embutido no binario para uso local (notebook, Raspberry Pi).
+//
+// As consultas dos handlers e do pacote auth sao as mesmas nos dois: o SQLite
+// aceita $1, RETURNING e IS NOT DISTINCT FROM, e a conexao aberta aqui
+// registra a funcao now() e as de RegistrarFuncaoSQLite. O que nao tem
+// equivalente direto (advisory locks, FOR UPDATE) e condicionado a SQLite(db).
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Valores aceitos em DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Driver SQLite com as funcoes extras
+var driverSQLite = &sqlite3.SQLiteDriver{ConnectHook: prepararSQLite}
+
+// Funcoes em Go registradas pelos outros pacotes em cada conexao SQLite
+var funcoesSQLite = map[string]funcaoSQLite{}
+
+type funcaoSQLite struct {
+	implementacao any
+	pura          bool
+}
+
+// Registra uma funcao em Go para as consultas no SQLite, como RegisterFunc do
+// go-sqlite3. Deve ser chamada no init do pacote, antes de abrir o banco.
+// pura indica que o resultado depende apenas dos argumentos.
+func RegistrarFuncaoSQLite(nome string, implementacao any, pura bool) {
+	funcoesSQLite[nome] = funcaoSQLite{implementacao: implementacao, pura: pura}
+}
+
+// Funcoes do Postgres usadas nas consultas compartilhadas, e as registradas
+// com RegistrarFuncaoSQLite
+func prepararSQLite(conn *sqlite3.SQLiteConn) error {
+	// Mesmo formato que o driver usa para gravar time.Time. Os horarios sao
+	// comparados como texto, por isso a aplicacao grava tudo em UTC.
+	err := conn.RegisterFunc("now", func() string {
+		return time.Now().UTC().Format(sqlite3.SQLiteTimestampFormats[0])
+	}, false)
+	if err != nil {
+		return err
+	}
+	for nome, funcao := range funcoesSQLite {
+		if err := conn.RegisterFunc(nome, funcao.implementacao, funcao.pura); err != nil {
+			return fmt.Errorf("erro ao registrar a função %s no SQLite: %w", nome, err)
+		}
+	}
+	return nil
+}
+
+// Abre o arquivo SQLite, criando-o se nao existir. As transacoes comecam com
+// BEGIN IMMEDIATE: a escrita fica serializada desde o inicio, o que faz o papel
+// dos locks de linha e advisory locks do Postgres.
+func AbrirSQLite(caminho string) (*sql.DB, error) {
+	parametros := url.Values{
+		"_foreign_keys": {"on"},
+		"_journal_mode": {"WAL"},
+		"_busy_timeout": {"5000"},
+		"_txlock":       {"immediate"},
+	}
+	conector, err := novoConector(driverSQLite, "file:"+caminho+"?"+parametros.Encode(), semconv.DBSystemNameSQLite)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir o banco SQLite %s: %w", caminho, err)
+	}
+	return sql.OpenDB(conector), nil
+}
+
+// Abre o pool do PostgreSQL a partir da string de conexao do lib/pq
+func AbrirPostgres(dsn string) (*sql.DB, error) {
+	conector, err := novoConector(&pq.Driver{}, dsn, semconv.DBSystemNamePostgreSQL)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(conector), nil
+}
+
+// Indica se a conexao e com um banco SQLite
+func SQLite(dbConnection *sql.DB) bool {
+	_, ok := dbConnection.Driver().(*sqlite3.SQLiteDriver)
+	return ok
+}
+
+// Valor repetido em uma coluna ou indice unico
+func ViolacaoUnica(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505" // unique_violation
+	}
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+// Referencia a uma linha que nao existe (ou deixou de existir)
+func ViolacaoChaveEstrangeira(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23503" // foreign_key_violation
+	}
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Nome do tracer dos spans de consulta
+const nomeTracer = "github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+
+// Connector que devolve conexoes rastreadas: cada consulta feita com um
+// contexto que ja tem um span (o da requisicao) vira um span filho com o SQL,
+// sem os argumentos. Consultas fora de uma requisicao (migrations, limpezas em
+// segundo plano) nao geram spans.
+type conectorRastreado struct {
+	driver.Connector
+	sistema attribute.KeyValue
+}
+
+// Envolve o driver; usa o Connector dele quando existe (lib/pq)
+func novoConector(drv driver.Driver, dsn string, sistema attribute.KeyValue) (driver.Connector, error) {
+	var conector driver.Connector = conectorDSN{drv, dsn}
+	if driverContext, ok := drv.(driver.DriverContext); ok {
+		var err error
+		if conector, err = driverContext.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return conectorRastreado{conector, sistema}, nil
+}
+
+func (conector conectorRastreado) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := conector.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conexaoRastreada{Conn: conn, sistema: conector.sistema}, nil
+}
+
+// Connector para drivers que so tem Open (go-sqlite3)
+type conectorDSN struct {
+	drv driver.Driver
+	dsn string
+}
+
+func (conector conectorDSN) Connect(context.Context) (driver.Conn, error) {
+	return conector.drv.Open(conector.dsn)
+}
+
+func (conector conectorDSN) Driver() driver.Driver {
+	return conector.drv
+}
+
+// Conexao que cria os spans em QueryContext e ExecContext. As demais
+// interfaces opcionais do database/sql sao repassadas ao driver, ou caem no
+// comportamento padrao quando ele nao as implementa.
+type conexaoRastreada struct {
+	driver.Conn
+	sistema attribute.KeyValue
+}
+
+func (conexao *conexaoRastreada) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := conexao.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := conexao.iniciarSpan(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	encerrarSpan(span, err)
+	return rows, err
+}
+
+func (conexao *conexaoRastreada) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := conexao.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := conexao.iniciarSpan(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	encerrarSpan(span, err)
+	return result, err
+}
+
+func (conexao *conexaoRastreada) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := conexao.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return conexao.Conn.Prepare(query)
+}
+
+func (conexao *conexaoRastreada) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := conexao.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.ReadOnly || opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("o driver não suporta opções de transação")
+	}
+	return conexao.Conn.Begin()
+}
+
+func (conexao *conexaoRastreada) Ping(ctx context.Context) error {
+	if pinger, ok := conexao.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (conexao *conexaoRastreada) ResetSession(ctx context.Context) error {
+	if resetter, ok := conexao.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (conexao *conexaoRastreada) IsValid() bool {
+	if validator, ok := conexao.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (conexao *conexaoRastreada) CheckNamedValue(valor *driver.NamedValue) error {
+	if checker, ok := conexao.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(valor)
+	}
+	return driver.ErrSkip
+}
+
+// Inicia o span da consulta, nomeado pela operacao (SELECT, INSERT...), se o
+// contexto ja estiver dentro de um trace
+func (conexao *conexaoRastreada) iniciarSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, nil
+	}
+	operacao := operacaoSQL(query)
+	return otel.Tracer(nomeTracer).Start(ctx, operacao,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(conexao.sistema, semconv.DBOperationName(operacao), semconv.DBQueryText(query)),
+	)
+}
+
+func encerrarSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	// ErrSkip nao e falha: o database/sql repete a consulta por outro caminho
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Primeira palavra da consulta, em maiusculas
+func operacaoSQL(query string) string {
+	campos := strings.Fields(query)
+	if len(campos) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(campos[0])
+}
@@ -15,38 +15,55 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/receitas": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Publica as chaves públicas (JWKS) usadas para validar os tokens emitidos por esta API, identificadas pelo kid",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Chaves públicas de assinatura",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.JWKSet"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/categorias": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retorna todas as receitas cadastradas no banco de dados",
+                "description": "Retorna as classificações em ordem alfabética, com o número de receitas de cada uma.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "receitas"
+                    "classificacoes"
                 ],
-                "summary": "Lista todas as receitas",
+                "summary": "Lista tags, categorias ou cozinhas",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Receita"
+                                "$ref": "#/definitions/models.Classificacao"
                             }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     }
                 }
@@ -57,7 +74,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Adiciona uma nova receita com nome, descrição, ingredientes e instruções",
+                "description": "Sem \"slug\", ele é gerado a partir do nome (\"Rápido e fácil\" vira \"rapido-e-facil\"). Exige papel editor.",
                 "consumes": [
                     "application/json"
                 ],
@@ -65,56 +82,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "receitas"
+                    "classificacoes"
                 ],
-                "summary": "Cria uma nova receita",
+                "summary": "Cria uma tag, categoria ou cozinha",
                 "parameters": [
                     {
-                        "description": "Dados da nova receita",
-                        "name": "receita",
+                        "description": "Nome e slug opcional",
+                        "name": "classificacao",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Receita"
+                            "$ref": "#/definitions/models.Classificacao"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Receita"
+                            "$ref": "#/definitions/models.Classificacao"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     }
                 }
             }
         },
-        "/api/receitas/{id}": {
+        "/api/categorias/{slug}": {
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Atualiza os dados de uma receita existente",
+                "description": "Altera o nome e, opcionalmente, o slug. As receitas continuam ligadas à classificação. Exige papel admin.",
                 "consumes": [
                     "application/json"
                 ],
@@ -122,24 +145,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "receitas"
+                    "classificacoes"
                 ],
-                "summary": "Atualiza uma receita",
+                "summary": "Renomeia uma tag, categoria ou cozinha",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID da receita (UUID)",
-                        "name": "id",
+                        "description": "Slug atual",
+                        "name": "slug",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Dados atualizados da receita",
-                        "name": "receita",
+                        "description": "Novo nome e slug opcional",
+                        "name": "classificacao",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Receita"
+                            "$ref": "#/definitions/models.Classificacao"
                         }
                     }
                 ],
@@ -147,34 +170,37 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Receita"
+                            "$ref": "#/definitions/models.Classificacao"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     }
                 }
@@ -185,19 +211,19 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove uma receita do banco de dados pelo ID",
+                "description": "As receitas não são removidas: perdem a tag, ou ficam sem categoria ou cozinha. Exige papel admin.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "receitas"
+                    "classificacoes"
                 ],
-                "summary": "Deleta uma receita",
+                "summary": "Remove uma tag, categoria ou cozinha",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID da receita (UUID)",
-                        "name": "id",
+                        "description": "Slug",
+                        "name": "slug",
                         "in": "path",
                         "required": true
                     }
@@ -209,58 +235,2049 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cozinhas": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retorna as classificações em ordem alfabética, com o número de receitas de cada uma.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "classificacoes"
+                ],
+                "summary": "Lista tags, categorias ou cozinhas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Classificacao"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sem \"slug\", ele é gerado a partir do nome (\"Rápido e fácil\" vira \"rapido-e-facil\"). Exige papel editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "classificacoes"
+                ],
+                "summary": "Cria uma tag, categoria ou cozinha",
+                "parameters": [
+                    {
+                        "description": "Nome e slug opcional",
+                        "name": "classificacao",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Classificacao"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Classificacao"
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cozinhas/{slug}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Altera o nome e, opcionalmente, o slug. As receitas continuam ligadas à classificação. Exige papel admin.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "classificacoes"
+                ],
+                "summary": "Renomeia uma tag, categoria ou cozinha",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug atual",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Novo nome e slug opcional",
+                        "name": "classificacao",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Classificacao"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Classificacao"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "As receitas não são removidas: perdem a tag, ou ficam sem categoria ou cozinha. Exige papel admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "classificacoes"
+                ],
+                "summary": "Remove uma tag, categoria ou cozinha",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Receita": {
-            "type": "object",
-            "properties": {
-                "descricao": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "ingredientes": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        },
+        "/api/receitas": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
-                },
-                "instrucoes": {
-                    "type": "string"
-                },
-                "nome": {
-                    "type": "string"
+                ],
+                "description": "Retorna as receitas em páginas. O cabeçalho Link traz a URL da próxima página (rel=\"next\") e X-Total-Count o total de receitas que atendem aos filtros.\nCom \"q\" faz uma busca textual em português (sem diferenciar acentos) no nome, descrição, ingredientes e instruções.\nAceita a sintaxe de busca na web: \"frase exata\", -excluir e OR. Por padrão os resultados vêm ordenados por relevância, com os termos encontrados marcados em \"busca\".\nCom \"tem\" filtra pelos ingredientes disponíveis, sem diferenciar acentos e plurais; \"farinha\" cobre \"farinha de trigo\". Ingredientes a gosto ou opcionais não contam, e \"despensa\" lista o que falta em cada receita.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Lista as receitas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Texto da busca",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ingredientes disponíveis, separados por vírgula (ex.: ovo,farinha,leite). Retorna as receitas que dá para fazer com eles, das que menos faltam ingredientes para as que mais faltam",
+                        "name": "tem",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Com tem, aceita receitas em que faltam até este número de ingredientes (padrão 0)",
+                        "name": "faltando_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slugs de tags separados por vírgula; a receita precisa ter todas (ex.: vegano,rapido)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slug da categoria",
+                        "name": "categoria",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slug da cozinha",
+                        "name": "cozinha",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tempo total mínimo, em minutos",
+                        "name": "tempo_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tempo total máximo, em minutos. Receitas sem tempo informado ficam de fora",
+                        "name": "tempo_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tempo de preparo máximo, em minutos",
+                        "name": "preparo_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dificuldades aceitas, separadas por vírgula (facil, media, dificil)",
+                        "name": "dificuldade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Envolve a resposta em {receitas, facetas} com o número de receitas por tag, categoria e cozinha dentro dos filtros",
+                        "name": "facetas",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "nome",
+                            "-nome",
+                            "criado_em",
+                            "-criado_em",
+                            "relevancia",
+                            "-relevancia",
+                            "faltando",
+                            "-faltando"
+                        ],
+                        "type": "string",
+                        "description": "Ordenação; prefixo - para decrescente. Padrão: nome, -relevancia com q ou faltando com tem",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Receitas por página (1 a 100, padrão 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor da próxima página, copiado do cabeçalho Link",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campos retornados, separados por vírgula (ex.: nome,descricao). O id sempre é incluído",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metrico",
+                            "imperial",
+                            "caseiro"
+                        ],
+                        "type": "string",
+                        "description": "Exibe as quantidades no sistema de unidades pedido",
+                        "name": "sistema",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lista de receitas, ou RespostaFacetada com facetas=true",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Receita"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL da próxima página"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Total de receitas que atendem aos filtros"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adiciona uma nova receita com nome, descrição, ingredientes e instruções.\nOs ingredientes podem vir como texto em \"ingredientes\" ou estruturados em \"ingredientes_estruturados\" (que tem prioridade).\nDa mesma forma, o modo de preparo pode vir em \"passos\" ou como texto em \"instrucoes\", que é dividido nas linhas numeradas ou nos parágrafos.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Cria uma nova receita",
+                "parameters": [
+                    {
+                        "description": "Dados da nova receita",
+                        "name": "receita",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Receita"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Receita"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/receitas/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retorna uma única receita com base no ID fornecido.\nCom \"porcoes\" ou \"fator\" as quantidades são escaladas, arredondadas para frações práticas e, quando faz sentido, convertidas para uma unidade maior (16 colheres de sopa viram 1 xícara).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Busca uma receita por ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da receita (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Escala a receita para este número de porções (exige que a receita informe porções)",
+                        "name": "porcoes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Multiplica todas as quantidades por este fator (ex.: 0.5 para meia receita)",
+                        "name": "fator",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metrico",
+                            "imperial",
+                            "caseiro"
+                        ],
+                        "type": "string",
+                        "description": "Exibe as quantidades no sistema de unidades pedido",
+                        "name": "sistema",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Receita"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Atualiza os dados de uma receita existente. Apenas o autor ou um admin pode alterar.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Atualiza uma receita",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da receita (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dados atualizados da receita",
+                        "name": "receita",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Receita"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Receita"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove uma receita do banco de dados pelo ID. Apenas o autor ou um admin pode remover.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Deleta uma receita",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da receita (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/receitas/{id}/capa": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Recebe uma imagem JPEG, PNG, GIF ou WebP de até 10 MB no campo \"imagem\" e gera as miniaturas pequena (160px), media (480px) e grande (1024px). Substitui a capa anterior.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Envia a capa da receita",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da receita (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Imagem da capa",
+                        "name": "imagem",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Imagem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Remove a capa da receita",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da receita (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/receitas/{id}/passos/{passo}/imagem": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Como a capa, mas para o passo na posição indicada (a partir de 0). A foto fica com a posição: reordenar os passos não a acompanha, e remover o passo a apaga.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Envia a foto de um passo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da receita (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Posição do passo, a partir de 0",
+                        "name": "passo",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Foto do passo",
+                        "name": "imagem",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Imagem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receitas"
+                ],
+                "summary": "Remove a foto de um passo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da receita (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Posição do passo, a partir de 0",
+                        "name": "passo",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tags": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retorna as classificações em ordem alfabética, com o número de receitas de cada uma.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "classificacoes"
+                ],
+                "summary": "Lista tags, categorias ou cozinhas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Classificacao"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sem \"slug\", ele é gerado a partir do nome (\"Rápido e fácil\" vira \"rapido-e-facil\"). Exige papel editor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "classificacoes"
+                ],
+                "summary": "Cria uma tag, categoria ou cozinha",
+                "parameters": [
+                    {
+                        "description": "Nome e slug opcional",
+                        "name": "classificacao",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Classificacao"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Classificacao"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tags/{slug}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Altera o nome e, opcionalmente, o slug. As receitas continuam ligadas à classificação. Exige papel admin.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "classificacoes"
+                ],
+                "summary": "Renomeia uma tag, categoria ou cozinha",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug atual",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Novo nome e slug opcional",
+                        "name": "classificacao",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Classificacao"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Classificacao"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "As receitas não são removidas: perdem a tag, ou ficam sem categoria ou cozinha. Exige papel admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "classificacoes"
+                ],
+                "summary": "Remove uma tag, categoria ou cozinha",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retorna todos os usuários cadastrados com seus papéis. Exige papel admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Lista os usuários",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Define o papel (admin, editor ou reader) de um usuário. Exige papel admin. Quando o papel muda, as sessões do usuário são encerradas na hora: os tokens já emitidos deixam de valer e ele precisa entrar de novo para receber um token com o novo papel.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Altera o papel de um usuário",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do usuário (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Novo papel",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Responde 200 enquanto o processo atende requisições, sem consultar dependências",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Verifica se o processo está no ar",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Verifica usuário e senha, abre uma sessão e retorna um token JWT válido por 1 hora e um refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Autentica um usuário",
+                "parameters": [
+                    {
+                        "description": "Usuário e senha",
+                        "name": "usuario",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoga a sessão do access token informado. O refresh token e todos os access tokens da sessão deixam de ser aceitos.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Encerra a sessão",
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/midia/{chave}": {
+            "get": {
+                "description": "Retorna a imagem original ou uma miniatura. Cada envio gera uma chave nova, então as respostas podem ficar em cache indefinidamente.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
+                ],
+                "tags": [
+                    "midia"
+                ],
+                "summary": "Arquivo de imagem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chave do arquivo, como aparece nas URLs das imagens",
+                        "name": "chave",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Confere o banco (ping), se todas as migrations deste binário foram aplicadas e se há chave de assinatura carregada. Responde 503 com o detalhe de cada dependência quando alguma falha ou durante o desligamento.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Verifica se a API está pronta para receber tráfego",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Troca um refresh token por um novo access token e um novo refresh token. Cada refresh token só pode ser usado uma vez; reutilizar um token revoga a sessão.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Renova o access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token recebido no login ou na última renovação",
+                        "name": "refresh",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Cria uma conta com usuário e senha. A senha é salva apenas como hash bcrypt. Todo usuário começa como reader; o primeiro admin é criado com o subcomando promote.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Cadastra um novo usuário",
+                "parameters": [
+                    {
+                        "description": "Usuário e senha",
+                        "name": "usuario",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problema.Problema"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auth.JWK"
+                    }
+                }
+            }
+        },
+        "handlers.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "verificacoes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/handlers.Verificacao"
+                    }
+                }
+            }
+        },
+        "handlers.RefreshRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RoleRequest": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "editor"
+                }
+            }
+        },
+        "handlers.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "Segundos ate o access token expirar",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.Verificacao": {
+            "type": "object",
+            "properties": {
+                "detalhes": {
+                    "type": "string",
+                    "example": "versão 15"
+                },
+                "erro": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "models.Classificacao": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "nome": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "total_receitas": {
+                    "description": "Calculado na leitura, ignorado na entrada",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Imagem": {
+            "type": "object",
+            "properties": {
+                "altura": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "largura": {
+                    "description": "Da original, em pixels",
+                    "type": "integer"
+                },
+                "miniaturas": {
+                    "description": "URL por tamanho: pequena, media e grande",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "description": "Arquivo original",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Ingrediente": {
+            "type": "object",
+            "properties": {
+                "nome": {
+                    "type": "string"
+                },
+                "observacao": {
+                    "type": "string"
+                },
+                "quantidade": {
+                    "type": "number"
+                },
+                "quantidade_max": {
+                    "description": "Fim da faixa em \"2 a 3 tomates\"",
+                    "type": "number"
+                },
+                "unidade": {
+                    "description": "Nome canonico no singular (ver units.Padrao)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Passo": {
+            "type": "object",
+            "properties": {
+                "duracao_segundos": {
+                    "description": "Timer do passo",
+                    "type": "integer"
+                },
+                "foto": {
+                    "description": "Enviada em /receitas/{id}/passos/{passo}/imagem, ignorada na entrada",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Imagem"
+                        }
+                    ]
+                },
+                "imagem": {
+                    "description": "URL http(s) ou caminho servido pela API",
+                    "type": "string"
+                },
+                "ingredientes": {
+                    "description": "Posicoes (a partir de 0) em ingredientes_estruturados",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "temperatura": {
+                    "type": "number"
+                },
+                "texto": {
+                    "type": "string"
+                },
+                "unidade_temperatura": {
+                    "description": "°C quando omitida",
+                    "type": "string",
+                    "enum": [
+                        "°C",
+                        "°F"
+                    ]
+                }
+            }
+        },
+        "models.Receita": {
+            "type": "object",
+            "properties": {
+                "autor_id": {
+                    "description": "Preenchido pelo token, ignorado na entrada",
+                    "type": "string"
+                },
+                "busca": {
+                    "description": "Apenas nas buscas com ?q=, ignorado na entrada",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResultadoBusca"
+                        }
+                    ]
+                },
+                "capa": {
+                    "description": "Enviada em /receitas/{id}/capa, ignorada na entrada",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Imagem"
+                        }
+                    ]
+                },
+                "categoria": {
+                    "description": "Slug da categoria",
+                    "type": "string"
+                },
+                "cozinha": {
+                    "description": "Slug da cozinha",
+                    "type": "string"
+                },
+                "criado_em": {
+                    "description": "Definido pelo banco, ignorado na entrada",
+                    "type": "string"
+                },
+                "descricao": {
+                    "type": "string"
+                },
+                "despensa": {
+                    "description": "Apenas nos filtros com ?tem=, ignorado na entrada",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResultadoDespensa"
+                        }
+                    ]
+                },
+                "dificuldade": {
+                    "type": "string",
+                    "enum": [
+                        "facil",
+                        "media",
+                        "dificil"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ingredientes": {
+                    "description": "Texto de cada ingrediente, gerado a partir da forma estruturada",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ingredientes_estruturados": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Ingrediente"
+                    }
+                },
+                "instrucoes": {
+                    "description": "Texto gerado a partir dos passos",
+                    "type": "string"
+                },
+                "nome": {
+                    "type": "string"
+                },
+                "passos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Passo"
+                    }
+                },
+                "porcoes": {
+                    "description": "Quantas porcoes a receita rende, 0 se nao informado",
+                    "type": "integer"
+                },
+                "tags": {
+                    "description": "Slugs das tags",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tempo_cozimento": {
+                    "description": "Minutos",
+                    "type": "integer"
+                },
+                "tempo_descanso": {
+                    "description": "Minutos",
+                    "type": "integer"
+                },
+                "tempo_preparo": {
+                    "description": "Minutos",
+                    "type": "integer"
+                },
+                "tempo_total": {
+                    "description": "Minutos; sem valor, soma dos demais",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ResultadoBusca": {
+            "type": "object",
+            "properties": {
+                "nome": {
+                    "description": "Nome com os termos encontrados entre \u003cmark\u003e\u003c/mark\u003e",
+                    "type": "string"
+                },
+                "relevancia": {
+                    "type": "number"
+                },
+                "trecho": {
+                    "description": "Trechos da descricao, ingredientes e instrucoes com os termos marcados",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResultadoDespensa": {
+            "type": "object",
+            "properties": {
+                "faltando": {
+                    "type": "integer"
+                },
+                "ingredientes_faltando": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "criado_em": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "problema.Campo": {
+            "type": "object",
+            "properties": {
+                "campo": {
+                    "type": "string",
+                    "example": "passos[0].texto"
+                },
+                "mensagem": {
+                    "type": "string",
+                    "example": "passo 1 sem texto"
+                }
+            }
+        },
+        "problema.Codigo": {
+            "type": "string",
+            "enum": [
+                "requisicao_invalida",
+                "validacao",
+                "nao_autenticado",
+                "credenciais_invalidas",
+                "refresh_invalido",
+                "sem_permissao",
+                "nao_encontrado",
+                "metodo_nao_permitido",
+                "conflito",
+                "muito_grande",
+                "tipo_nao_suportado",
+                "erro_interno"
+            ],
+            "x-enum-comments": {
+                "CodigoConflito": "Valor unico ja usado ou alteracao concorrente",
+                "CodigoCredenciaisInvalidas": "Usuario ou senha errados no login",
+                "CodigoErroInterno": "Falha do servidor; o detalhe fica so no log",
+                "CodigoMetodoNaoPermitido": "Rota existe, mas nao com este metodo",
+                "CodigoMuitoGrande": "Corpo ou imagem acima do limite",
+                "CodigoNaoAutenticado": "Token ausente, invalido, expirado ou de sessao encerrada",
+                "CodigoNaoEncontrado": "Recurso ou rota inexistente",
+                "CodigoRefreshInvalido": "Refresh token invalido, expirado ou ja usado",
+                "CodigoRequisicaoInvalida": "JSON malformado, ID ou parametro invalido",
+                "CodigoSemPermissao": "Papel insuficiente ou receita de outro autor",
+                "CodigoTipoNaoSuportado": "Formato de imagem nao aceito",
+                "CodigoValidacao": "Campos com valores invalidos, listados em erros"
+            },
+            "x-enum-varnames": [
+                "CodigoRequisicaoInvalida",
+                "CodigoValidacao",
+                "CodigoNaoAutenticado",
+                "CodigoCredenciaisInvalidas",
+                "CodigoRefreshInvalido",
+                "CodigoSemPermissao",
+                "CodigoNaoEncontrado",
+                "CodigoMetodoNaoPermitido",
+                "CodigoConflito",
+                "CodigoMuitoGrande",
+                "CodigoTipoNaoSuportado",
+                "CodigoErroInterno"
+            ]
+        },
+        "problema.Problema": {
+            "type": "object",
+            "properties": {
+                "codigo": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/problema.Codigo"
+                        }
+                    ],
+                    "example": "validacao"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "passo 1 sem texto"
+                },
+                "erros": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/problema.Campo"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/receitas"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f1c9a0e-6f0b-4c1e-9a57-2b8f0f7d9e11"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Dados inválidos"
+                },
+                "trace_id": {
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:receitas:problema:validacao"
                 }
             }
         }
@@ -1,63 +1,278 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
-	"log" // Adicione para logs de depuração
+	"errors"
+	"log/slog"
 	"net/http"
-	"os"
-	"time"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/auth"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/metrics"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/middleware"
 	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+	"github.com/google/uuid"
 )
 
-// REMOVA OU COMENTE ESTA LINHA GLOBAL:
-// var jwtKey = []byte(os.Getenv("JWT_SECRET"))
+// Limites aceitos no cadastro de usuarios.
+// O bcrypt ignora tudo depois de 72 bytes, por isso o limite da senha e em bytes.
+const (
+	usernameMinLen   = 3
+	usernameMaxLen   = 50
+	passwordMinLen   = 8
+	passwordMaxBytes = 72
+)
+
+// Resposta do login e da renovacao
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Segundos ate o access token expirar
+}
+
+// Corpo aceito na renovacao
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
 
-type Claims struct {
-	Username string `json:"username"`
-	jwt.RegisteredClaims
+type AuthHandler struct {
+	DBConnection *sql.DB
+	Sessions     *auth.SessionStore
+	Keys         *auth.KeyManager
+	Logger       *slog.Logger
 }
 
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+// Construtor de AuthHandler
+func NewAuthHandler(dbConnection *sql.DB, sessions *auth.SessionStore, keys *auth.KeyManager, logger *slog.Logger) *AuthHandler {
+	return &AuthHandler{DBConnection: dbConnection, Sessions: sessions, Keys: keys, Logger: logger}
+}
+
+// RegisterHandler godoc
+// @Summary Cadastra um novo usuário
+// @Description Cria uma conta com usuário e senha. A senha é salva apenas como hash bcrypt. Todo usuário começa como reader; o primeiro admin é criado com o subcomando promote.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param usuario body models.User true "Usuário e senha"
+// @Success 201 {object} models.User
+// @Failure 400 {object} problema.Problema
+// @Failure 409 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /register [post]
+func (authHandler *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+
+	err := lerJSON(w, r, &user)
+	if err != nil {
+		erroJSON(w, r, err)
+		return
+	}
+
+	user.Username = strings.TrimSpace(user.Username)
+	if n := utf8.RuneCountInString(user.Username); n < usernameMinLen || n > usernameMaxLen {
+		erroValidacao(w, r, models.ErroCampo("username", "Usuário deve ter entre %d e %d caracteres", usernameMinLen, usernameMaxLen))
+		return
+	}
+	// O minimo conta caracteres; o maximo conta bytes, que e o que o bcrypt le
+	if utf8.RuneCountInString(user.Password) < passwordMinLen {
+		erroValidacao(w, r, models.ErroCampo("password", "Senha deve ter pelo menos %d caracteres", passwordMinLen))
+		return
+	}
+	if len(user.Password) > passwordMaxBytes {
+		erroValidacao(w, r, models.ErroCampo("password", "Senha deve ter no máximo %d bytes (letras acentuadas ocupam mais de um)", passwordMaxBytes))
+		return
+	}
+
+	user.PasswordHash, err = auth.HashSenha(user.Password)
+	if err != nil {
+		authHandler.Logger.ErrorContext(r.Context(), "RegisterHandler: Erro ao gerar hash da senha", "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+
+	// O papel nunca vem do corpo da requisicao: todo cadastro publico comeca como reader.
+	// O primeiro admin e criado com o subcomando "promote".
+	query := `INSERT INTO users (username, password_hash, role) VALUES ($1, $2, $3) RETURNING id, role, criado_em`
+	err = authHandler.DBConnection.QueryRow(query, user.Username, user.PasswordHash, models.RoleReader).Scan(&user.ID, &user.Role, &user.CriadoEm)
+	if err != nil {
+		if database.ViolacaoUnica(err) {
+			problema.Escrever(w, r, problema.CodigoConflito, "Usuário já cadastrado")
+			return
+		}
+		authHandler.Logger.ErrorContext(r.Context(), "RegisterHandler: Erro ao inserir usuário", "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+
+	user.Password = ""
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+	authHandler.Logger.InfoContext(r.Context(), "RegisterHandler: Usuário cadastrado", "usuario", user.Username, "papel", user.Role)
+}
+
+// LoginHandler godoc
+// @Summary Autentica um usuário
+// @Description Verifica usuário e senha, abre uma sessão e retorna um token JWT válido por 1 hora e um refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param usuario body models.User true "Usuário e senha"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} problema.Problema
+// @Failure 401 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /login [post]
+func (authHandler *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var creds models.User
 
-	err := json.NewDecoder(r.Body).Decode(&creds)
+	err := lerJSON(w, r, &creds)
 	if err != nil {
-		http.Error(w, "Erro no JSON", http.StatusBadRequest)
+		erroJSON(w, r, err)
 		return
 	}
 
-	if creds.Username != "bruno" || creds.Password != "senha123" {
-		http.Error(w, "Usuário ou senha inválidos", http.StatusUnauthorized)
+	var user models.User
+	query := `SELECT id, username, password_hash, role FROM users WHERE username = $1`
+	err = authHandler.DBConnection.QueryRow(query, strings.TrimSpace(creds.Username)).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role)
+	if err != nil && err != sql.ErrNoRows {
+		metrics.LoginTotal.WithLabelValues(metrics.LoginErro).Inc()
+		authHandler.Logger.ErrorContext(r.Context(), "LoginHandler: Erro ao buscar usuário", "erro", err)
+		problema.Interno(w, r)
 		return
 	}
 
-	expirationTime := time.Now().Add(1 * time.Hour)
-	claims := &Claims{
-		Username: creds.Username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-		},
+	// Com sql.ErrNoRows o hash fica vazio e VerificarSenha sempre falha
+	if !auth.VerificarSenha(user.PasswordHash, creds.Password) {
+		metrics.LoginTotal.WithLabelValues(metrics.LoginFalha).Inc()
+		problema.Escrever(w, r, problema.CodigoCredenciaisInvalidas, "Usuário ou senha inválidos")
+		return
 	}
 
-	// LEIA A CHAVE SECRETA AQUI DENTRO DA FUNÇÃO
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Println("ERRO: JWT_SECRET não está definida no ambiente para o handler de login!")
-		http.Error(w, "Erro interno do servidor: Chave secreta não configurada", http.StatusInternalServerError)
+	sessionID, refreshToken, err := authHandler.Sessions.Criar(r.Context(), user.ID)
+	if err != nil {
+		metrics.LoginTotal.WithLabelValues(metrics.LoginErro).Inc()
+		authHandler.Logger.ErrorContext(r.Context(), "LoginHandler: Erro ao criar sessão", "erro", err)
+		problema.Interno(w, r)
 		return
 	}
-	// log.Printf("LoginHandler: JWT_SECRET para assinatura: %s (length: %d)\n", jwtSecret, len(jwtSecret)) // Opcional para depuração
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtSecret)) // Use a variável local jwtSecret
+	metrics.LoginTotal.WithLabelValues(metrics.LoginSucesso).Inc()
+	authHandler.escreverTokens(w, r, user, sessionID, refreshToken)
+}
+
+// RefreshHandler godoc
+// @Summary Renova o access token
+// @Description Troca um refresh token por um novo access token e um novo refresh token. Cada refresh token só pode ser usado uma vez; reutilizar um token revoga a sessão.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body RefreshRequest true "Refresh token recebido no login ou na última renovação"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} problema.Problema
+// @Failure 401 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /refresh [post]
+func (authHandler *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var body RefreshRequest
+	if err := lerJSON(w, r, &body); err != nil {
+		erroJSON(w, r, err)
+		return
+	}
+	if body.RefreshToken == "" {
+		erroValidacao(w, r, models.ErroCampo("refresh_token", "refresh_token é obrigatório"))
+		return
+	}
+
+	userID, sessionID, refreshToken, err := authHandler.Sessions.Rotacionar(r.Context(), body.RefreshToken)
+	if errors.Is(err, auth.ErrRefreshReutilizado) {
+		authHandler.Logger.WarnContext(r.Context(), "RefreshHandler: Refresh token reutilizado, sessão revogada")
+		problema.Escrever(w, r, problema.CodigoRefreshInvalido, "Refresh token inválido ou expirado")
+		return
+	}
+	if errors.Is(err, auth.ErrRefreshInvalido) {
+		problema.Escrever(w, r, problema.CodigoRefreshInvalido, "Refresh token inválido ou expirado")
+		return
+	}
+	if err != nil {
+		authHandler.Logger.ErrorContext(r.Context(), "RefreshHandler: Erro ao rotacionar refresh token", "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+
+	// Busca o usuario de novo para que mudancas de papel valham ja na renovacao
+	var user models.User
+	err = authHandler.DBConnection.QueryRow(`SELECT id, username, role FROM users WHERE id = $1`, userID).Scan(&user.ID, &user.Username, &user.Role)
+	if err != nil {
+		authHandler.Logger.ErrorContext(r.Context(), "RefreshHandler: Erro ao buscar usuário", "usuario_id", userID, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+
+	authHandler.escreverTokens(w, r, user, sessionID, refreshToken)
+}
+
+// LogoutHandler godoc
+// @Summary Encerra a sessão
+// @Description Revoga a sessão do access token informado. O refresh token e todos os access tokens da sessão deixam de ser aceitos.
+// @Tags auth
+// @Security BearerAuth
+// @Success 204 {string} string "No Content"
+// @Failure 401 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /logout [post]
+func (authHandler *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.ClaimsFromContext(r.Context())
+	sessionID, err := claims.SessionID()
+	if err != nil {
+		problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token inválido ou expirado")
+		return
+	}
+
+	if err := authHandler.Sessions.Revogar(r.Context(), sessionID); err != nil {
+		authHandler.Logger.ErrorContext(r.Context(), "LogoutHandler: Erro ao revogar sessão", "sessao_id", sessionID, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+
+	authHandler.Logger.InfoContext(r.Context(), "LogoutHandler: Sessão encerrada", "sessao_id", sessionID, "usuario", claims.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Assina o access token da sessao e escreve a resposta com os dois tokens
+func (authHandler *AuthHandler) escreverTokens(w http.ResponseWriter, r *http.Request, user models.User, sessionID uuid.UUID, refreshToken string) {
+	tokenString, err := authHandler.Keys.GerarToken(user.ID, user.Username, user.Role, sessionID)
 	if err != nil {
-		log.Printf("Erro ao gerar token: %v\n", err) // Log de erro
-		http.Error(w, "Erro ao gerar token", http.StatusInternalServerError)
+		authHandler.Logger.ErrorContext(r.Context(), "escreverTokens: Erro ao gerar token", "erro", err)
+		problema.Interno(w, r)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"token": tokenString})
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(TokenResponse{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(authHandler.Keys.TokenTTL.Seconds()),
+	})
+}
+
+// JWKSHandler godoc
+// @Summary Chaves públicas de assinatura
+// @Description Publica as chaves públicas (JWKS) usadas para validar os tokens emitidos por esta API, identificadas pelo kid
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (authHandler *AuthHandler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(authHandler.Keys.JWKS())
 }
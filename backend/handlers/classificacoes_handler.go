@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+	"github.com/gorilla/mux"
+)
+
+// Gerencia tags, categorias ou cozinhas, conforme o tipo
+type ClassificacaoHandler struct {
+	DBConnection *sql.DB
+	Tipo         models.TipoClassificacao
+	Logger       *slog.Logger
+}
+
+// Construtor de ClassificacaoHandler
+func NewClassificacaoHandler(dbConnection *sql.DB, tipo models.TipoClassificacao, logger *slog.Logger) *ClassificacaoHandler {
+	return &ClassificacaoHandler{DBConnection: dbConnection, Tipo: tipo, Logger: logger}
+}
+
+// ReadClassificacoes godoc
+// @Summary Lista tags, categorias ou cozinhas
+// @Description Retorna as classificações em ordem alfabética, com o número de receitas de cada uma.
+// @Tags classificacoes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Classificacao
+// @Failure 500 {object} problema.Problema
+// @Router /api/tags [get]
+// @Router /api/categorias [get]
+// @Router /api/cozinhas [get]
+func (classificacaoHandler *ClassificacaoHandler) ReadClassificacoes(w http.ResponseWriter, r *http.Request) {
+	query := `SELECT id, nome, slug, ` + classificacaoHandler.Tipo.ContagemSQL + ` FROM ` + classificacaoHandler.Tipo.Tabela + ` ORDER BY nome`
+	rows, err := classificacaoHandler.DBConnection.Query(query)
+	if err != nil {
+		classificacaoHandler.Logger.ErrorContext(r.Context(), "ReadClassificacoes: Erro ao listar", "tabela", classificacaoHandler.Tipo.Tabela, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+	defer rows.Close()
+
+	classificacoes := []models.Classificacao{}
+	for rows.Next() {
+		var classificacao models.Classificacao
+		if err := rows.Scan(&classificacao.ID, &classificacao.Nome, &classificacao.Slug, &classificacao.TotalReceitas); err != nil {
+			classificacaoHandler.Logger.ErrorContext(r.Context(), "ReadClassificacoes: Erro ao ler", "tabela", classificacaoHandler.Tipo.Tabela, "erro", err)
+			problema.Interno(w, r)
+			return
+		}
+		classificacoes = append(classificacoes, classificacao)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(classificacoes)
+}
+
+// CreateClassificacao godoc
+// @Summary Cria uma tag, categoria ou cozinha
+// @Description Sem "slug", ele é gerado a partir do nome ("Rápido e fácil" vira "rapido-e-facil"). Exige papel editor.
+// @Tags classificacoes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param classificacao body models.Classificacao true "Nome e slug opcional"
+// @Success 201 {object} models.Classificacao
+// @Failure 400 {object} problema.Problema
+// @Failure 409 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/tags [post]
+// @Router /api/categorias [post]
+// @Router /api/cozinhas [post]
+func (classificacaoHandler *ClassificacaoHandler) CreateClassificacao(w http.ResponseWriter, r *http.Request) {
+	var classificacao models.Classificacao
+	if err := lerJSON(w, r, &classificacao); err != nil {
+		erroJSON(w, r, err)
+		return
+	}
+	if err := classificacao.Preparar(); err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
+
+	query := `INSERT INTO ` + classificacaoHandler.Tipo.Tabela + ` (nome, slug) VALUES ($1, $2) RETURNING id`
+	err := classificacaoHandler.DBConnection.QueryRow(query, classificacao.Nome, classificacao.Slug).Scan(&classificacao.ID)
+	if err != nil {
+		classificacaoHandler.erroEscrita(w, r, "CreateClassificacao", err)
+		return
+	}
+	classificacao.TotalReceitas = 0
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(classificacao)
+}
+
+// UpdateClassificacao godoc
+// @Summary Renomeia uma tag, categoria ou cozinha
+// @Description Altera o nome e, opcionalmente, o slug. As receitas continuam ligadas à classificação. Exige papel admin.
+// @Tags classificacoes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Slug atual"
+// @Param classificacao body models.Classificacao true "Novo nome e slug opcional"
+// @Success 200 {object} models.Classificacao
+// @Failure 400 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 409 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/tags/{slug} [put]
+// @Router /api/categorias/{slug} [put]
+// @Router /api/cozinhas/{slug} [put]
+func (classificacaoHandler *ClassificacaoHandler) UpdateClassificacao(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+
+	var classificacao models.Classificacao
+	if err := lerJSON(w, r, &classificacao); err != nil {
+		erroJSON(w, r, err)
+		return
+	}
+	// Sem slug no corpo, o atual e mantido
+	if classificacao.Slug == "" {
+		classificacao.Slug = slug
+	}
+	if err := classificacao.Preparar(); err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
+
+	query := `UPDATE ` + classificacaoHandler.Tipo.Tabela + ` SET nome = $1, slug = $2 WHERE slug = $3 RETURNING id, ` + classificacaoHandler.Tipo.ContagemSQL
+	err := classificacaoHandler.DBConnection.QueryRow(query, classificacao.Nome, classificacao.Slug, slug).Scan(&classificacao.ID, &classificacao.TotalReceitas)
+	if err == sql.ErrNoRows {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, classificacaoHandler.naoEncontrada())
+		return
+	}
+	if err != nil {
+		classificacaoHandler.erroEscrita(w, r, "UpdateClassificacao", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(classificacao)
+}
+
+// DeleteClassificacao godoc
+// @Summary Remove uma tag, categoria ou cozinha
+// @Description As receitas não são removidas: perdem a tag, ou ficam sem categoria ou cozinha. Exige papel admin.
+// @Tags classificacoes
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Slug"
+// @Success 204 {string} string "No Content"
+// @Failure 404 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/tags/{slug} [delete]
+// @Router /api/categorias/{slug} [delete]
+// @Router /api/cozinhas/{slug} [delete]
+func (classificacaoHandler *ClassificacaoHandler) DeleteClassificacao(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+
+	result, err := classificacaoHandler.DBConnection.Exec(`DELETE FROM `+classificacaoHandler.Tipo.Tabela+` WHERE slug = $1`, slug)
+	if err != nil {
+		classificacaoHandler.Logger.ErrorContext(r.Context(), "DeleteClassificacao: Erro ao remover", "tabela", classificacaoHandler.Tipo.Tabela, "slug", slug, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		classificacaoHandler.Logger.ErrorContext(r.Context(), "DeleteClassificacao: Erro ao verificar RowsAffected", "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+	if rowsAffected == 0 {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, classificacaoHandler.naoEncontrada())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (classificacaoHandler *ClassificacaoHandler) naoEncontrada() string {
+	return classificacaoHandler.Tipo.Rotulo + " não encontrada"
+}
+
+// Slug repetido vira 409; o resto e erro interno
+func (classificacaoHandler *ClassificacaoHandler) erroEscrita(w http.ResponseWriter, r *http.Request, origem string, err error) {
+	if database.ViolacaoUnica(err) {
+		problema.Escrever(w, r, problema.CodigoConflito, "Já existe "+classificacaoHandler.Tipo.Rotulo+" com este slug")
+		return
+	}
+	classificacaoHandler.Logger.ErrorContext(r.Context(), origem+": Erro ao gravar", "tabela", classificacaoHandler.Tipo.Tabela, "erro", err)
+	problema.Interno(w, r)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+)
+
+// Limite do corpo JSON: uma receita grande tem poucas dezenas de KB
+const tamanhoMaximoJSON = 1 << 20
+
+// Decodifica o corpo JSON da requisicao em destino, lendo no maximo
+// tamanhoMaximoJSON bytes. Os erros vao para erroJSON.
+func lerJSON(w http.ResponseWriter, r *http.Request, destino any) error {
+	return json.NewDecoder(http.MaxBytesReader(w, r.Body, tamanhoMaximoJSON)).Decode(destino)
+}
+
+// Corpo que nao pode ser lido como o JSON esperado. A mensagem do decoder cita
+// tipos Go e nao vai para o cliente: um tipo errado vira erro de validacao do
+// campo, o resto vira requisicao invalida.
+func erroJSON(w http.ResponseWriter, r *http.Request, err error) {
+	var tipoErrado *json.UnmarshalTypeError
+	if errors.As(err, &tipoErrado) && tipoErrado.Field != "" {
+		mensagem := "deve ser " + nomeTipoJSON(tipoErrado.Type)
+		problema.Validacao(w, r, tipoErrado.Field+" "+mensagem, problema.Campo{Campo: tipoErrado.Field, Mensagem: mensagem})
+		return
+	}
+	var muitoGrande *http.MaxBytesError
+	if errors.As(err, &muitoGrande) {
+		problema.Escrever(w, r, problema.CodigoMuitoGrande, "Corpo da requisição muito grande")
+		return
+	}
+	problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, "JSON inválido")
+}
+
+// Nome do tipo esperado em um campo, como o cliente o ve no JSON
+func nomeTipoJSON(tipo reflect.Type) string {
+	for tipo.Kind() == reflect.Pointer {
+		tipo = tipo.Elem()
+	}
+	switch tipo.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "um número inteiro"
+	case reflect.Float32, reflect.Float64:
+		return "um número"
+	case reflect.Bool:
+		return "true ou false"
+	case reflect.Slice, reflect.Array:
+		return "uma lista"
+	case reflect.Struct, reflect.Map:
+		return "um objeto"
+	default:
+		return "um texto"
+	}
+}
+
+// Erro das validacoes da entrada (models, parametros da URL). As mensagens
+// sao escritas para o cliente; com models.ErroValidacao o campo vai junto.
+func erroValidacao(w http.ResponseWriter, r *http.Request, err error) {
+	var invalido *models.ErroValidacao
+	if errors.As(err, &invalido) {
+		problema.Validacao(w, r, invalido.Mensagem, problema.Campo{Campo: invalido.Campo, Mensagem: invalido.Mensagem})
+		return
+	}
+	problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, err.Error())
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/auth"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/migrations"
+)
+
+// Prazo de cada verificacao de dependencia do /readyz
+const timeoutVerificacao = 2 * time.Second
+
+// Valores de status das respostas de saude
+const (
+	StatusOK    = "ok"
+	StatusFalha = "falha"
+)
+
+type HealthHandler struct {
+	DBConnection *sql.DB
+	Migrador     *migrations.Migrador
+	Keys         *auth.KeyManager
+	Logger       *slog.Logger
+	desligando   atomic.Bool
+}
+
+// Construtor de HealthHandler
+func NewHealthHandler(dbConnection *sql.DB, migrador *migrations.Migrador, keys *auth.KeyManager, logger *slog.Logger) *HealthHandler {
+	return &HealthHandler{DBConnection: dbConnection, Migrador: migrador, Keys: keys, Logger: logger}
+}
+
+// Resposta do /healthz e do /readyz
+type HealthResponse struct {
+	Status       string                 `json:"status" example:"ok"`
+	Verificacoes map[string]Verificacao `json:"verificacoes,omitempty"`
+}
+
+// Resultado da verificacao de uma dependencia
+type Verificacao struct {
+	Status   string `json:"status" example:"ok"`
+	Erro     string `json:"erro,omitempty"`
+	Detalhes string `json:"detalhes,omitempty" example:"versão 15"`
+}
+
+// Marca o servidor como em desligamento: o /readyz passa a falhar para o
+// balanceador parar de mandar requisicoes enquanto as atuais terminam
+func (healthHandler *HealthHandler) Desligar() {
+	healthHandler.desligando.Store(true)
+}
+
+// Liveness godoc
+// @Summary Verifica se o processo está no ar
+// @Description Responde 200 enquanto o processo atende requisições, sem consultar dependências
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Router /healthz [get]
+func (healthHandler *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	escreverSaude(w, http.StatusOK, HealthResponse{Status: StatusOK})
+}
+
+// Readiness godoc
+// @Summary Verifica se a API está pronta para receber tráfego
+// @Description Confere o banco (ping), se todas as migrations deste binário foram aplicadas e se há chave de assinatura carregada. Responde 503 com o detalhe de cada dependência quando alguma falha ou durante o desligamento.
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /readyz [get]
+func (healthHandler *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	verificacoes := map[string]Verificacao{
+		"banco":      healthHandler.verificarBanco(r.Context()),
+		"migrations": healthHandler.verificarMigrations(r.Context()),
+		"chave_jwt":  healthHandler.verificarChave(),
+	}
+	if healthHandler.desligando.Load() {
+		verificacoes["servidor"] = Verificacao{Status: StatusFalha, Erro: "em desligamento"}
+	}
+
+	resposta := HealthResponse{Status: StatusOK, Verificacoes: verificacoes}
+	status := http.StatusOK
+	for nome, verificacao := range verificacoes {
+		if verificacao.Status != StatusOK {
+			resposta.Status = StatusFalha
+			status = http.StatusServiceUnavailable
+			healthHandler.Logger.WarnContext(r.Context(), "Readiness: Dependência indisponível", "dependencia", nome, "erro", verificacao.Erro)
+		}
+	}
+	escreverSaude(w, status, resposta)
+}
+
+func (healthHandler *HealthHandler) verificarBanco(ctx context.Context) Verificacao {
+	ctx, cancel := context.WithTimeout(ctx, timeoutVerificacao)
+	defer cancel()
+	if err := healthHandler.DBConnection.PingContext(ctx); err != nil {
+		healthHandler.Logger.ErrorContext(ctx, "Readiness: Erro ao consultar o banco", "erro", err)
+		return Verificacao{Status: StatusFalha, Erro: "banco indisponível"}
+	}
+	return Verificacao{Status: StatusOK}
+}
+
+func (healthHandler *HealthHandler) verificarMigrations(ctx context.Context) Verificacao {
+	ctx, cancel := context.WithTimeout(ctx, timeoutVerificacao)
+	defer cancel()
+	pendentes, err := healthHandler.Migrador.Pendentes(ctx)
+	if err != nil {
+		healthHandler.Logger.ErrorContext(ctx, "Readiness: Erro ao consultar as migrations", "erro", err)
+		return Verificacao{Status: StatusFalha, Erro: "não foi possível ler as migrations aplicadas"}
+	}
+	migracoes := healthHandler.Migrador.Migracoes
+	esperada := migracoes[len(migracoes)-1].Versao
+	if len(pendentes) > 0 {
+		return Verificacao{Status: StatusFalha, Erro: fmt.Sprintf("%d migrations pendentes", len(pendentes)), Detalhes: fmt.Sprintf("versão esperada %d", esperada)}
+	}
+	return Verificacao{Status: StatusOK, Detalhes: fmt.Sprintf("versão %d", esperada)}
+}
+
+func (healthHandler *HealthHandler) verificarChave() Verificacao {
+	if !healthHandler.Keys.Carregada() {
+		return Verificacao{Status: StatusFalha, Erro: "nenhuma chave de assinatura carregada"}
+	}
+	return Verificacao{Status: StatusOK}
+}
+
+func escreverSaude(w http.ResponseWriter, status int, resposta HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resposta)
+}
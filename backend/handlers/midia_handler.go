@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/storage"
+	"github.com/gorilla/mux"
+)
+
+// Serve os arquivos do storage em /midia. E publico porque tags <img> nao
+// enviam o token; as chaves levam o ID aleatorio da imagem.
+type MidiaHandler struct {
+	Storage storage.Storage
+	Logger  *slog.Logger
+}
+
+// Construtor de MidiaHandler
+func NewMidiaHandler(storage storage.Storage, logger *slog.Logger) *MidiaHandler {
+	return &MidiaHandler{Storage: storage, Logger: logger}
+}
+
+// ReadMidia godoc
+// @Summary Arquivo de imagem
+// @Description Retorna a imagem original ou uma miniatura. Cada envio gera uma chave nova, então as respostas podem ficar em cache indefinidamente.
+// @Tags midia
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param chave path string true "Chave do arquivo, como aparece nas URLs das imagens"
+// @Success 200 {file} file
+// @Failure 404 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /midia/{chave} [get]
+func (midiaHandler *MidiaHandler) ReadMidia(w http.ResponseWriter, r *http.Request) {
+	chave := mux.Vars(r)["chave"]
+	if storage.ValidarChave(chave) != nil {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Arquivo não encontrado")
+		return
+	}
+
+	arquivo, contentType, err := midiaHandler.Storage.Abrir(r.Context(), chave)
+	if errors.Is(err, storage.ErrNaoEncontrado) {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Arquivo não encontrado")
+		return
+	}
+	if err != nil {
+		midiaHandler.Logger.ErrorContext(r.Context(), "ReadMidia: Erro ao abrir arquivo", "chave", chave, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+	defer arquivo.Close()
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	io.Copy(w, arquivo)
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+)
+
+// Erro ao gravar a receita: classificacao desconhecida vira erro de validacao do
+// campo; o resto e erro interno, com a causa apenas no log
+func (receitaHandler *ReceitaHandler) erroClassificacao(w http.ResponseWriter, r *http.Request, origem string, err error) {
+	var desconhecida *models.ClassificacaoDesconhecidaError
+	if errors.As(err, &desconhecida) {
+		erroValidacao(w, r, models.ErroCampo(desconhecida.Tipo.Campo, "%s", desconhecida.Error()))
+		return
+	}
+	receitaHandler.Logger.ErrorContext(r.Context(), origem+": Erro ao gravar a receita", "erro", err)
+	problema.Interno(w, r)
+}
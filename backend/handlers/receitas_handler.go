@@ -1,136 +1,244 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/middleware"
 	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/repository"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/storage"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"github.com/lib/pq"
 )
 
 type ReceitaHandler struct {
-	DBConnection *sql.DB
+	Repository repository.ReceitaRepository
+	Storage    storage.Storage // Arquivos das imagens
+	Logger     *slog.Logger
 }
 
 // Construtor de ReceitaHandler
-func NewReceitaHandler(dbConnection *sql.DB) *ReceitaHandler {
-	return &ReceitaHandler{DBConnection: dbConnection}
+func NewReceitaHandler(repository repository.ReceitaRepository, storage storage.Storage, logger *slog.Logger) *ReceitaHandler {
+	return &ReceitaHandler{Repository: repository, Storage: storage, Logger: logger}
 }
 
 // ReadReceitas godoc
-// @Summary Lista todas as receitas
-// @Description Retorna todas as receitas cadastradas no banco de dados
+// @Summary Lista as receitas
+// @Description Retorna as receitas em páginas. O cabeçalho Link traz a URL da próxima página (rel="next") e X-Total-Count o total de receitas que atendem aos filtros.
+// @Description Com "q" faz uma busca textual em português (sem diferenciar acentos) no nome, descrição, ingredientes e instruções.
+// @Description Aceita a sintaxe de busca na web: "frase exata", -excluir e OR. Por padrão os resultados vêm ordenados por relevância, com os termos encontrados marcados em "busca".
+// @Description Com "tem" filtra pelos ingredientes disponíveis, sem diferenciar acentos e plurais; "farinha" cobre "farinha de trigo". Ingredientes a gosto ou opcionais não contam, e "despensa" lista o que falta em cada receita.
 // @Tags receitas
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.Receita
-// @Failure 500 {object} map[string]string
+// @Param q query string false "Texto da busca"
+// @Param tem query string false "Ingredientes disponíveis, separados por vírgula (ex.: ovo,farinha,leite). Retorna as receitas que dá para fazer com eles, das que menos faltam ingredientes para as que mais faltam"
+// @Param faltando_max query int false "Com tem, aceita receitas em que faltam até este número de ingredientes (padrão 0)"
+// @Param tag query string false "Slugs de tags separados por vírgula; a receita precisa ter todas (ex.: vegano,rapido)"
+// @Param categoria query string false "Slug da categoria"
+// @Param cozinha query string false "Slug da cozinha"
+// @Param tempo_min query int false "Tempo total mínimo, em minutos"
+// @Param tempo_max query int false "Tempo total máximo, em minutos. Receitas sem tempo informado ficam de fora"
+// @Param preparo_max query int false "Tempo de preparo máximo, em minutos"
+// @Param dificuldade query string false "Dificuldades aceitas, separadas por vírgula (facil, media, dificil)"
+// @Param facetas query bool false "Envolve a resposta em {receitas, facetas} com o número de receitas por tag, categoria e cozinha dentro dos filtros"
+// @Param sort query string false "Ordenação; prefixo - para decrescente. Padrão: nome, -relevancia com q ou faltando com tem" Enums(nome, -nome, criado_em, -criado_em, relevancia, -relevancia, faltando, -faltando)
+// @Param limit query int false "Receitas por página (1 a 100, padrão 20)"
+// @Param cursor query string false "Cursor da próxima página, copiado do cabeçalho Link"
+// @Param fields query string false "Campos retornados, separados por vírgula (ex.: nome,descricao). O id sempre é incluído"
+// @Param sistema query string false "Exibe as quantidades no sistema de unidades pedido" Enums(metrico, imperial, caseiro)
+// @Success 200 {array} models.Receita "Lista de receitas, ou RespostaFacetada com facetas=true"
+// @Header 200 {string} Link "URL da próxima página"
+// @Header 200 {integer} X-Total-Count "Total de receitas que atendem aos filtros"
+// @Failure 400 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
 // @Router /api/receitas [get]
 func (receitaHandler *ReceitaHandler) ReadReceitas(w http.ResponseWriter, r *http.Request) {
+	opcoes, err := lerListagem(r)
+	if err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
 
-	rows, err := receitaHandler.DBConnection.Query("SELECT * FROM receitas")
+	var pagina repository.Pagina
+	if opcoes.busca != "" {
+		pagina, err = receitaHandler.Repository.Buscar(r.Context(), opcoes.busca, opcoes.consulta)
+	} else {
+		pagina, err = receitaHandler.Repository.Listar(r.Context(), opcoes.consulta)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		receitaHandler.Logger.ErrorContext(r.Context(), "ReadReceitas: Erro ao listar receitas", "erro", err)
+		problema.Interno(w, r)
 		return
 	}
 
-	var receitas []models.Receita
+	receitas := pagina.Receitas
+	for i := range receitas {
+		receitaHandler.prepararURLs(&receitas[i])
+	}
+	if pagina.Proxima != nil {
+		w.Header().Set("Link", linkProximaPagina(r, *pagina.Proxima))
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(pagina.Total))
 
-	for rows.Next() {
-		var receita models.Receita
-		err := rows.Scan(&receita.ID, &receita.Nome, &receita.Descricao, pq.Array(&receita.Ingredientes), &receita.Instrucoes)
+	if opcoes.sistema != "" {
+		for i := range receitas {
+			receitas[i] = receitas[i].ParaSistema(opcoes.sistema)
+		}
+	}
 
+	var resposta any = receitas
+	if len(opcoes.campos) > 0 {
+		parciais, err := selecionarCampos(receitas, opcoes.campos)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			receitaHandler.Logger.ErrorContext(r.Context(), "ReadReceitas: Erro ao selecionar campos", "erro", err)
+			problema.Interno(w, r)
 			return
 		}
-
-		receitas = append(receitas, receita)
+		resposta = parciais
+	}
+	if opcoes.consulta.Facetas {
+		resposta = RespostaFacetada{Receitas: resposta, Facetas: pagina.Facetas}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(receitas)
+	json.NewEncoder(w).Encode(resposta)
+}
+
+// Resposta da listagem com ?facetas=true
+type RespostaFacetada struct {
+	Receitas any            `json:"receitas"`
+	Facetas  models.Facetas `json:"facetas"`
 }
 
 // ReadReceitaByID godoc
 // @Summary Busca uma receita por ID
-// @Description Retorna uma única receita com base no ID fornecido
+// @Description Retorna uma única receita com base no ID fornecido.
+// @Description Com "porcoes" ou "fator" as quantidades são escaladas, arredondadas para frações práticas e, quando faz sentido, convertidas para uma unidade maior (16 colheres de sopa viram 1 xícara).
 // @Tags receitas
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID da receita (UUID)"
+// @Param porcoes query int false "Escala a receita para este número de porções (exige que a receita informe porções)"
+// @Param fator query number false "Multiplica todas as quantidades por este fator (ex.: 0.5 para meia receita)"
+// @Param sistema query string false "Exibe as quantidades no sistema de unidades pedido" Enums(metrico, imperial, caseiro)
 // @Success 200 {object} models.Receita
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
 // @Router /api/receitas/{id} [get]
 func (receitaHandler *ReceitaHandler) ReadReceitasById(w http.ResponseWriter, r *http.Request) {
-	log.Println("ReadReceitaByID: Recebendo requisição para detalhes de receita.")
+	receitaHandler.Logger.DebugContext(r.Context(), "ReadReceitaByID: Recebendo requisição para detalhes de receita")
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
 	// Valida se o ID é um UUID válido
-	_, err := uuid.Parse(idStr)
+	id, err := uuid.Parse(idStr)
 	if err != nil {
-		log.Printf("ReadReceitaByID: ID inválido '%s': %v\n", idStr, err)
-		http.Error(w, "ID da receita inválido", http.StatusBadRequest)
+		receitaHandler.Logger.InfoContext(r.Context(), "ReadReceitaByID: ID inválido", "id", idStr, "erro", err)
+		problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, "ID da receita inválido")
 		return
 	}
 
-	var receita models.Receita
-	var ingredientesArray pq.StringArray // Para escanear o array do PostgreSQL
-
 	// Consulta a receita pelo ID
-	query := `SELECT id, nome, descricao, ingredientes, instrucoes FROM receitas WHERE id = $1`
-	err = receitaHandler.DBConnection.QueryRow(query, idStr).Scan(&receita.ID, &receita.Nome, &receita.Descricao, &ingredientesArray, &receita.Instrucoes)
-
+	receita, err := receitaHandler.Repository.Obter(r.Context(), id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("ReadReceitaByID: Receita não encontrada para o ID %s.\n", idStr)
-			http.Error(w, "Receita não encontrada", http.StatusNotFound)
+		if errors.Is(err, repository.ErrNaoEncontrada) {
+			receitaHandler.Logger.InfoContext(r.Context(), "ReadReceitaByID: Receita não encontrada", "id", idStr)
+			problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Receita não encontrada")
 		} else {
-			log.Printf("ReadReceitaByID: Erro ao buscar receita por ID: %v\n", err)
-			http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+			receitaHandler.Logger.ErrorContext(r.Context(), "ReadReceitaByID: Erro ao buscar receita por ID", "id", idStr, "erro", err)
+			problema.Interno(w, r)
 		}
 		return
 	}
+	receitaHandler.prepararURLs(&receita)
 
-	receita.Ingredientes = []string(ingredientesArray) // Converte para []string para o modelo
+	fator, err := fatorEscala(r, receita)
+	if err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
+	if fator != 1 {
+		receita = receita.Escalar(fator)
+	}
+
+	sistema, err := sistemaUnidades(r)
+	if err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
+	if sistema != "" {
+		receita = receita.ParaSistema(sistema)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(receita)
-	log.Printf("ReadReceitaByID: Receita '%s' carregada com sucesso.\n", receita.Nome)
+	receitaHandler.Logger.DebugContext(r.Context(), "ReadReceitaByID: Receita carregada", "id", idStr)
 }
 
 // CreateReceitas godoc
 // @Summary Cria uma nova receita
-// @Description Adiciona uma nova receita com nome, descrição, ingredientes e instruções
+// @Description Adiciona uma nova receita com nome, descrição, ingredientes e instruções.
+// @Description Os ingredientes podem vir como texto em "ingredientes" ou estruturados em "ingredientes_estruturados" (que tem prioridade).
+// @Description Da mesma forma, o modo de preparo pode vir em "passos" ou como texto em "instrucoes", que é dividido nas linhas numeradas ou nos parágrafos.
 // @Tags receitas
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param receita body models.Receita true "Dados da nova receita"
 // @Success 200 {object} models.Receita
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
 // @Router /api/receitas [post]
 func (receitaHandler *ReceitaHandler) CreateReceitas(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.ClaimsFromContext(r.Context())
+	autorID, err := claims.UserID()
+	if err != nil {
+		problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token inválido ou expirado")
+		return
+	}
+
 	var receita models.Receita
 
-	err := json.NewDecoder(r.Body).Decode(&receita)
+	err = lerJSON(w, r, &receita)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		erroJSON(w, r, err)
+		return
+	}
+	receita.AutorID = &autorID
+	receita.Busca, receita.Despensa, receita.Capa = nil, nil, nil
+	if receita.Porcoes < 0 {
+		erroValidacao(w, r, models.ErroCampo("porcoes", "porcoes deve ser positivo"))
+		return
+	}
+	if err := receita.PrepararTempos(); err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
+	if err := receita.PrepararIngredientes(); err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
+	if err := receita.PrepararPassos(); err != nil {
+		erroValidacao(w, r, err)
 		return
 	}
 
-	query := `INSERT INTO receitas (nome, descricao, ingredientes, instrucoes) VALUES ($1, $2, $3, $4) RETURNING id`
-	err = receitaHandler.DBConnection.QueryRow(query, receita.Nome, receita.Descricao, pq.Array(receita.Ingredientes), receita.Instrucoes).Scan(&receita.ID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	receita.PrepararClassificacoes()
+
+	if err := receitaHandler.Repository.Criar(r.Context(), &receita); err != nil {
+		receitaHandler.erroClassificacao(w, r, "CreateReceitas", err)
 		return
 	}
 
@@ -140,18 +248,19 @@ func (receitaHandler *ReceitaHandler) CreateReceitas(w http.ResponseWriter, r *h
 
 // DeleteReceitas godoc
 // @Summary Deleta uma receita
-// @Description Remove uma receita do banco de dados pelo ID
+// @Description Remove uma receita do banco de dados pelo ID. Apenas o autor ou um admin pode remover.
 // @Tags receitas
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID da receita (UUID)"
 // @Success 204 {string} string "No Content"
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problema.Problema
+// @Failure 403 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
 // @Router /api/receitas/{id} [delete]
 func (receitaHandler *ReceitaHandler) DeleteReceitas(w http.ResponseWriter, r *http.Request) {
-	log.Println("DeleteReceitas: Recebendo requisição para deletar receita.") // Log de início da função
+	receitaHandler.Logger.DebugContext(r.Context(), "DeleteReceitas: Recebendo requisição para deletar receita")
 
 	vars := mux.Vars(r)
 	idStr := vars["id"] // Pega o ID da URL
@@ -159,41 +268,39 @@ func (receitaHandler *ReceitaHandler) DeleteReceitas(w http.ResponseWriter, r *h
 	// 1. Validação do ID: Garante que é um UUID válido
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		log.Printf("DeleteReceitas: Erro de ID inválido '%s': %v\n", idStr, err)
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		receitaHandler.Logger.InfoContext(r.Context(), "DeleteReceitas: ID inválido", "id", idStr, "erro", err)
+		problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, "ID inválido")
 		return
 	}
 
-	// 2. Executa a deleção no banco de dados
-	result, err := receitaHandler.DBConnection.Exec("DELETE FROM receitas WHERE id = $1", id)
-	if err != nil {
-		log.Printf("DeleteReceitas: Erro ao executar DELETE no banco para ID %s: %v\n", idStr, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// 2. Só o autor da receita ou um admin pode removê-la
+	_, ok := receitaHandler.autorizarAlteracao(w, r, id)
+	if !ok {
 		return
 	}
 
-	// 3. Verifica se alguma linha foi afetada (se a receita existia)
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("DeleteReceitas: Erro ao verificar RowsAffected para ID %s: %v\n", idStr, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// 3. Executa a deleção no banco de dados, junto com as imagens da receita
+	removidas, err := receitaHandler.Repository.Remover(r.Context(), id)
+	if errors.Is(err, repository.ErrNaoEncontrada) {
+		receitaHandler.Logger.InfoContext(r.Context(), "DeleteReceitas: Receita não encontrada para exclusão", "id", idStr)
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Receita não encontrada") // 404 Not Found se não encontrou
 		return
 	}
-
-	if rowsAffected == 0 {
-		log.Printf("DeleteReceitas: Receita com ID %s não encontrada para exclusão.\n", idStr)
-		http.Error(w, "Receita não encontrada", http.StatusNotFound) // 404 Not Found se não encontrou
+	if err != nil {
+		receitaHandler.Logger.ErrorContext(r.Context(), "DeleteReceitas: Erro ao remover a receita", "id", idStr, "erro", err)
+		problema.Interno(w, r)
 		return
 	}
+	receitaHandler.removerArquivos(context.WithoutCancel(r.Context()), removidas)
 
-	// 4. Se chegou até aqui, a exclusão foi bem-sucedida
-	w.WriteHeader(http.StatusNoContent)                                            // <-- ESTA LINHA É CRUCIAL! Envia o status 204 No Content
-	log.Printf("DeleteReceitas: Receita com ID %s deletada com sucesso.\n", idStr) // Log de sucesso
+	// 5. Se chegou até aqui, a exclusão foi bem-sucedida
+	w.WriteHeader(http.StatusNoContent) // <-- ESTA LINHA É CRUCIAL! Envia o status 204 No Content
+	receitaHandler.Logger.InfoContext(r.Context(), "DeleteReceitas: Receita deletada", "id", idStr)
 }
 
 // UpdateReceitas godoc
 // @Summary Atualiza uma receita
-// @Description Atualiza os dados de uma receita existente
+// @Description Atualiza os dados de uma receita existente. Apenas o autor ou um admin pode alterar.
 // @Tags receitas
 // @Accept json
 // @Produce json
@@ -201,9 +308,11 @@ func (receitaHandler *ReceitaHandler) DeleteReceitas(w http.ResponseWriter, r *h
 // @Param id path string true "ID da receita (UUID)"
 // @Param receita body models.Receita true "Dados atualizados da receita"
 // @Success 200 {object} models.Receita
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problema.Problema
+// @Failure 403 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
 // @Router /api/receitas/{id} [put]
 func (receitaHandler *ReceitaHandler) UpdateReceitas(w http.ResponseWriter, r *http.Request) {
 
@@ -213,36 +322,135 @@ func (receitaHandler *ReceitaHandler) UpdateReceitas(w http.ResponseWriter, r *h
 	id, err := uuid.Parse(idStr)
 
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, "ID inválido")
 		return
 	}
 
-	var receita models.Receita
-	err = json.NewDecoder(r.Body).Decode(&receita)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	autorID, ok := receitaHandler.autorizarAlteracao(w, r, id)
+	if !ok {
 		return
 	}
 
-	query := `UPDATE receitas SET nome = $1, descricao = $2, ingredientes = $3, instrucoes = $4 WHERE id = $5`
-	result, err := receitaHandler.DBConnection.Exec(query, receita.Nome, receita.Descricao, pq.Array(receita.Ingredientes), receita.Instrucoes, id)
+	var receita models.Receita
+	err = lerJSON(w, r, &receita)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		erroJSON(w, r, err)
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if receita.Porcoes < 0 {
+		erroValidacao(w, r, models.ErroCampo("porcoes", "porcoes deve ser positivo"))
 		return
 	}
-
-	if rowsAffected == 0 {
-		http.Error(w, "Receita não encontrada", http.StatusNotFound)
+	if err := receita.PrepararTempos(); err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
+	if err := receita.PrepararIngredientes(); err != nil {
+		erroValidacao(w, r, err)
 		return
 	}
+	if err := receita.PrepararPassos(); err != nil {
+		erroValidacao(w, r, err)
+		return
+	}
+	receita.PrepararClassificacoes()
 
 	receita.ID = id
+	receita.AutorID = autorID
+	receita.Busca, receita.Despensa = nil, nil
+	removidas, err := receitaHandler.Repository.Atualizar(r.Context(), &receita)
+	if errors.Is(err, repository.ErrNaoEncontrada) {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Receita não encontrada")
+		return
+	}
+	if err != nil {
+		receitaHandler.erroClassificacao(w, r, "UpdateReceitas", err)
+		return
+	}
+	receitaHandler.removerArquivos(context.WithoutCancel(r.Context()), removidas)
+	receitaHandler.prepararURLs(&receita)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(receita)
 }
+
+// Verifica se o usuario do token pode alterar ou remover a receita.
+// Retorna o autor atual da receita; em caso de falha ja escreve a resposta (401, 403, 404 ou 500).
+func (receitaHandler *ReceitaHandler) autorizarAlteracao(w http.ResponseWriter, r *http.Request, id uuid.UUID) (*uuid.UUID, bool) {
+	claims, _ := middleware.ClaimsFromContext(r.Context())
+	userID, err := claims.UserID()
+	if err != nil {
+		problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token inválido ou expirado")
+		return nil, false
+	}
+
+	autorID, err := receitaHandler.Repository.Autor(r.Context(), id)
+	if errors.Is(err, repository.ErrNaoEncontrada) {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Receita não encontrada")
+		return nil, false
+	}
+	if err != nil {
+		receitaHandler.Logger.ErrorContext(r.Context(), "autorizarAlteracao: Erro ao buscar autor da receita", "id", id, "erro", err)
+		problema.Interno(w, r)
+		return nil, false
+	}
+
+	if claims.Role != models.RoleAdmin && (autorID == nil || *autorID != userID) {
+		receitaHandler.Logger.InfoContext(r.Context(), "autorizarAlteracao: Usuário sem permissão para alterar a receita", "usuario_id", userID, "id", id)
+		problema.Escrever(w, r, problema.CodigoSemPermissao, "Sem permissão para alterar esta receita")
+		return nil, false
+	}
+
+	return autorID, true
+}
+
+// Limite do fator de escala, para evitar receitas absurdas
+const fatorMaximo = 100
+
+// Le "porcoes" ou "fator" da query string e retorna o multiplicador das quantidades
+func fatorEscala(r *http.Request, receita models.Receita) (float64, error) {
+	query := r.URL.Query()
+	fator := 1.0
+
+	if valor := query.Get("porcoes"); valor != "" {
+		porcoes, err := strconv.Atoi(valor)
+		if err != nil || porcoes <= 0 {
+			return 0, models.ErroCampo("porcoes", "porcoes deve ser um inteiro positivo")
+		}
+		if receita.Porcoes == 0 {
+			return 0, models.ErroCampo("porcoes", "a receita não informa o número de porções, use fator")
+		}
+		fator = float64(porcoes) / float64(receita.Porcoes)
+	}
+
+	if valor := query.Get("fator"); valor != "" {
+		f, err := strconv.ParseFloat(strings.Replace(valor, ",", ".", 1), 64)
+		if err != nil || f <= 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, models.ErroCampo("fator", "fator deve ser um número positivo")
+		}
+		fator *= f
+	}
+
+	if fator > fatorMaximo || fator < 1.0/fatorMaximo {
+		campo := "fator"
+		if query.Get("fator") == "" {
+			campo = "porcoes"
+		}
+		return 0, models.ErroCampo(campo, "escala deve ficar entre 1/%d e %d vezes a receita", fatorMaximo, fatorMaximo)
+	}
+	return fator, nil
+}
+
+// Le o parametro "sistema" (metrico, imperial ou caseiro); vazio mantem as unidades originais
+func sistemaUnidades(r *http.Request) (units.Sistema, error) {
+	valor := r.URL.Query().Get("sistema")
+	if valor == "" {
+		return "", nil
+	}
+	sistema, err := units.ParseSistema(valor)
+	if err != nil {
+		return "", models.ErroCampo("sistema", "%s", err.Error())
+	}
+	return sistema, nil
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/auth"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/middleware"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/repository"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/storage"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Rotas de receitas como em main.go, sobre o repositorio em memoria. As claims
+// vao direto no contexto, no lugar do JWTMiddleware, que depende do banco.
+func novoRouterReceitas(t *testing.T) *mux.Router {
+	t.Helper()
+	midia, err := storage.NewLocal(t.TempDir(), "/midia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	receitaHandler := NewReceitaHandler(repository.NewMemoria(), midia, logger)
+
+	reader := middleware.RequireRole(models.RoleReader)
+	editor := middleware.RequireRole(models.RoleEditor)
+	router := mux.NewRouter()
+	router.Handle("/api/receitas", reader(http.HandlerFunc(receitaHandler.ReadReceitas))).Methods("GET")
+	router.Handle("/api/receitas/{id}", reader(http.HandlerFunc(receitaHandler.ReadReceitasById))).Methods("GET")
+	router.Handle("/api/receitas", editor(http.HandlerFunc(receitaHandler.CreateReceitas))).Methods("POST")
+	router.Handle("/api/receitas/{id}", editor(http.HandlerFunc(receitaHandler.DeleteReceitas))).Methods("DELETE")
+	router.Handle("/api/receitas/{id}", editor(http.HandlerFunc(receitaHandler.UpdateReceitas))).Methods("PUT")
+	return router
+}
+
+func novoUsuario(role string) *auth.Claims {
+	return &auth.Claims{Username: role, Role: role, RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()}}
+}
+
+// Faz a requisicao como o usuario (nil: sem autenticacao). corpo pode ser string ou valor a codificar em JSON.
+func requisitar(t *testing.T, router http.Handler, usuario *auth.Claims, metodo, caminho string, corpo any) *httptest.ResponseRecorder {
+	t.Helper()
+	var leitor io.Reader
+	switch corpo := corpo.(type) {
+	case nil:
+	case string:
+		leitor = strings.NewReader(corpo)
+	default:
+		dados, err := json.Marshal(corpo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leitor = bytes.NewReader(dados)
+	}
+	r := httptest.NewRequest(metodo, caminho, leitor)
+	if usuario != nil {
+		r = r.WithContext(middleware.ContextWithClaims(r.Context(), usuario))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func decodificar[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var valor T
+	if err := json.NewDecoder(w.Body).Decode(&valor); err != nil {
+		t.Fatalf("resposta nao e JSON: %v", err)
+	}
+	return valor
+}
+
+// Confere o status e o codigo de uma resposta de erro
+func conferirProblema(t *testing.T, w *httptest.ResponseRecorder, codigo problema.Codigo) problema.Problema {
+	t.Helper()
+	if tipo := w.Header().Get("Content-Type"); tipo != problema.ContentType {
+		t.Fatalf("Content-Type = %q, esperado %q (corpo %s)", tipo, problema.ContentType, w.Body)
+	}
+	resposta := decodificar[problema.Problema](t, w)
+	if resposta.Codigo != codigo || resposta.Status != w.Code {
+		t.Fatalf("erro %d %q, esperado %q: %+v", w.Code, resposta.Codigo, codigo, resposta)
+	}
+	return resposta
+}
+
+func criarReceita(t *testing.T, router http.Handler, autor *auth.Claims, nome string, ingredientes ...string) models.Receita {
+	t.Helper()
+	corpo := map[string]any{"nome": nome, "ingredientes": ingredientes, "instrucoes": "Misture tudo.", "porcoes": 4}
+	w := requisitar(t, router, autor, "POST", "/api/receitas", corpo)
+	if w.Code != http.StatusOK {
+		t.Fatalf("criar %q: status %d: %s", nome, w.Code, w.Body)
+	}
+	return decodificar[models.Receita](t, w)
+}
+
+func TestCriarEObterReceita(t *testing.T) {
+	router := novoRouterReceitas(t)
+	editor := novoUsuario(models.RoleEditor)
+
+	criada := criarReceita(t, router, editor, "Bolo de cenoura", "3 cenouras", "2 xícaras de farinha de trigo")
+	if criada.ID == uuid.Nil {
+		t.Fatal("receita criada sem ID")
+	}
+	if criada.AutorID == nil || criada.AutorID.String() != editor.Subject {
+		t.Fatalf("autor = %v, esperado %s", criada.AutorID, editor.Subject)
+	}
+
+	w := requisitar(t, router, novoUsuario(models.RoleReader), "GET", "/api/receitas/"+criada.ID.String(), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	lida := decodificar[models.Receita](t, w)
+	if lida.Nome != "Bolo de cenoura" || len(lida.IngredientesEstruturados) != 2 {
+		t.Fatalf("receita lida = %+v", lida)
+	}
+	if ingrediente := lida.IngredientesEstruturados[0]; ingrediente.Quantidade == nil || *ingrediente.Quantidade != 3 {
+		t.Fatalf("ingrediente = %+v, esperado quantidade 3", ingrediente)
+	}
+
+	w = requisitar(t, router, editor, "GET", "/api/receitas/"+uuid.NewString(), nil)
+	conferirProblema(t, w, problema.CodigoNaoEncontrado)
+	w = requisitar(t, router, editor, "GET", "/api/receitas/123", nil)
+	conferirProblema(t, w, problema.CodigoRequisicaoInvalida)
+}
+
+func TestCriarReceitaInvalida(t *testing.T) {
+	router := novoRouterReceitas(t)
+	editor := novoUsuario(models.RoleEditor)
+
+	casos := []struct {
+		nome   string
+		corpo  string
+		codigo problema.Codigo
+		campo  string
+	}{
+		{"JSON malformado", `{"nome":`, problema.CodigoRequisicaoInvalida, ""},
+		{"tipo errado", `{"nome":"Bolo","porcoes":"quatro"}`, problema.CodigoValidacao, "porcoes"},
+		{"porcoes negativas", `{"nome":"Bolo","porcoes":-1}`, problema.CodigoValidacao, "porcoes"},
+		{"quantidade zero no texto", `{"nome":"Bolo","ingredientes":["3 ovos","0 xícara de leite"]}`, problema.CodigoValidacao, "ingredientes[1]"},
+		{"passo sem texto", `{"nome":"Bolo","passos":[{"texto":" "}]}`, problema.CodigoValidacao, "passos[0].texto"},
+		{"dificuldade desconhecida", `{"nome":"Bolo","dificuldade":"extrema"}`, problema.CodigoValidacao, "dificuldade"},
+		{"corpo acima do limite", `{"nome":"` + strings.Repeat("a", tamanhoMaximoJSON) + `"}`, problema.CodigoMuitoGrande, ""},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			w := requisitar(t, router, editor, "POST", "/api/receitas", caso.corpo)
+			resposta := conferirProblema(t, w, caso.codigo)
+			if caso.campo != "" && (len(resposta.Erros) != 1 || resposta.Erros[0].Campo != caso.campo) {
+				t.Fatalf("erros = %+v, esperado o campo %q", resposta.Erros, caso.campo)
+			}
+		})
+	}
+}
+
+func TestAtualizarReceita(t *testing.T) {
+	router := novoRouterReceitas(t)
+	autor := novoUsuario(models.RoleEditor)
+	receita := criarReceita(t, router, autor, "Arroz", "2 xícaras de arroz")
+	caminho := "/api/receitas/" + receita.ID.String()
+	alteracao := map[string]any{"nome": "Arroz soltinho", "ingredientes": []string{"2 xícaras de arroz", "sal a gosto"}}
+
+	w := requisitar(t, router, novoUsuario(models.RoleEditor), "PUT", caminho, alteracao)
+	conferirProblema(t, w, problema.CodigoSemPermissao)
+	w = requisitar(t, router, novoUsuario(models.RoleReader), "PUT", caminho, alteracao)
+	conferirProblema(t, w, problema.CodigoSemPermissao)
+
+	w = requisitar(t, router, autor, "PUT", caminho, alteracao)
+	if w.Code != http.StatusOK {
+		t.Fatalf("autor: status %d: %s", w.Code, w.Body)
+	}
+	if atualizada := decodificar[models.Receita](t, w); atualizada.Nome != "Arroz soltinho" || len(atualizada.Ingredientes) != 2 {
+		t.Fatalf("receita atualizada = %+v", atualizada)
+	}
+
+	// O admin altera receitas de outros autores sem tomar a autoria
+	alteracao["nome"] = "Arroz branco"
+	w = requisitar(t, router, novoUsuario(models.RoleAdmin), "PUT", caminho, alteracao)
+	if w.Code != http.StatusOK {
+		t.Fatalf("admin: status %d: %s", w.Code, w.Body)
+	}
+	w = requisitar(t, router, autor, "GET", caminho, nil)
+	if lida := decodificar[models.Receita](t, w); lida.Nome != "Arroz branco" || lida.AutorID == nil || lida.AutorID.String() != autor.Subject {
+		t.Fatalf("receita depois do admin = %+v", lida)
+	}
+
+	w = requisitar(t, router, autor, "PUT", "/api/receitas/"+uuid.NewString(), alteracao)
+	conferirProblema(t, w, problema.CodigoNaoEncontrado)
+}
+
+func TestRemoverReceita(t *testing.T) {
+	router := novoRouterReceitas(t)
+	autor := novoUsuario(models.RoleEditor)
+	primeira := criarReceita(t, router, autor, "Feijoada", "1 kg de feijão preto")
+	segunda := criarReceita(t, router, autor, "Farofa", "2 xícaras de farinha de mandioca")
+
+	w := requisitar(t, router, novoUsuario(models.RoleEditor), "DELETE", "/api/receitas/"+primeira.ID.String(), nil)
+	conferirProblema(t, w, problema.CodigoSemPermissao)
+
+	w = requisitar(t, router, autor, "DELETE", "/api/receitas/"+primeira.ID.String(), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("autor: status %d: %s", w.Code, w.Body)
+	}
+	w = requisitar(t, router, autor, "GET", "/api/receitas/"+primeira.ID.String(), nil)
+	conferirProblema(t, w, problema.CodigoNaoEncontrado)
+	w = requisitar(t, router, autor, "DELETE", "/api/receitas/"+primeira.ID.String(), nil)
+	conferirProblema(t, w, problema.CodigoNaoEncontrado)
+
+	w = requisitar(t, router, novoUsuario(models.RoleAdmin), "DELETE", "/api/receitas/"+segunda.ID.String(), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("admin: status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestPapeisReceitas(t *testing.T) {
+	router := novoRouterReceitas(t)
+	leitor := novoUsuario(models.RoleReader)
+	corpo := map[string]any{"nome": "Pão de queijo"}
+
+	w := requisitar(t, router, leitor, "POST", "/api/receitas", corpo)
+	conferirProblema(t, w, problema.CodigoSemPermissao)
+	w = requisitar(t, router, nil, "POST", "/api/receitas", corpo)
+	conferirProblema(t, w, problema.CodigoNaoAutenticado)
+	w = requisitar(t, router, nil, "GET", "/api/receitas", nil)
+	conferirProblema(t, w, problema.CodigoNaoAutenticado)
+
+	w = requisitar(t, router, leitor, "GET", "/api/receitas", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("leitor: status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestListarReceitas(t *testing.T) {
+	router := novoRouterReceitas(t)
+	editor := novoUsuario(models.RoleEditor)
+	for _, nome := range []string{"Pudim", "Brigadeiro", "Quindim"} {
+		criarReceita(t, router, editor, nome, "1 lata de leite condensado")
+	}
+
+	var nomes []string
+	caminho := "/api/receitas?sort=nome&limit=2&fields=id,nome"
+	for paginas := 0; caminho != ""; paginas++ {
+		if paginas == 3 {
+			t.Fatal("paginacao nao terminou")
+		}
+		w := requisitar(t, router, editor, "GET", caminho, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		if total := w.Header().Get("X-Total-Count"); total != "3" {
+			t.Fatalf("X-Total-Count = %q", total)
+		}
+		for _, receita := range decodificar[[]map[string]any](t, w) {
+			if len(receita) != 2 {
+				t.Fatalf("fields=id,nome retornou %v", receita)
+			}
+			nomes = append(nomes, receita["nome"].(string))
+		}
+		caminho = ""
+		if link := w.Header().Get("Link"); link != "" {
+			caminho = link[strings.Index(link, "/api/"):strings.Index(link, ">")]
+		}
+	}
+	if strings.Join(nomes, ",") != "Brigadeiro,Pudim,Quindim" {
+		t.Fatalf("receitas = %v", nomes)
+	}
+
+	w := requisitar(t, router, editor, "GET", "/api/receitas?sort=-nome&limit=1", nil)
+	if receitas := decodificar[[]models.Receita](t, w); len(receitas) != 1 || receitas[0].Nome != "Quindim" {
+		t.Fatalf("sort=-nome = %+v", receitas)
+	}
+
+	for _, consulta := range []string{"limit=0", "sort=preco", "cursor=xyz", "fields=senha"} {
+		w := requisitar(t, router, editor, "GET", "/api/receitas?"+consulta, nil)
+		conferirProblema(t, w, problema.CodigoValidacao)
+	}
+}
+
+func TestBuscarReceitas(t *testing.T) {
+	router := novoRouterReceitas(t)
+	editor := novoUsuario(models.RoleEditor)
+	criarReceita(t, router, editor, "Bolo de chocolate", "200 g de chocolate meio amargo", "3 ovos")
+	criarReceita(t, router, editor, "Bolo de laranja", "2 laranjas", "3 ovos")
+	criarReceita(t, router, editor, "Omelete", "2 ovos")
+
+	w := requisitar(t, router, editor, "GET", "/api/receitas?q=chocolate", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	receitas := decodificar[[]models.Receita](t, w)
+	if len(receitas) != 1 || receitas[0].Nome != "Bolo de chocolate" || receitas[0].Busca == nil {
+		t.Fatalf("busca por chocolate = %+v", receitas)
+	}
+
+	w = requisitar(t, router, editor, "GET", "/api/receitas?q=bolo+-laranja", nil)
+	if receitas := decodificar[[]models.Receita](t, w); len(receitas) != 1 || receitas[0].Nome != "Bolo de chocolate" {
+		t.Fatalf("busca com exclusao = %+v", receitas)
+	}
+
+	w = requisitar(t, router, editor, "GET", "/api/receitas?tem=ovos,laranja", nil)
+	receitas = decodificar[[]models.Receita](t, w)
+	if len(receitas) != 2 {
+		t.Fatalf("tem=ovos,laranja = %+v", receitas)
+	}
+	for _, receita := range receitas {
+		if receita.Nome == "Bolo de chocolate" || receita.Despensa == nil || receita.Despensa.Faltando != 0 {
+			t.Fatalf("tem=ovos,laranja trouxe %q com despensa %+v", receita.Nome, receita.Despensa)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/imagens"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/repository"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Limite do corpo das requisicoes de envio: a imagem e a sobra do multipart
+const tamanhoMaximoEnvio = imagens.TamanhoMaximo + 1<<20
+
+// Preenche as URLs da capa e das fotos dos passos com o endereco do storage
+func (receitaHandler *ReceitaHandler) prepararURLs(receita *models.Receita) {
+	if receita.Capa != nil {
+		receita.Capa.PrepararURLs(receitaHandler.Storage.URL)
+	}
+	for i := range receita.Passos {
+		if receita.Passos[i].Foto != nil {
+			receita.Passos[i].Foto.PrepararURLs(receitaHandler.Storage.URL)
+		}
+	}
+}
+
+// Apaga do storage os arquivos das imagens. Chame depois do commit: um arquivo
+// que sobra ocupa espaco, mas uma linha sem arquivo quebra a imagem.
+func (receitaHandler *ReceitaHandler) removerArquivos(ctx context.Context, lista []models.Imagem) {
+	for _, imagem := range lista {
+		for _, chave := range imagem.Arquivos() {
+			if err := receitaHandler.Storage.Remover(ctx, chave); err != nil {
+				receitaHandler.Logger.ErrorContext(ctx, "removerArquivos: Erro ao remover arquivo", "chave", chave, "erro", err)
+			}
+		}
+	}
+}
+
+// UploadCapa godoc
+// @Summary Envia a capa da receita
+// @Description Recebe uma imagem JPEG, PNG, GIF ou WebP de até 10 MB no campo "imagem" e gera as miniaturas pequena (160px), media (480px) e grande (1024px). Substitui a capa anterior.
+// @Tags receitas
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da receita (UUID)"
+// @Param imagem formData file true "Imagem da capa"
+// @Success 201 {object} models.Imagem
+// @Failure 400 {object} problema.Problema
+// @Failure 403 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 415 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/receitas/{id}/capa [post]
+func (receitaHandler *ReceitaHandler) UploadCapa(w http.ResponseWriter, r *http.Request) {
+	receitaHandler.enviarImagem(w, r, false)
+}
+
+// DeleteCapa godoc
+// @Summary Remove a capa da receita
+// @Tags receitas
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da receita (UUID)"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} problema.Problema
+// @Failure 403 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/receitas/{id}/capa [delete]
+func (receitaHandler *ReceitaHandler) DeleteCapa(w http.ResponseWriter, r *http.Request) {
+	receitaHandler.removerImagem(w, r, false)
+}
+
+// UploadImagemPasso godoc
+// @Summary Envia a foto de um passo
+// @Description Como a capa, mas para o passo na posição indicada (a partir de 0). A foto fica com a posição: reordenar os passos não a acompanha, e remover o passo a apaga.
+// @Tags receitas
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da receita (UUID)"
+// @Param passo path int true "Posição do passo, a partir de 0"
+// @Param imagem formData file true "Foto do passo"
+// @Success 201 {object} models.Imagem
+// @Failure 400 {object} problema.Problema
+// @Failure 403 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 415 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/receitas/{id}/passos/{passo}/imagem [post]
+func (receitaHandler *ReceitaHandler) UploadImagemPasso(w http.ResponseWriter, r *http.Request) {
+	receitaHandler.enviarImagem(w, r, true)
+}
+
+// DeleteImagemPasso godoc
+// @Summary Remove a foto de um passo
+// @Tags receitas
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da receita (UUID)"
+// @Param passo path int true "Posição do passo, a partir de 0"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} problema.Problema
+// @Failure 403 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/receitas/{id}/passos/{passo}/imagem [delete]
+func (receitaHandler *ReceitaHandler) DeleteImagemPasso(w http.ResponseWriter, r *http.Request) {
+	receitaHandler.removerImagem(w, r, true)
+}
+
+// Valida o ID, a permissao e, nas fotos de passos, se o passo existe.
+// Em caso de falha ja escreve a resposta.
+func (receitaHandler *ReceitaHandler) lerAlvoImagem(w http.ResponseWriter, r *http.Request, comPasso bool) (uuid.UUID, *int, bool) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, "ID inválido")
+		return id, nil, false
+	}
+	if _, ok := receitaHandler.autorizarAlteracao(w, r, id); !ok {
+		return id, nil, false
+	}
+	if !comPasso {
+		return id, nil, true
+	}
+
+	passo, err := strconv.Atoi(vars["passo"])
+	if err != nil || passo < 0 {
+		problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, "Posição do passo inválida")
+		return id, nil, false
+	}
+	receita, err := receitaHandler.Repository.Obter(r.Context(), id)
+	if errors.Is(err, repository.ErrNaoEncontrada) {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Receita não encontrada")
+		return id, nil, false
+	}
+	if err != nil {
+		receitaHandler.Logger.ErrorContext(r.Context(), "lerAlvoImagem: Erro ao contar os passos da receita", "id", id, "erro", err)
+		problema.Interno(w, r)
+		return id, nil, false
+	}
+	if passo >= len(receita.Passos) {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Passo não encontrado")
+		return id, nil, false
+	}
+	return id, &passo, true
+}
+
+func (receitaHandler *ReceitaHandler) enviarImagem(w http.ResponseWriter, r *http.Request, comPasso bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, tamanhoMaximoEnvio)
+	id, passo, ok := receitaHandler.lerAlvoImagem(w, r, comPasso)
+	if !ok {
+		return
+	}
+
+	arquivo, _, err := r.FormFile("imagem")
+	var muitoGrande *http.MaxBytesError
+	if errors.As(err, &muitoGrande) {
+		problema.Escrever(w, r, problema.CodigoMuitoGrande, imagens.ErrMuitoGrande.Error())
+		return
+	}
+	if err != nil {
+		problema.Validacao(w, r, `Envie a imagem no campo "imagem" de um formulário multipart`, problema.Campo{Campo: "imagem", Mensagem: "obrigatório"})
+		return
+	}
+	defer arquivo.Close()
+	dados, err := io.ReadAll(io.LimitReader(arquivo, imagens.TamanhoMaximo+1))
+	if err != nil {
+		receitaHandler.Logger.InfoContext(r.Context(), "enviarImagem: Erro ao ler o arquivo enviado", "id", id, "erro", err)
+		problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, "Não foi possível ler a imagem enviada")
+		return
+	}
+
+	processada, err := imagens.Processar(dados)
+	switch {
+	case errors.Is(err, imagens.ErrMuitoGrande):
+		problema.Escrever(w, r, problema.CodigoMuitoGrande, err.Error())
+		return
+	case errors.Is(err, imagens.ErrFormato):
+		problema.Escrever(w, r, problema.CodigoTipoNaoSuportado, err.Error())
+		return
+	case err != nil:
+		receitaHandler.Logger.ErrorContext(r.Context(), "enviarImagem: Erro ao gerar miniaturas", "id", id, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+
+	// Cada envio ganha uma chave nova, entao as URLs antigas podem ficar em cache para sempre
+	imagem := models.Imagem{
+		ID:       uuid.New(),
+		Passo:    passo,
+		Extensao: processada.Extensao,
+		Largura:  processada.Largura,
+		Altura:   processada.Altura,
+	}
+	imagem.Chave = fmt.Sprintf("receitas/%s/%s", id, imagem.ID)
+
+	ctx := r.Context()
+	gravados := []models.Imagem{imagem}
+	err = receitaHandler.Storage.Salvar(ctx, imagem.ArquivoOriginal(), processada.Original, processada.ContentType)
+	for _, tamanho := range imagens.Tamanhos {
+		if err != nil {
+			break
+		}
+		err = receitaHandler.Storage.Salvar(ctx, imagem.ArquivoMiniatura(tamanho.Nome), processada.Miniaturas[tamanho.Nome], "image/jpeg")
+	}
+	if err != nil {
+		receitaHandler.Logger.ErrorContext(ctx, "enviarImagem: Erro ao gravar a imagem da receita", "id", id, "erro", err)
+		receitaHandler.removerArquivos(context.WithoutCancel(ctx), gravados)
+		problema.Interno(w, r)
+		return
+	}
+
+	antigas, err := receitaHandler.Repository.SalvarImagem(ctx, id, imagem, processada.ContentType)
+	if err != nil {
+		receitaHandler.removerArquivos(context.WithoutCancel(ctx), gravados)
+		if errors.Is(err, repository.ErrConflito) {
+			problema.Escrever(w, r, problema.CodigoConflito, "Outra imagem foi enviada ao mesmo tempo; tente novamente")
+			return
+		}
+		if errors.Is(err, repository.ErrNaoEncontrada) {
+			problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Receita não encontrada")
+			return
+		}
+		receitaHandler.Logger.ErrorContext(ctx, "enviarImagem: Erro ao registrar a imagem da receita", "id", id, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+	receitaHandler.removerArquivos(context.WithoutCancel(ctx), antigas)
+
+	imagem.PrepararURLs(receitaHandler.Storage.URL)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(imagem)
+}
+
+func (receitaHandler *ReceitaHandler) removerImagem(w http.ResponseWriter, r *http.Request, comPasso bool) {
+	id, passo, ok := receitaHandler.lerAlvoImagem(w, r, comPasso)
+	if !ok {
+		return
+	}
+
+	removidas, err := receitaHandler.Repository.RemoverImagem(r.Context(), id, passo)
+	if err != nil {
+		receitaHandler.Logger.ErrorContext(r.Context(), "removerImagem: Erro ao remover a imagem da receita", "id", id, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+	if len(removidas) == 0 {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Imagem não encontrada")
+		return
+	}
+	receitaHandler.removerArquivos(context.WithoutCancel(r.Context()), removidas)
+	w.WriteHeader(http.StatusNoContent)
+}
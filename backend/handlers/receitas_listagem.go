@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/repository"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+	"github.com/google/uuid"
+)
+
+// Tamanho de pagina da listagem
+const (
+	limitePadrao = 20
+	limiteMaximo = 100
+)
+
+// Limite do texto de busca, em bytes
+const tamanhoMaximoBusca = 200
+
+// Limite de ingredientes em "tem"
+const maximoDisponiveis = 50
+
+// Campos aceitos em "fields", iguais as chaves do JSON de models.Receita
+var camposReceita = map[string]bool{
+	"id": true, "nome": true, "descricao": true, "ingredientes": true, "ingredientes_estruturados": true,
+	"instrucoes": true, "passos": true, "porcoes": true, "autor_id": true, "criado_em": true, "busca": true, "despensa": true,
+	"capa": true, "tags": true, "categoria": true, "cozinha": true,
+	"tempo_preparo": true, "tempo_cozimento": true, "tempo_descanso": true, "tempo_total": true, "dificuldade": true,
+}
+
+// O cursor vai para o cliente como texto opaco
+func codificarCursor(cursor repository.Cursor) string {
+	dados, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(dados)
+}
+
+// Le o cursor da query string. O valor passa a ter o tipo do campo de ordenacao
+// (Ordenacao.Valor), para que um cursor alterado pelo cliente nao chegue ao banco.
+func decodificarCursor(texto string, ordem repository.Ordenacao) (repository.Cursor, error) {
+	var lido struct {
+		Ordem string          `json:"o"`
+		Valor json.RawMessage `json:"v"`
+		ID    uuid.UUID       `json:"id"`
+	}
+	dados, err := base64.RawURLEncoding.DecodeString(texto)
+	if err != nil || json.Unmarshal(dados, &lido) != nil || lido.ID == uuid.Nil {
+		return repository.Cursor{}, models.ErroCampo("cursor", "cursor inválido")
+	}
+	if lido.Ordem != ordem.String() {
+		return repository.Cursor{}, models.ErroCampo("cursor", "cursor gerado para outra ordenação")
+	}
+	valor, err := valorCursor(ordem, lido.Valor)
+	if err != nil {
+		return repository.Cursor{}, models.ErroCampo("cursor", "cursor inválido")
+	}
+	return repository.Cursor{Ordem: lido.Ordem, Valor: valor, ID: lido.ID}, nil
+}
+
+// Valor do cursor no tipo da ordenacao: data RFC 3339 para criado_em, numero
+// para relevancia, inteiro para faltando e texto para nome
+func valorCursor(ordem repository.Ordenacao, dados json.RawMessage) (any, error) {
+	if len(dados) == 0 || string(dados) == "null" {
+		return nil, errors.New("cursor sem valor")
+	}
+	switch ordem.Campo {
+	case "criado_em":
+		var momento time.Time
+		err := json.Unmarshal(dados, &momento)
+		return momento, err
+	case "relevancia":
+		var relevancia float64
+		err := json.Unmarshal(dados, &relevancia)
+		return relevancia, err
+	case "faltando":
+		var faltando int
+		err := json.Unmarshal(dados, &faltando)
+		return faltando, err
+	}
+	var nome string
+	err := json.Unmarshal(dados, &nome)
+	return nome, err
+}
+
+// Opcoes da listagem lidas da query string: os filtros que vao para o repositorio
+// e o que e aplicado na resposta
+type listagemReceitas struct {
+	consulta repository.Consulta
+	busca    string
+	campos   []string
+	sistema  units.Sistema
+}
+
+func lerListagem(r *http.Request) (listagemReceitas, error) {
+	query := r.URL.Query()
+	var opcoes listagemReceitas
+	var err error
+
+	if opcoes.sistema, err = sistemaUnidades(r); err != nil {
+		return opcoes, err
+	}
+
+	opcoes.busca = strings.TrimSpace(query.Get("q"))
+	if len(opcoes.busca) > tamanhoMaximoBusca {
+		return opcoes, models.ErroCampo("q", "q deve ter no máximo %d caracteres", tamanhoMaximoBusca)
+	}
+
+	if valor := query.Get("tem"); valor != "" {
+		vistos := map[string]bool{}
+		for _, nome := range strings.Split(valor, ",") {
+			if nome = models.NomeNormalizado(nome); nome != "" && !vistos[nome] {
+				vistos[nome] = true
+				opcoes.consulta.Disponiveis = append(opcoes.consulta.Disponiveis, nome)
+			}
+		}
+		if len(opcoes.consulta.Disponiveis) > maximoDisponiveis {
+			return opcoes, models.ErroCampo("tem", "tem aceita no máximo %d ingredientes", maximoDisponiveis)
+		}
+	}
+	if valor := query.Get("faltando_max"); valor != "" {
+		if len(opcoes.consulta.Disponiveis) == 0 {
+			return opcoes, models.ErroCampo("faltando_max", "faltando_max exige o parâmetro tem")
+		}
+		opcoes.consulta.FaltandoMax, err = strconv.Atoi(valor)
+		if err != nil || opcoes.consulta.FaltandoMax < 0 {
+			return opcoes, models.ErroCampo("faltando_max", "faltando_max deve ser um inteiro não negativo")
+		}
+	}
+
+	if valor := query.Get("tag"); valor != "" {
+		opcoes.consulta.Tags = models.Slugs(strings.Split(valor, ","))
+	}
+	opcoes.consulta.Categoria = models.Slug(query.Get("categoria"))
+	opcoes.consulta.Cozinha = models.Slug(query.Get("cozinha"))
+	for parametro, destino := range map[string]**int{"tempo_min": &opcoes.consulta.TempoMin, "tempo_max": &opcoes.consulta.TempoMax, "preparo_max": &opcoes.consulta.PreparoMax} {
+		if valor := query.Get(parametro); valor != "" {
+			minutos, err := strconv.Atoi(valor)
+			if err != nil || minutos < 0 {
+				return opcoes, models.ErroCampo(parametro, "%s deve ser um número de minutos não negativo", parametro)
+			}
+			*destino = &minutos
+		}
+	}
+	if valor := query.Get("dificuldade"); valor != "" {
+		for _, texto := range strings.Split(valor, ",") {
+			dificuldade, err := models.ParseDificuldade(texto)
+			if err != nil {
+				return opcoes, err
+			}
+			opcoes.consulta.Dificuldades = append(opcoes.consulta.Dificuldades, dificuldade)
+		}
+	}
+	if valor := query.Get("facetas"); valor != "" {
+		if opcoes.consulta.Facetas, err = strconv.ParseBool(valor); err != nil {
+			return opcoes, models.ErroCampo("facetas", "facetas deve ser true ou false")
+		}
+	}
+
+	// Filtros por ingredientes vem do que falta menos; buscas por relevancia; o resto por nome
+	sort := query.Get("sort")
+	switch {
+	case sort != "":
+	case len(opcoes.consulta.Disponiveis) > 0:
+		sort = "faltando"
+	case opcoes.busca != "":
+		sort = "-relevancia"
+	default:
+		sort = "nome"
+	}
+	if opcoes.consulta.Ordem, err = repository.ParseOrdenacao(sort); err != nil {
+		return opcoes, models.ErroCampo("sort", "%s", err.Error())
+	}
+	if opcoes.consulta.Ordem.Campo == "relevancia" && opcoes.busca == "" {
+		return opcoes, models.ErroCampo("sort", "ordenação por relevancia exige o parâmetro q")
+	}
+	if opcoes.consulta.Ordem.Campo == "faltando" && len(opcoes.consulta.Disponiveis) == 0 {
+		return opcoes, models.ErroCampo("sort", "ordenação por faltando exige o parâmetro tem")
+	}
+
+	opcoes.consulta.Limite = limitePadrao
+	if valor := query.Get("limit"); valor != "" {
+		opcoes.consulta.Limite, err = strconv.Atoi(valor)
+		if err != nil || opcoes.consulta.Limite < 1 || opcoes.consulta.Limite > limiteMaximo {
+			return opcoes, models.ErroCampo("limit", "limit deve ser um inteiro entre 1 e %d", limiteMaximo)
+		}
+	}
+
+	if valor := query.Get("cursor"); valor != "" {
+		cursor, err := decodificarCursor(valor, opcoes.consulta.Ordem)
+		if err != nil {
+			return opcoes, err
+		}
+		opcoes.consulta.Cursor = &cursor
+	}
+
+	if valor := query.Get("fields"); valor != "" {
+		for _, campo := range strings.Split(valor, ",") {
+			campo = strings.TrimSpace(campo)
+			if campo == "" {
+				continue
+			}
+			if !camposReceita[campo] {
+				return opcoes, models.ErroCampo("fields", "campo desconhecido em fields: %q", campo)
+			}
+			opcoes.campos = append(opcoes.campos, campo)
+		}
+	}
+	return opcoes, nil
+}
+
+// Link da proxima pagina, com os mesmos parametros e o novo cursor
+func linkProximaPagina(r *http.Request, cursor repository.Cursor) string {
+	query := r.URL.Query()
+	query.Set("cursor", codificarCursor(cursor))
+	proxima := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="next"`, proxima.String())
+}
+
+// Mantem apenas os campos pedidos em "fields"; o id sempre vai junto
+func selecionarCampos(receitas []models.Receita, campos []string) ([]map[string]json.RawMessage, error) {
+	selecionadas := make([]map[string]json.RawMessage, 0, len(receitas))
+	for _, receita := range receitas {
+		dados, err := json.Marshal(receita)
+		if err != nil {
+			return nil, err
+		}
+		var completa map[string]json.RawMessage
+		if err := json.Unmarshal(dados, &completa); err != nil {
+			return nil, err
+		}
+
+		parcial := map[string]json.RawMessage{"id": completa["id"]}
+		for _, campo := range campos {
+			if valor, ok := completa[campo]; ok {
+				parcial[campo] = valor
+			}
+		}
+		selecionadas = append(selecionadas, parcial)
+	}
+	return selecionadas, nil
+}
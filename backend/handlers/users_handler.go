@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/auth"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type UserHandler struct {
+	DBConnection *sql.DB
+	Sessions     *auth.SessionStore
+	Logger       *slog.Logger
+}
+
+// Construtor de UserHandler
+func NewUserHandler(dbConnection *sql.DB, sessions *auth.SessionStore, logger *slog.Logger) *UserHandler {
+	return &UserHandler{DBConnection: dbConnection, Sessions: sessions, Logger: logger}
+}
+
+// Corpo aceito na troca de papel
+type RoleRequest struct {
+	Role string `json:"role" example:"editor"`
+}
+
+// ReadUsers godoc
+// @Summary Lista os usuários
+// @Description Retorna todos os usuários cadastrados com seus papéis. Exige papel admin.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.User
+// @Failure 403 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/users [get]
+func (userHandler *UserHandler) ReadUsers(w http.ResponseWriter, r *http.Request) {
+	rows, err := userHandler.DBConnection.Query(`SELECT id, username, role, criado_em FROM users ORDER BY username`)
+	if err != nil {
+		userHandler.Logger.ErrorContext(r.Context(), "ReadUsers: Erro ao listar usuários", "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Role, &user.CriadoEm); err != nil {
+			userHandler.Logger.ErrorContext(r.Context(), "ReadUsers: Erro ao ler usuário", "erro", err)
+			problema.Interno(w, r)
+			return
+		}
+		users = append(users, user)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// UpdateUserRole godoc
+// @Summary Altera o papel de um usuário
+// @Description Define o papel (admin, editor ou reader) de um usuário. Exige papel admin. Quando o papel muda, as sessões do usuário são encerradas na hora: os tokens já emitidos deixam de valer e ele precisa entrar de novo para receber um token com o novo papel.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do usuário (UUID)"
+// @Param role body RoleRequest true "Novo papel"
+// @Success 200 {object} models.User
+// @Failure 400 {object} problema.Problema
+// @Failure 403 {object} problema.Problema
+// @Failure 404 {object} problema.Problema
+// @Failure 413 {object} problema.Problema
+// @Failure 500 {object} problema.Problema
+// @Router /api/users/{id}/role [put]
+func (userHandler *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		problema.Escrever(w, r, problema.CodigoRequisicaoInvalida, "ID inválido")
+		return
+	}
+
+	var body RoleRequest
+	if err := lerJSON(w, r, &body); err != nil {
+		erroJSON(w, r, err)
+		return
+	}
+	if !models.RoleValida(body.Role) {
+		erroValidacao(w, r, models.ErroCampo("role", "Papel inválido, use admin, editor ou reader"))
+		return
+	}
+
+	user, alterado, err := userHandler.alterarPapel(r.Context(), id, body.Role)
+	if err == sql.ErrNoRows {
+		problema.Escrever(w, r, problema.CodigoNaoEncontrado, "Usuário não encontrado")
+		return
+	}
+	if err != nil {
+		userHandler.Logger.ErrorContext(r.Context(), "UpdateUserRole: Erro ao alterar papel do usuário", "usuario_id", id, "erro", err)
+		problema.Interno(w, r)
+		return
+	}
+
+	if alterado {
+		userHandler.Logger.InfoContext(r.Context(), "UpdateUserRole: Papel do usuário alterado, sessões encerradas", "usuario", user.Username, "papel", user.Role)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// Grava o novo papel e, se ele mudou, revoga as sessoes do usuario na mesma
+// transacao: um admin rebaixado nao pode seguir usando o token antigo ate expirar
+func (userHandler *UserHandler) alterarPapel(ctx context.Context, id uuid.UUID, role string) (models.User, bool, error) {
+	var user models.User
+	tx, err := userHandler.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return user, false, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, username, role, criado_em FROM users WHERE id = $1`
+	// No SQLite a transacao ja comeca com o banco travado para escrita
+	if !database.SQLite(userHandler.DBConnection) {
+		query += ` FOR UPDATE`
+	}
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Role, &user.CriadoEm); err != nil {
+		return user, false, err
+	}
+	if user.Role == role {
+		return user, false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET role = $1 WHERE id = $2`, role, id); err != nil {
+		return user, false, err
+	}
+	if err := userHandler.Sessions.RevogarDoUsuario(ctx, tx, id); err != nil {
+		return user, false, err
+	}
+	user.Role = role
+	return user, true, tx.Commit()
+}
@@ -0,0 +1,126 @@
+// Package imagens valida as imagens enviadas e gera as miniaturas servidas
+// junto com as receitas.
+package imagens
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Limites do arquivo enviado. O limite de pixels evita imagens pequenas no
+// disco que ocupariam gigabytes depois de decodificadas.
+const (
+	TamanhoMaximo = 10 << 20
+	PixelsMaximo  = 40_000_000
+)
+
+var (
+	ErrFormato     = errors.New("formato de imagem não suportado (use JPEG, PNG, GIF ou WebP)")
+	ErrMuitoGrande = fmt.Errorf("imagem muito grande (máximo de %d MB e %d megapixels)", TamanhoMaximo>>20, PixelsMaximo/1_000_000)
+)
+
+// Miniatura gerada: cabe em um quadrado de Lado pixels, sem ampliar a original
+type Tamanho struct {
+	Nome string
+	Lado int
+}
+
+var Tamanhos = []Tamanho{
+	{Nome: "pequena", Lado: 160},
+	{Nome: "media", Lado: 480},
+	{Nome: "grande", Lado: 1024},
+}
+
+// Qualidade JPEG das miniaturas
+const qualidade = 82
+
+// Imagem validada, pronta para ser gravada
+type Processada struct {
+	Original    []byte
+	ContentType string
+	Extensao    string // Da original: jpg, png, gif ou webp
+	Largura     int
+	Altura      int
+	Miniaturas  map[string][]byte // JPEG por nome do tamanho
+}
+
+var formatos = map[string]struct{ contentType, extensao string }{
+	"jpeg": {"image/jpeg", "jpg"},
+	"png":  {"image/png", "png"},
+	"gif":  {"image/gif", "gif"},
+	"webp": {"image/webp", "webp"},
+}
+
+// Valida o arquivo e gera as miniaturas de Tamanhos. A original e guardada como
+// veio; as miniaturas sao JPEG, com a transparencia sobre fundo branco.
+func Processar(dados []byte) (*Processada, error) {
+	if len(dados) > TamanhoMaximo {
+		return nil, ErrMuitoGrande
+	}
+	config, formato, err := image.DecodeConfig(bytes.NewReader(dados))
+	if err != nil {
+		return nil, ErrFormato
+	}
+	tipo, ok := formatos[formato]
+	if !ok {
+		return nil, ErrFormato
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > PixelsMaximo {
+		return nil, ErrMuitoGrande
+	}
+
+	original, _, err := image.Decode(bytes.NewReader(dados))
+	if err != nil {
+		return nil, ErrFormato
+	}
+
+	processada := &Processada{
+		Original:    dados,
+		ContentType: tipo.contentType,
+		Extensao:    tipo.extensao,
+		Largura:     config.Width,
+		Altura:      config.Height,
+		Miniaturas:  map[string][]byte{},
+	}
+	for _, tamanho := range Tamanhos {
+		miniatura, err := Redimensionar(original, tamanho.Lado)
+		if err != nil {
+			return nil, err
+		}
+		processada.Miniaturas[tamanho.Nome] = miniatura
+	}
+	return processada, nil
+}
+
+// Reduz a imagem para caber em lado x lado, mantendo a proporcao, e codifica em JPEG
+func Redimensionar(img image.Image, lado int) ([]byte, error) {
+	origem := img.Bounds()
+	largura, altura := origem.Dx(), origem.Dy()
+	if largura > lado || altura > lado {
+		if largura >= altura {
+			largura, altura = lado, max(1, altura*lado/largura)
+		} else {
+			largura, altura = max(1, largura*lado/altura), lado
+		}
+	}
+
+	destino := image.NewRGBA(image.Rect(0, 0, largura, altura))
+	draw.Draw(destino, destino.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(destino, destino.Bounds(), img, origem, draw.Over, nil)
+
+	var saida bytes.Buffer
+	if err := jpeg.Encode(&saida, destino, &jpeg.Options{Quality: qualidade}); err != nil {
+		return nil, err
+	}
+	return saida.Bytes(), nil
+}
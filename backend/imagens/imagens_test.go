@@ -0,0 +1,113 @@
+package imagens
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func pngTeste(t *testing.T, largura, altura int, cor color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, largura, altura))
+	for y := range altura {
+		for x := range largura {
+			img.Set(x, y, cor)
+		}
+	}
+	var saida bytes.Buffer
+	if err := png.Encode(&saida, img); err != nil {
+		t.Fatal(err)
+	}
+	return saida.Bytes()
+}
+
+// Cabecalho GIF com as dimensoes pedidas: DecodeConfig le apenas o cabecalho
+func cabecalhoGIF(largura, altura uint16) []byte {
+	dados := []byte("GIF89a")
+	dados = binary.LittleEndian.AppendUint16(dados, largura)
+	dados = binary.LittleEndian.AppendUint16(dados, altura)
+	return append(dados, 0, 0, 0)
+}
+
+func TestProcessarRecusa(t *testing.T) {
+	var jpegTruncado bytes.Buffer
+	if err := jpeg.Encode(&jpegTruncado, image.NewGray(image.Rect(0, 0, 64, 64)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct {
+		nome  string
+		dados []byte
+		erro  error
+	}{
+		{"arquivo maior que o limite", make([]byte, TamanhoMaximo+1), ErrMuitoGrande},
+		{"pixels demais", cabecalhoGIF(10_000, 5_000), ErrMuitoGrande},
+		{"largura zero", cabecalhoGIF(0, 100), ErrMuitoGrande},
+		{"texto", []byte("nao e uma imagem"), ErrFormato},
+		{"BMP", append([]byte("BM"), make([]byte, 64)...), ErrFormato},
+		{"vazio", nil, ErrFormato},
+		{"JPEG truncado", jpegTruncado.Bytes()[:jpegTruncado.Len()/2], ErrFormato},
+	}
+	for _, caso := range casos {
+		if _, err := Processar(caso.dados); !errors.Is(err, caso.erro) {
+			t.Errorf("%s: erro %v, esperado %v", caso.nome, err, caso.erro)
+		}
+	}
+}
+
+func TestProcessarMiniaturas(t *testing.T) {
+	casos := []struct {
+		nome      string
+		largura   int
+		altura    int
+		esperadas map[string]image.Point
+	}{
+		{"paisagem", 2000, 1000, map[string]image.Point{"pequena": {160, 80}, "media": {480, 240}, "grande": {1024, 512}}},
+		{"retrato", 600, 1200, map[string]image.Point{"pequena": {80, 160}, "media": {240, 480}, "grande": {512, 1024}}},
+		// Imagens menores que o tamanho nao sao ampliadas
+		{"pequena", 100, 300, map[string]image.Point{"pequena": {53, 160}, "media": {100, 300}, "grande": {100, 300}}},
+		{"faixa", 3000, 2, map[string]image.Point{"pequena": {160, 1}, "media": {480, 1}, "grande": {1024, 1}}},
+	}
+	for _, caso := range casos {
+		processada, err := Processar(pngTeste(t, caso.largura, caso.altura, color.NRGBA{R: 200, A: 255}))
+		if err != nil {
+			t.Fatalf("%s: %v", caso.nome, err)
+		}
+		if processada.Largura != caso.largura || processada.Altura != caso.altura || processada.Extensao != "png" || processada.ContentType != "image/png" {
+			t.Fatalf("%s: %dx%d %s %s", caso.nome, processada.Largura, processada.Altura, processada.Extensao, processada.ContentType)
+		}
+		if len(processada.Miniaturas) != len(Tamanhos) {
+			t.Fatalf("%s: %d miniaturas, esperado %d", caso.nome, len(processada.Miniaturas), len(Tamanhos))
+		}
+		for nome, esperada := range caso.esperadas {
+			miniatura, err := jpeg.Decode(bytes.NewReader(processada.Miniaturas[nome]))
+			if err != nil {
+				t.Fatalf("%s: miniatura %s nao e JPEG: %v", caso.nome, nome, err)
+			}
+			if tamanho := miniatura.Bounds().Size(); tamanho != esperada {
+				t.Errorf("%s: miniatura %s com %v, esperado %v", caso.nome, nome, tamanho, esperada)
+			}
+		}
+	}
+}
+
+// A transparencia vira fundo branco na miniatura JPEG
+func TestProcessarTransparencia(t *testing.T) {
+	processada, err := Processar(pngTeste(t, 20, 20, color.NRGBA{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	miniatura, err := jpeg.Decode(bytes.NewReader(processada.Miniaturas["pequena"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, g, b, _ := miniatura.At(10, 10).RGBA()
+	if r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Fatalf("pixel transparente virou (%d, %d, %d), esperado branco", r>>8, g>>8, b>>8)
+	}
+}
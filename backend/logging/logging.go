@@ -0,0 +1,122 @@
+// Package logging monta o logger estruturado (log/slog) da API. Cada linha
+// registrada com um contexto de requisicao leva o request_id e o trace_id dela,
+// e atributos sensiveis (tokens, senhas, segredos, o cabecalho Authorization)
+// saem mascarados.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formatos de saida aceitos
+const (
+	FormatoJSON  = "json"
+	FormatoTexto = "text"
+)
+
+// Texto que substitui os valores mascarados
+const Mascarado = "[REDACTED]"
+
+// Trechos de nomes de atributo (e de cabecalhos) cujo valor nunca vai para o log
+var nomesSensiveis = []string{"authorization", "cookie", "token", "password", "senha", "secret", "access_key", "api_key"}
+
+// Cria o logger. nivel e debug, info, warn ou error; formato e json ou text.
+func New(saida io.Writer, nivel, formato string) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(nivel)); err != nil {
+		return nil, fmt.Errorf("nível de log inválido: %q (use debug, info, warn ou error)", nivel)
+	}
+
+	opcoes := &slog.HandlerOptions{Level: level, ReplaceAttr: mascarar}
+	var handler slog.Handler
+	switch formato {
+	case FormatoJSON:
+		handler = slog.NewJSONHandler(saida, opcoes)
+	case FormatoTexto:
+		handler = slog.NewTextHandler(saida, opcoes)
+	default:
+		return nil, fmt.Errorf("formato de log inválido: %q (use json ou text)", formato)
+	}
+	return slog.New(comContexto{handler}), nil
+}
+
+// Sensivel informa se um atributo ou cabecalho com este nome deve ser mascarado
+func Sensivel(nome string) bool {
+	nome = strings.ToLower(nome)
+	for _, trecho := range nomesSensiveis {
+		if strings.Contains(nome, trecho) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceAttr dos handlers: mascara atributos sensiveis pelo nome, valores com
+// cara de bearer token e os cabecalhos sensiveis de um http.Header
+func mascarar(grupos []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindGroup {
+		return attr
+	}
+	if Sensivel(attr.Key) {
+		return slog.String(attr.Key, Mascarado)
+	}
+	switch valor := attr.Value.Any().(type) {
+	case string:
+		if strings.HasPrefix(strings.ToLower(valor), "bearer ") {
+			return slog.String(attr.Key, "Bearer "+Mascarado)
+		}
+	case http.Header:
+		limpo := valor.Clone()
+		for nome := range limpo {
+			if Sensivel(nome) {
+				limpo[nome] = []string{Mascarado}
+			}
+		}
+		return slog.Any(attr.Key, limpo)
+	}
+	return attr
+}
+
+type chaveContexto struct{}
+
+// Guarda o request ID no contexto da requisicao
+func ComRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, chaveContexto{}, id)
+}
+
+// Request ID da requisicao, ou vazio fora de uma
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(chaveContexto{}).(string)
+	return id
+}
+
+// Handler que acrescenta o request_id e o trace_id/span_id do contexto a cada
+// registro, para ligar o log ao trace da requisicao
+type comContexto struct {
+	slog.Handler
+}
+
+func (handler comContexto) Handle(ctx context.Context, registro slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		registro.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		registro.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return handler.Handler.Handle(ctx, registro)
+}
+
+func (handler comContexto) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return comContexto{handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler comContexto) WithGroup(nome string) slog.Handler {
+	return comContexto{handler.Handler.WithGroup(nome)}
+}
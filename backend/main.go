@@ -1,70 +1,210 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os" // Certifique-se que 'os' está importado!
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/auth"
 	"github.com/Bruno-Fagundes/crud-receitas-culinarias/config"
 	_ "github.com/Bruno-Fagundes/crud-receitas-culinarias/docs"
 	"github.com/Bruno-Fagundes/crud-receitas-culinarias/handlers"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/logging"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/metrics"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/migrations"
 	"github.com/Bruno-Fagundes/crud-receitas-culinarias/middleware"
 	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/tracing"
 
 	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
 
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// @title API Receitas Culinárias
+// @version 1.0
+// @description Esta é a API para gerenciamento de receitas com JWT
+// @host localhost:5555
+// @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
-	// Carrega .env
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Erro ao carregar .env")
+	// Variaveis de ambiente, .env e CONFIG_FILE, validados antes de qualquer conexao
+	cfg, err := config.Carregar()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Logs em JSON (ou texto) com request_id; o pacote log tambem passa por ele
+	logger, err := logging.New(os.Stderr, cfg.Log.Nivel, cfg.Log.Formato)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+	desligarTracing, err := tracing.Configurar(context.Background(), cfg.Tracing.Exportador, cfg.Tracing.Endpoint, cfg.Tracing.NomeServico)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	// *** ADICIONE ESTA LINHA AQUI ***
-	log.Println("DEBUG: JWT_SECRET carregado no backend:", os.Getenv("JWT_SECRET"))
-	// ******************************
-
-	db := config.SetupDB()
+	db := config.SetupDB(cfg.DB)
 	defer db.Close()
-	if _, err := db.Exec(models.CreateTableQuery); err != nil {
-		log.Fatalf("Erro ao criar tabela: %v", err)
+	if err := metrics.RegistrarBanco(db, cfg.DB.Driver); err != nil {
+		log.Fatal(err)
+	}
+	migrador, err := migrations.NewMigrador(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// "migrate up|down|status" gerencia o esquema e sai sem subir o servidor
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := executarMigrate(context.Background(), migrador, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	// Com AUTO_MIGRATE=false as migrations ficam a cargo de "migrate up" no deploy
+	if cfg.AutoMigrate {
+		if n, err := migrador.Up(context.Background()); err != nil {
+			log.Fatalf("Erro ao aplicar migrations: %v", err)
+		} else if n > 0 {
+			slog.Info("Migrations aplicadas", "total", n)
+		}
 	}
+	// "promote <usuario>" cria um admin e sai sem subir o servidor
+	if len(os.Args) > 1 && os.Args[1] == "promote" {
+		if err := executarPromote(context.Background(), db, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	midiaStorage := config.SetupStorage(cfg.Storage)
+	receitaHandler := handlers.NewReceitaHandler(config.SetupReceitaRepository(cfg.Repositorio, db), midiaStorage, logger)
+	midiaHandler := handlers.NewMidiaHandler(midiaStorage, logger)
+	sessionStore := auth.NewSessionStore(db)
+	segundoPlano := novasTarefas()
+	segundoPlano.iniciar(func(ctx context.Context) { sessionStore.LimparPeriodicamente(ctx, time.Hour) })
+
+	// Chaves de assinatura: EdDSA por padrao, rotacionadas a cada 30 dias
+	// Ja validada por config.Carregar
+	chaveCifragem, _ := auth.DecodificarChaveCifragem(cfg.JWT.ChaveCifragem)
+	keyManager, err := auth.NewKeyManager(db, cfg.JWT.Algoritmo, cfg.JWT.Rotacao, cfg.JWT.DuracaoToken, chaveCifragem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := keyManager.Iniciar(context.Background()); err != nil {
+		log.Fatalf("Erro ao carregar chaves de assinatura: %v", err)
+	}
+	segundoPlano.iniciar(func(ctx context.Context) { keyManager.RotacionarPeriodicamente(ctx, time.Minute) })
 
-	receitaHandler := handlers.NewReceitaHandler(db)
+	authHandler := handlers.NewAuthHandler(db, sessionStore, keyManager, logger)
+	userHandler := handlers.NewUserHandler(db, sessionStore, logger)
+	healthHandler := handlers.NewHealthHandler(db, migrador, keyManager, logger)
 
 	router := mux.NewRouter()
+	// Rota ou metodo inexistente tambem respondem com problem+json
+	router.NotFoundHandler = http.HandlerFunc(problema.RotaNaoEncontrada)
+	router.MethodNotAllowedHandler = http.HandlerFunc(problema.MetodoNaoPermitido)
+	// Guarda o template da rota casada para o tracing e as metricas
+	router.Use(middleware.Rota)
 	// Public
-	router.HandleFunc("/login", handlers.LoginHandler).Methods("POST")
+	router.HandleFunc("/healthz", healthHandler.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.Readiness).Methods("GET")
+	router.HandleFunc("/register", authHandler.RegisterHandler).Methods("POST")
+	router.HandleFunc("/login", authHandler.LoginHandler).Methods("POST")
+	router.HandleFunc("/refresh", authHandler.RefreshHandler).Methods("POST")
+	router.HandleFunc("/.well-known/jwks.json", authHandler.JWKSHandler).Methods("GET")
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	router.HandleFunc("/midia/{chave:.+}", midiaHandler.ReadMidia).Methods("GET")
 
 	// Protegidas
+	jwtMiddleware := middleware.JWTMiddleware(keyManager, sessionStore, logger)
+	router.Handle("/logout", jwtMiddleware(http.HandlerFunc(authHandler.LogoutHandler))).Methods("POST")
+
 	api := router.PathPrefix("/api").Subrouter()
-	api.Use(middleware.JWTMiddleware)
-	api.HandleFunc("/receitas", receitaHandler.ReadReceitas).Methods("GET")
-	api.HandleFunc("/receitas/{id}", receitaHandler.ReadReceitasById).Methods("GET")
-	api.HandleFunc("/receitas", receitaHandler.CreateReceitas).Methods("POST")
-	api.HandleFunc("/receitas/{id}", receitaHandler.DeleteReceitas).Methods("DELETE")
-	api.HandleFunc("/receitas/{id}", receitaHandler.UpdateReceitas).Methods("PUT")
+	api.Use(jwtMiddleware)
+	reader := middleware.RequireRole(models.RoleReader)
+	editor := middleware.RequireRole(models.RoleEditor)
+	admin := middleware.RequireRole(models.RoleAdmin)
+
+	api.Handle("/receitas", reader(http.HandlerFunc(receitaHandler.ReadReceitas))).Methods("GET")
+	api.Handle("/receitas/{id}", reader(http.HandlerFunc(receitaHandler.ReadReceitasById))).Methods("GET")
+	api.Handle("/receitas", editor(http.HandlerFunc(receitaHandler.CreateReceitas))).Methods("POST")
+	// Editores so alteram as proprias receitas; a checagem de autor fica no handler e admins passam direto
+	api.Handle("/receitas/{id}", editor(http.HandlerFunc(receitaHandler.DeleteReceitas))).Methods("DELETE")
+	api.Handle("/receitas/{id}", editor(http.HandlerFunc(receitaHandler.UpdateReceitas))).Methods("PUT")
+	api.Handle("/receitas/{id}/capa", editor(http.HandlerFunc(receitaHandler.UploadCapa))).Methods("POST")
+	api.Handle("/receitas/{id}/capa", editor(http.HandlerFunc(receitaHandler.DeleteCapa))).Methods("DELETE")
+	api.Handle("/receitas/{id}/passos/{passo}/imagem", editor(http.HandlerFunc(receitaHandler.UploadImagemPasso))).Methods("POST")
+	api.Handle("/receitas/{id}/passos/{passo}/imagem", editor(http.HandlerFunc(receitaHandler.DeleteImagemPasso))).Methods("DELETE")
+
+	// Tags, categorias e cozinhas: leitores listam, editores criam e admins renomeiam ou removem
+	classificacoes := map[string]models.TipoClassificacao{"/tags": models.TipoTag, "/categorias": models.TipoCategoria, "/cozinhas": models.TipoCozinha}
+	for caminho, tipo := range classificacoes {
+		classificacaoHandler := handlers.NewClassificacaoHandler(db, tipo, logger)
+		api.Handle(caminho, reader(http.HandlerFunc(classificacaoHandler.ReadClassificacoes))).Methods("GET")
+		api.Handle(caminho, editor(http.HandlerFunc(classificacaoHandler.CreateClassificacao))).Methods("POST")
+		api.Handle(caminho+"/{slug}", admin(http.HandlerFunc(classificacaoHandler.UpdateClassificacao))).Methods("PUT")
+		api.Handle(caminho+"/{slug}", admin(http.HandlerFunc(classificacaoHandler.DeleteClassificacao))).Methods("DELETE")
+	}
+
+	api.Handle("/users", admin(http.HandlerFunc(userHandler.ReadUsers))).Methods("GET")
+	api.Handle("/users/{id}/role", admin(http.HandlerFunc(userHandler.UpdateUserRole))).Methods("PUT")
 
 	// Configurações de CORS
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173"},
+		AllowedOrigins:   cfg.Servidor.OrigensCORS,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", middleware.RequestIDHeader},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", middleware.RequestIDHeader},
 		AllowCredentials: true,
 	})
 
-	handlerWithCORS := c.Handler(router)
-port := os.Getenv("PORT")
-if port == "" {
-    port = "5555" // valor de fallback local
-}
+	// O request ID e o span vem primeiro para que o log de acesso e as respostas do CORS tambem os tenham
+	handlerWithCORS := middleware.RequestID(middleware.Tracing(middleware.AccessLog(logger)(middleware.Metricas(c.Handler(router)))))
+	server := &http.Server{
+		Addr:              ":" + cfg.Servidor.Porta,
+		Handler:           handlerWithCORS,
+		ReadHeaderTimeout: cfg.Servidor.TimeoutCabecalho,
+		ReadTimeout:       cfg.Servidor.TimeoutLeitura,
+		WriteTimeout:      cfg.Servidor.TimeoutEscrita,
+		IdleTimeout:       cfg.Servidor.TimeoutOcioso,
+	}
+	sinais, pararSinais := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer pararSinais()
 
-log.Printf("Servidor rodando em http://0.0.0.0:%s", port)
-log.Fatal(http.ListenAndServe(":"+port, handlerWithCORS))
+	// /metrics fica em um servidor interno, fora da porta publica
+	ouvinteMetricas, err := net.Listen("tcp", cfg.Servidor.EnderecoMetricas)
+	if err != nil {
+		log.Fatalf("Erro ao abrir o endereço das métricas: %v", err)
+	}
+	segundoPlano.iniciar(func(ctx context.Context) { servirMetricas(ctx, ouvinteMetricas) })
+
+	slog.Info("Servidor rodando", "endereco", "http://0.0.0.0:"+cfg.Servidor.Porta)
+	err = servir(sinais, server, cfg.Servidor.AtrasoDesligamento, cfg.Servidor.PrazoDesligamento, healthHandler.Desligar)
+
+	// Desligamento em ordem: o servidor ja nao atende; param os trabalhos em
+	// segundo plano, os spans pendentes sao enviados e, por ultimo, fecha o pool do banco
+	segundoPlano.parar()
+	ctxTracing, cancelar := context.WithTimeout(context.Background(), cfg.Servidor.PrazoDesligamento)
+	if errTracing := desligarTracing(ctxTracing); errTracing != nil {
+		slog.Error("Erro ao enviar os traces pendentes", "erro", errTracing)
+	}
+	cancelar()
+	if errClose := db.Close(); errClose != nil {
+		slog.Error("Erro ao fechar o banco", "erro", errClose)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.Info("Servidor encerrado")
 
 }
@@ -0,0 +1,98 @@
+// Package metrics reune as metricas Prometheus da API, servidas em /metrics no
+// endereco interno de METRICS_ADDR, fora da porta publica: requisicoes HTTP
+// por rota, o pool de conexoes do banco, logins e tokens recusados. Usa um
+// registro proprio em vez do global do client_golang.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prefixo de todas as metricas da API
+const namespace = "receitas"
+
+// Rota usada quando a requisicao nao casa com nenhuma rota do router, para
+// caminhos arbitrarios nao virarem series novas
+const RotaDesconhecida = "desconhecida"
+
+// Resultados de LoginTotal
+const (
+	LoginSucesso = "sucesso"
+	LoginFalha   = "falha"
+	LoginErro    = "erro"
+)
+
+// Motivos de TokensRecusados
+const (
+	TokenAusente        = "ausente"
+	TokenInvalido       = "invalido"
+	TokenSemUsuario     = "sem_usuario"
+	TokenSemSessao      = "sem_sessao"
+	TokenSessaoRevogada = "sessao_revogada"
+)
+
+var registro = prometheus.NewRegistry()
+
+var fabrica = promauto.With(registro)
+
+var (
+	// Requisicoes atendidas por metodo, template da rota (ex.: /api/receitas/{id}) e status
+	RequisicoesHTTP = fabrica.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Requisições HTTP atendidas, por método, rota e status.",
+	}, []string{"method", "route", "status"})
+
+	// Duracao das requisicoes por metodo e template da rota
+	DuracaoHTTP = fabrica.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duração das requisições HTTP em segundos, por método e rota.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// Tentativas de login por resultado: sucesso, falha (credenciais) ou erro interno
+	LoginTotal = fabrica.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_logins_total",
+		Help:      "Tentativas de login, por resultado (sucesso, falha ou erro).",
+	}, []string{"resultado"})
+
+	// Access tokens recusados pelo JWTMiddleware, por motivo
+	TokensRecusados = fabrica.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_tokens_rejected_total",
+		Help:      "Access tokens recusados, por motivo.",
+	}, []string{"motivo"})
+)
+
+func init() {
+	registro.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	// Series zeradas desde o inicio, para rate() e alertas nao dependerem do primeiro evento
+	for _, resultado := range []string{LoginSucesso, LoginFalha, LoginErro} {
+		LoginTotal.WithLabelValues(resultado)
+	}
+	for _, motivo := range []string{TokenAusente, TokenInvalido, TokenSemUsuario, TokenSemSessao, TokenSessaoRevogada} {
+		TokensRecusados.WithLabelValues(motivo)
+	}
+}
+
+// Publica as estatisticas do pool (conexoes abertas, em uso, esperas...) da
+// conexao aberta por config.SetupDB, com o driver no rotulo db_name
+func RegistrarBanco(db *sql.DB, driver string) error {
+	return registro.Register(collectors.NewDBStatsCollector(db, driver))
+}
+
+// Handler do endpoint /metrics do servidor interno
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registro, promhttp.HandlerOpts{Registry: registro})
+}
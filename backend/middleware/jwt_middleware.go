@@ -2,12 +2,15 @@ package middleware
 
 import (
 	"context"
-	"log" // Adicione esta linha
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/auth"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/metrics"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Tipo de chave personalizado
@@ -15,38 +18,85 @@ type contextKey string
 
 const userKey contextKey = "user"
 
-func JWTMiddleware(next http.Handler) http.Handler {
+// Consulta se a sessao de um token ainda vale (implementado por auth.SessionStore)
+type SessionChecker interface {
+	Ativa(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+// Valida o access token com as chaves do KeyManager e confere no banco
+// se a sessao dele nao foi revogada. O token nunca vai para o log.
+func JWTMiddleware(keys *auth.KeyManager, sessions SessionChecker, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return jwtHandler(keys, sessions, logger, next)
+	}
+}
+
+func jwtHandler(keys *auth.KeyManager, sessions SessionChecker, logger *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Println("JWTMiddleware: Recebendo requisição") // Log 1
+		ctx := r.Context()
 		authHeader := r.Header.Get("Authorization")
-		log.Printf("JWTMiddleware: Authorization Header: %s\n", authHeader) // Log 2
 
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			log.Println("JWTMiddleware: Token ausente ou formato inválido") // Log 3
-			http.Error(w, "Token ausente ou inválido", http.StatusUnauthorized)
+			metrics.TokensRecusados.WithLabelValues(metrics.TokenAusente).Inc()
+			logger.InfoContext(ctx, "JWTMiddleware: Token ausente ou formato inválido")
+			problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token ausente ou inválido")
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		log.Printf("JWTMiddleware: Token String: %s\n", tokenString) // Log 4
-
-		claims := &jwt.MapClaims{}
 
-		jwtSecret := os.Getenv("JWT_SECRET")
-		log.Printf("JWTMiddleware: JWT_SECRET do .env: %s (length: %d)\n", jwtSecret, len(jwtSecret)) // Log 5
+		claims := &auth.Claims{}
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil // Use a variável local jwtSecret
-		})
+		token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc, jwt.WithValidMethods(keys.Algoritmos()))
 
 		if err != nil || !token.Valid {
-			log.Printf("JWTMiddleware: Erro de validação do token: %v, Token válido: %t\n", err, token.Valid) // Log 6
-			http.Error(w, "Token inválido ou expirado", http.StatusUnauthorized)
+			metrics.TokensRecusados.WithLabelValues(metrics.TokenInvalido).Inc()
+			logger.InfoContext(ctx, "JWTMiddleware: Erro de validação do token", "erro", err)
+			problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token inválido ou expirado")
+			return
+		}
+
+		// Tokens emitidos antes das contas persistentes nao tem o ID do usuario
+		if _, err := claims.UserID(); err != nil {
+			metrics.TokensRecusados.WithLabelValues(metrics.TokenSemUsuario).Inc()
+			logger.InfoContext(ctx, "JWTMiddleware: Token sem ID de usuário válido", "erro", err)
+			problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token inválido ou expirado")
+			return
+		}
+
+		sessionID, err := claims.SessionID()
+		if err != nil {
+			metrics.TokensRecusados.WithLabelValues(metrics.TokenSemSessao).Inc()
+			logger.InfoContext(ctx, "JWTMiddleware: Token sem sessão", "erro", err)
+			problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token inválido ou expirado")
+			return
+		}
+
+		ativa, err := sessions.Ativa(ctx, sessionID)
+		if err != nil {
+			logger.ErrorContext(ctx, "JWTMiddleware: Erro ao verificar sessão", "sessao_id", sessionID, "erro", err)
+			problema.Interno(w, r)
+			return
+		}
+		if !ativa {
+			metrics.TokensRecusados.WithLabelValues(metrics.TokenSessaoRevogada).Inc()
+			logger.InfoContext(ctx, "JWTMiddleware: Sessão revogada ou expirada", "sessao_id", sessionID)
+			problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token inválido ou expirado")
 			return
 		}
 
-		log.Println("JWTMiddleware: Token validado com sucesso!") // Log 7
-		ctx := context.WithValue(r.Context(), userKey, (*claims)["username"])
-		next.ServeHTTP(w, r.WithContext(ctx))
+		logger.DebugContext(ctx, "JWTMiddleware: Token validado", "usuario", claims.Username, "sessao_id", sessionID)
+		next.ServeHTTP(w, r.WithContext(ContextWithClaims(ctx, claims)))
 	})
 }
+
+// Contexto com as claims do usuario autenticado, lidas por ClaimsFromContext
+func ContextWithClaims(ctx context.Context, claims *auth.Claims) context.Context {
+	return context.WithValue(ctx, userKey, claims)
+}
+
+// Retorna as claims do usuario autenticado salvas pelo JWTMiddleware
+func ClaimsFromContext(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(userKey).(*auth.Claims)
+	return claims, ok
+}
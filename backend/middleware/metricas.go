@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/metrics"
+)
+
+// Conta as requisicoes e mede a duracao delas por rota. A rota e o template
+// do gorilla/mux (/api/receitas/{id}), nunca o caminho com os IDs; ela e
+// registrada por Rota, que precisa estar em router.Use.
+func Metricas(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inicio := time.Now()
+		r, rota := comRota(r)
+		resposta := &respostaRegistrada{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(resposta, r)
+
+		metrics.RequisicoesHTTP.WithLabelValues(r.Method, rota.template, strconv.Itoa(resposta.status)).Inc()
+		metrics.DuracaoHTTP.WithLabelValues(r.Method, rota.template).Observe(time.Since(inicio).Seconds())
+	})
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/logging"
+	"github.com/google/uuid"
+)
+
+// Cabecalho com o identificador da requisicao, aceito na entrada e devolvido na resposta
+const RequestIDHeader = "X-Request-ID"
+
+// Tamanho maximo de um X-Request-ID recebido; acima disso um novo e gerado
+const tamanhoMaximoRequestID = 128
+
+// Usa o X-Request-ID recebido (de um proxy ou do cliente) ou gera um, devolve
+// na resposta e o guarda no contexto, de onde o logger o coloca em cada linha
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDValido(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.ComRequestID(r.Context(), id)))
+	})
+}
+
+// Aceita apenas ASCII visivel, para o valor nao quebrar o log nem os cabecalhos
+func requestIDValido(id string) bool {
+	if id == "" || len(id) > tamanhoMaximoRequestID {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Registra uma linha por requisicao, ao final, com o status e a duracao.
+// Use dentro de RequestID para a linha sair com o request_id.
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inicio := time.Now()
+			resposta := &respostaRegistrada{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(resposta, r)
+
+			nivel := slog.LevelInfo
+			if resposta.status >= http.StatusInternalServerError {
+				nivel = slog.LevelError
+			}
+			logger.LogAttrs(r.Context(), nivel, "Requisição",
+				slog.String("metodo", r.Method),
+				slog.String("caminho", r.URL.Path),
+				slog.Int("status", resposta.status),
+				slog.Int("bytes", resposta.bytes),
+				slog.Duration("duracao", time.Since(inicio)),
+				slog.String("remoto", r.RemoteAddr),
+			)
+		})
+	}
+}
+
+// ResponseWriter que guarda o status e o tamanho da resposta
+type respostaRegistrada struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	cabecalhoOK bool
+}
+
+func (resposta *respostaRegistrada) WriteHeader(status int) {
+	if !resposta.cabecalhoOK {
+		resposta.status = status
+		resposta.cabecalhoOK = true
+	}
+	resposta.ResponseWriter.WriteHeader(status)
+}
+
+func (resposta *respostaRegistrada) Write(dados []byte) (int, error) {
+	resposta.cabecalhoOK = true
+	n, err := resposta.ResponseWriter.Write(dados)
+	resposta.bytes += n
+	return n, err
+}
+
+// Permite a http.ResponseController chegar ao ResponseWriter original
+func (resposta *respostaRegistrada) Unwrap() http.ResponseWriter {
+	return resposta.ResponseWriter
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/problema"
+)
+
+// Exige que o usuario autenticado tenha pelo menos o papel informado.
+// Deve ser usado depois do JWTMiddleware, que coloca as claims no contexto.
+//
+//	api.Handle("/receitas", middleware.RequireRole(models.RoleEditor)(handler))
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				problema.Escrever(w, r, problema.CodigoNaoAutenticado, "Token ausente ou inválido")
+				return
+			}
+
+			if !models.RoleAtLeast(claims.Role, role) {
+				slog.InfoContext(r.Context(), "RequireRole: Papel sem acesso", "usuario", claims.Username, "papel", claims.Role, "exige", role)
+				problema.Escrever(w, r, problema.CodigoSemPermissao, "Permissão insuficiente")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
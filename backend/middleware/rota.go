@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/metrics"
+	"github.com/gorilla/mux"
+)
+
+type chaveRota struct{}
+
+// Template da rota (/api/receitas/{id}) que atendeu a requisicao. Os
+// middlewares de fora do router (Tracing, Metricas) criam o valor no contexto
+// e o leem depois que a requisicao volta; Rota o preenche dentro do router,
+// que assim faz o roteamento uma unica vez.
+type rotaResolvida struct {
+	template string
+}
+
+// Reaproveita o valor criado por um middleware anterior ou cria um novo
+func comRota(r *http.Request) (*http.Request, *rotaResolvida) {
+	if rota, ok := r.Context().Value(chaveRota{}).(*rotaResolvida); ok {
+		return r, rota
+	}
+	rota := &rotaResolvida{template: metrics.RotaDesconhecida}
+	return r.WithContext(context.WithValue(r.Context(), chaveRota{}, rota)), rota
+}
+
+// Middleware do router (router.Use), executado so quando alguma rota casou:
+// guarda o template da rota para Tracing e Metricas
+func Rota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rota, ok := r.Context().Value(chaveRota{}).(*rotaResolvida); ok {
+			if atual := mux.CurrentRoute(r); atual != nil {
+				if template, err := atual.GetPathTemplate(); err == nil {
+					rota.template = template
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
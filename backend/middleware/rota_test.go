@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/metrics"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRotaResolvidaUmaVez(t *testing.T) {
+	matches := 0
+	router := mux.NewRouter()
+	router.Use(Rota)
+	router.HandleFunc("/api/receitas/{id}", func(w http.ResponseWriter, r *http.Request) {}).
+		MatcherFunc(func(*http.Request, *mux.RouteMatch) bool {
+			matches++
+			return true
+		})
+	handler := Tracing(Metricas(router))
+
+	casos := []struct {
+		caminho string
+		rota    string
+		status  string
+	}{
+		{"/api/receitas/42", "/api/receitas/{id}", "200"},
+		{"/api/inexistente", metrics.RotaDesconhecida, "404"},
+	}
+	for _, caso := range casos {
+		contador := metrics.RequisicoesHTTP.WithLabelValues(http.MethodGet, caso.rota, caso.status)
+		antes := testutil.ToFloat64(contador)
+		matches = 0
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, caso.caminho, nil))
+
+		if depois := testutil.ToFloat64(contador); depois != antes+1 {
+			t.Errorf("%s: contador da rota %q foi de %v para %v", caso.caminho, caso.rota, antes, depois)
+		}
+		if caso.rota != metrics.RotaDesconhecida && matches != 1 {
+			t.Errorf("%s: router casou a rota %d vezes, esperado 1", caso.caminho, matches)
+		}
+	}
+}
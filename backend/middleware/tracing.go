@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Caminhos chamados a todo momento pelas sondas de saude
+var naoRastreados = map[string]bool{"/healthz": true, "/readyz": true}
+
+// Abre um span por requisicao, continuando o trace do traceparent recebido.
+// Quando a requisicao volta do router, o span recebe o nome do template da rota
+// (GET /api/receitas/{id}), como nas metricas; por isso Rota precisa estar em
+// router.Use. As consultas SQL feitas com o contexto da requisicao viram spans
+// filhos deste. As sondas de saude nao sao rastreadas.
+func Tracing(next http.Handler) http.Handler {
+	comNome := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, rota := comRota(r)
+		next.ServeHTTP(w, r)
+
+		span := trace.SpanFromContext(r.Context())
+		if rota.template == metrics.RotaDesconhecida {
+			span.SetName(r.Method)
+			return
+		}
+		span.SetName(r.Method + " " + rota.template)
+		span.SetAttributes(semconv.HTTPRoute(rota.template))
+	})
+	return otelhttp.NewHandler(comNome, "http.server",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !naoRastreados[r.URL.Path]
+		}),
+	)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/migrations"
+)
+
+const usoMigrate = `uso: migrate up | down [n] | status
+  up      aplica as migrations pendentes
+  down    desfaz as n últimas migrations aplicadas (padrão 1)
+  status  lista as migrations e quando foram aplicadas`
+
+// Subcomando "migrate"; a saida vai para saida
+func executarMigrate(ctx context.Context, migrador *migrations.Migrador, args []string, saida io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usoMigrate)
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) > 1 {
+			return errors.New(usoMigrate)
+		}
+		n, err := migrador.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(saida, "%d migrations aplicadas\n", n)
+	case "down":
+		passos := 1
+		if len(args) > 2 {
+			return errors.New(usoMigrate)
+		}
+		if len(args) == 2 {
+			var err error
+			if passos, err = strconv.Atoi(args[1]); err != nil || passos < 1 {
+				return fmt.Errorf("número de migrations inválido: %q", args[1])
+			}
+		}
+		n, err := migrador.Down(ctx, passos)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(saida, "%d migrations desfeitas\n", n)
+	case "status":
+		estados, err := migrador.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tabela := tabwriter.NewWriter(saida, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tabela, "VERSÃO\tNOME\tAPLICADA EM")
+		for _, estado := range estados {
+			aplicada := "pendente"
+			if estado.AplicadaEm != nil {
+				aplicada = estado.AplicadaEm.Local().Format("2006-01-02 15:04:05")
+			}
+			if estado.Desconhecida {
+				aplicada += " (desconhecida por esta versão)"
+			}
+			fmt.Fprintf(tabela, "%04d\t%s\t%s\n", estado.Versao, estado.Nome, aplicada)
+		}
+		return tabela.Flush()
+	default:
+		return errors.New(usoMigrate)
+	}
+	return nil
+}
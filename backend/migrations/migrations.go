@@ -0,0 +1,351 @@
+// Package migrations versiona o esquema do banco. Cada migration tem um numero,
+// um "up" e um "down"; as em SQL ficam em sql/NNNN_nome.up.sql e
+// sql/NNNN_nome.down.sql, embutidas no binario, e as que precisam de codigo Go
+// (preenchimentos de dados) sao registradas em preenchimentos.go. O SQLite tem
+// as mesmas migrations, com os mesmos numeros, escritas no dialeto dele em
+// sqlite/.
+//
+// As versoes aplicadas ficam na tabela schema_migrations. Um advisory lock do
+// Postgres garante que so uma replica migra por vez; as outras esperam e
+// encontram o banco ja atualizado.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+)
+
+//go:embed sql/*.sql sqlite/*.sql
+var arquivosSQL embed.FS
+
+// Diretorio das migrations em SQL de cada driver
+var diretorios = map[string]string{
+	database.DriverPostgres: "sql",
+	database.DriverSQLite:   "sqlite",
+}
+
+// Numero arbitrario, fixo, que identifica o lock das migrations no Postgres
+const chaveLock int64 = 0x72656365697461
+
+const createSchemaMigrationsQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	versao BIGINT PRIMARY KEY,
+	nome TEXT NOT NULL,
+	aplicada_em TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+const createSchemaMigrationsSQLiteQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	versao INTEGER PRIMARY KEY,
+	nome TEXT NOT NULL,
+	aplicada_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+)`
+
+// Passo de uma migration, executado dentro da transacao dela
+type Funcao func(ctx context.Context, tx *sql.Tx) error
+
+type Migracao struct {
+	Versao int64
+	Nome   string
+	Up     Funcao
+	Down   Funcao // nil quando nao ha o que desfazer
+}
+
+// Situacao de uma migration no banco
+type Estado struct {
+	Versao     int64
+	Nome       string
+	AplicadaEm *time.Time // nil se pendente
+	// Aplicada no banco mas ausente deste binario, normalmente por uma versao mais nova
+	Desconhecida bool
+}
+
+var reArquivo = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Todas as migrations do driver (database.DriverPostgres ou DriverSQLite), em ordem de versao
+func Carregar(driver string) ([]Migracao, error) {
+	diretorio, ok := diretorios[driver]
+	if !ok {
+		return nil, fmt.Errorf("migrations: driver desconhecido %q", driver)
+	}
+
+	porVersao := map[int64]*Migracao{}
+	obter := func(versao int64, nome string) (*Migracao, error) {
+		migracao, ok := porVersao[versao]
+		if !ok {
+			migracao = &Migracao{Versao: versao, Nome: nome}
+			porVersao[versao] = migracao
+		} else if migracao.Nome != nome {
+			return nil, fmt.Errorf("migration %d com dois nomes: %s e %s", versao, migracao.Nome, nome)
+		}
+		return migracao, nil
+	}
+
+	entradas, err := fs.ReadDir(arquivosSQL, diretorio)
+	if err != nil {
+		return nil, err
+	}
+	for _, entrada := range entradas {
+		m := reArquivo.FindStringSubmatch(entrada.Name())
+		if m == nil {
+			return nil, fmt.Errorf("arquivo de migration com nome inválido: %s", entrada.Name())
+		}
+		versao, _ := strconv.ParseInt(m[1], 10, 64)
+		conteudo, err := arquivosSQL.ReadFile(diretorio + "/" + entrada.Name())
+		if err != nil {
+			return nil, err
+		}
+		migracao, err := obter(versao, m[2])
+		if err != nil {
+			return nil, err
+		}
+		if m[3] == "up" {
+			migracao.Up = executarSQL(string(conteudo))
+		} else {
+			migracao.Down = executarSQL(string(conteudo))
+		}
+	}
+
+	for _, preenchimento := range preenchimentos(driver) {
+		if _, ok := porVersao[preenchimento.Versao]; ok {
+			return nil, fmt.Errorf("migration %d definida em SQL e em Go", preenchimento.Versao)
+		}
+		migracao := preenchimento
+		porVersao[migracao.Versao] = &migracao
+	}
+
+	migracoes := make([]Migracao, 0, len(porVersao))
+	for _, migracao := range porVersao {
+		if migracao.Up == nil {
+			return nil, fmt.Errorf("migration %d (%s) sem up", migracao.Versao, migracao.Nome)
+		}
+		migracoes = append(migracoes, *migracao)
+	}
+	sort.Slice(migracoes, func(i, j int) bool { return migracoes[i].Versao < migracoes[j].Versao })
+	return migracoes, nil
+}
+
+// Sem parametros o lib/pq usa o protocolo simples, que aceita varios comandos no
+// mesmo texto; o driver do SQLite executa os comandos um a um
+func executarSQL(query string) Funcao {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query)
+		return err
+	}
+}
+
+// Aplica e desfaz as migrations em um banco
+type Migrador struct {
+	DBConnection *sql.DB
+	Driver       string
+	Migracoes    []Migracao
+}
+
+// Construtor de Migrador com as migrations embutidas do driver da conexao
+func NewMigrador(dbConnection *sql.DB) (*Migrador, error) {
+	driver := database.DriverPostgres
+	if database.SQLite(dbConnection) {
+		driver = database.DriverSQLite
+	}
+	migracoes, err := Carregar(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrador{DBConnection: dbConnection, Driver: driver, Migracoes: migracoes}, nil
+}
+
+// Executa fn com o lock das migrations, em uma conexao exclusiva: advisory locks
+// de sessao pertencem a conexao que os pegou.
+func (migrador *Migrador) comLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := migrador.DBConnection.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// O arquivo SQLite pertence a um unico processo; cada migration ja roda em
+	// uma transacao com o banco travado para escrita
+	if migrador.Driver == database.DriverSQLite {
+		if _, err := conn.ExecContext(ctx, createSchemaMigrationsSQLiteQuery); err != nil {
+			return err
+		}
+		return fn(conn)
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, chaveLock); err != nil {
+		return fmt.Errorf("erro ao obter o lock das migrations: %w", err)
+	}
+	// Desbloqueia mesmo com o ctx cancelado; se a conexao cair, o Postgres solta o lock sozinho
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, chaveLock)
+
+	if _, err := conn.ExecContext(ctx, createSchemaMigrationsQuery); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func aplicadas(ctx context.Context, conn *sql.Conn) (map[int64]Estado, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT versao, nome, aplicada_em FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	estados := map[int64]Estado{}
+	for rows.Next() {
+		var estado Estado
+		var aplicadaEm time.Time
+		if err := rows.Scan(&estado.Versao, &estado.Nome, &aplicadaEm); err != nil {
+			return nil, err
+		}
+		estado.AplicadaEm = &aplicadaEm
+		estados[estado.Versao] = estado
+	}
+	return estados, rows.Err()
+}
+
+// Executa uma migration e registra (ou apaga) a versao na mesma transacao
+func executar(ctx context.Context, conn *sql.Conn, migracao Migracao, subir bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if subir {
+		err = migracao.Up(ctx, tx)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (versao, nome) VALUES ($1, $2)`, migracao.Versao, migracao.Nome)
+		}
+	} else {
+		if migracao.Down != nil {
+			err = migracao.Down(ctx, tx)
+		}
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE versao = $1`, migracao.Versao)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s: %w", migracao.Versao, migracao.Nome, err)
+	}
+	return tx.Commit()
+}
+
+// Aplica as migrations pendentes, em ordem. Retorna quantas foram aplicadas.
+func (migrador *Migrador) Up(ctx context.Context) (int, error) {
+	total := 0
+	err := migrador.comLock(ctx, func(conn *sql.Conn) error {
+		estados, err := aplicadas(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migracao := range migrador.Migracoes {
+			if _, ok := estados[migracao.Versao]; ok {
+				continue
+			}
+			if err := executar(ctx, conn, migracao, true); err != nil {
+				return err
+			}
+			total++
+		}
+		return nil
+	})
+	return total, err
+}
+
+// Desfaz as n ultimas migrations aplicadas. Retorna quantas foram desfeitas.
+func (migrador *Migrador) Down(ctx context.Context, n int) (int, error) {
+	total := 0
+	err := migrador.comLock(ctx, func(conn *sql.Conn) error {
+		estados, err := aplicadas(ctx, conn)
+		if err != nil {
+			return err
+		}
+		// Desfazer uma anterior deixaria um buraco sob a migration que este binario nao conhece
+		conhecidas := map[int64]bool{}
+		for _, migracao := range migrador.Migracoes {
+			conhecidas[migracao.Versao] = true
+		}
+		for versao, estado := range estados {
+			if !conhecidas[versao] {
+				return fmt.Errorf("a migration %04d_%s foi aplicada por uma versão mais nova da aplicação; desfaça com ela", versao, estado.Nome)
+			}
+		}
+
+		for i := len(migrador.Migracoes) - 1; i >= 0 && total < n; i-- {
+			migracao := migrador.Migracoes[i]
+			if _, ok := estados[migracao.Versao]; !ok {
+				continue
+			}
+			if err := executar(ctx, conn, migracao, false); err != nil {
+				return err
+			}
+			total++
+		}
+		return nil
+	})
+	return total, err
+}
+
+// Situacao de todas as migrations, em ordem de versao
+func (migrador *Migrador) Status(ctx context.Context) ([]Estado, error) {
+	var lista []Estado
+	err := migrador.comLock(ctx, func(conn *sql.Conn) error {
+		estados, err := aplicadas(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migracao := range migrador.Migracoes {
+			estado, ok := estados[migracao.Versao]
+			if !ok {
+				estado = Estado{Versao: migracao.Versao, Nome: migracao.Nome}
+			}
+			delete(estados, migracao.Versao)
+			lista = append(lista, estado)
+		}
+		for _, estado := range estados {
+			estado.Desconhecida = true
+			lista = append(lista, estado)
+		}
+		return nil
+	})
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Versao < lista[j].Versao })
+	return lista, err
+}
+
+// Migrations deste binario ainda nao aplicadas no banco. Nao pega o lock, para
+// poder ser chamada a cada verificacao de prontidao sem esperar um Up em andamento.
+func (migrador *Migrador) Pendentes(ctx context.Context) ([]Migracao, error) {
+	rows, err := migrador.DBConnection.QueryContext(ctx, `SELECT versao FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aplicada := map[int64]bool{}
+	for rows.Next() {
+		var versao int64
+		if err := rows.Scan(&versao); err != nil {
+			return nil, err
+		}
+		aplicada[versao] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pendentes []Migracao
+	for _, migracao := range migrador.Migracoes {
+		if !aplicada[migracao.Versao] {
+			pendentes = append(pendentes, migracao)
+		}
+	}
+	return pendentes, nil
+}
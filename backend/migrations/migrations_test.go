@@ -0,0 +1,135 @@
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+)
+
+func TestMigracoesSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.AbrirSQLite(filepath.Join(t.TempDir(), "receitas.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrador, err := NewMigrador(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todas := migrador.Migracoes
+	ultima := todas[len(todas)-1].Versao
+
+	// Uma receita gravada antes das migrations de preenchimento
+	antigas := &Migrador{DBConnection: db, Driver: database.DriverSQLite}
+	for _, migracao := range todas {
+		if migracao.Versao < 10 {
+			antigas.Migracoes = append(antigas.Migracoes, migracao)
+		}
+	}
+	if _, err := antigas.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO receitas (nome, descricao, ingredientes, instrucoes) VALUES ($1, $2, $3, $4)`,
+		"Bolo", "Simples", `["2 xícaras de farinha de trigo","3 Ovos","0 colheres de fermento","sal a gosto"]`,
+		"1. Misture tudo\n2. Asse a 180 °C por 40 minutos")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aplicadas, err := migrador.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aplicadas != len(todas)-len(antigas.Migracoes) {
+		t.Fatalf("%d migrations aplicadas, esperado %d", aplicadas, len(todas)-len(antigas.Migracoes))
+	}
+	conferirVersao(t, migrador, ultima)
+
+	var normalizados, passos string
+	if err := db.QueryRowContext(ctx, `SELECT ingredientes_normalizados, passos FROM receitas`).Scan(&normalizados, &passos); err != nil {
+		t.Fatal(err)
+	}
+	var nomes []string
+	if err := json.Unmarshal([]byte(normalizados), &nomes); err != nil {
+		t.Fatal(err)
+	}
+	if esperado := []string{"farinha trigo", "ovo", "0 colher fermento"}; !reflect.DeepEqual(nomes, esperado) {
+		t.Fatalf("ingredientes_normalizados = %q, esperado %q", nomes, esperado)
+	}
+	esperado := `[{"texto":"Misture tudo"},{"texto":"Asse a 180 °C por 40 minutos","duracao_segundos":2400,"temperatura":180,"unidade_temperatura":"°C"}]`
+	if passos != esperado {
+		t.Fatalf("passos = %s, esperado %s", passos, esperado)
+	}
+
+	// Todas desfazem e aplicam de novo
+	desfeitas, err := migrador.Down(ctx, len(todas))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desfeitas != len(todas) {
+		t.Fatalf("%d migrations desfeitas, esperado %d", desfeitas, len(todas))
+	}
+	if pendentes, err := migrador.Pendentes(ctx); err != nil || len(pendentes) != len(todas) {
+		t.Fatalf("%d pendentes depois do down, erro %v", len(pendentes), err)
+	}
+	if _, err := migrador.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	conferirVersao(t, migrador, ultima)
+}
+
+// O banco fica na ultima versao, sem pendentes nem migrations desconhecidas
+func conferirVersao(t *testing.T, migrador *Migrador, ultima int64) {
+	t.Helper()
+	ctx := context.Background()
+
+	var versao int64
+	if err := migrador.DBConnection.QueryRowContext(ctx, `SELECT max(versao) FROM schema_migrations`).Scan(&versao); err != nil {
+		t.Fatal(err)
+	}
+	if versao != ultima {
+		t.Fatalf("versão %d, esperado %d", versao, ultima)
+	}
+	if pendentes, err := migrador.Pendentes(ctx); err != nil || len(pendentes) != 0 {
+		t.Fatalf("pendentes = %v, erro %v", pendentes, err)
+	}
+	estados, err := migrador.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, estado := range estados {
+		if estado.AplicadaEm == nil || estado.Desconhecida {
+			t.Fatalf("migration %d: %+v", estado.Versao, estado)
+		}
+	}
+}
+
+func TestCarregar(t *testing.T) {
+	postgres, err := Carregar(database.DriverPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := Carregar(database.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Os dois drivers tem as mesmas migrations, com os mesmos numeros
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("%d migrations no Postgres e %d no SQLite", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Versao != sqlite[i].Versao || postgres[i].Nome != sqlite[i].Nome {
+			t.Fatalf("migration %d: %d_%s no Postgres e %d_%s no SQLite", i,
+				postgres[i].Versao, postgres[i].Nome, sqlite[i].Versao, sqlite[i].Nome)
+		}
+	}
+	if _, err := Carregar("mysql"); err == nil {
+		t.Fatal("driver desconhecido aceito")
+	}
+}
@@ -0,0 +1,317 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Codigo da migration 0010, copiado de models e units como estava quando ela
+// foi escrita. Nao atualize junto com a aplicacao.
+
+// Nomes, plurais e aliases de unidades reconhecidos pelo parser, ja normalizados
+var unidadesCongeladas = map[string]bool{}
+
+func init() {
+	for _, alias := range []string{
+		"c. cha", "c. de cha", "c. de sopa", "c. sopa", "caixa", "caixas", "caixinha", "caixinhas", "cch", "celsius",
+		"colher (cha)", "colher (sobremesa)", "colher (sopa)", "colher de cha", "colher de sobremesa", "colher de sopa", "colher",
+		"colheres (cha)", "colheres (sobremesa)", "colheres (sopa)", "colheres de cha", "colheres de sobremesa", "colheres de sopa", "colheres",
+		"copo (americano)", "copo americano", "copo", "copos (americano)", "copos americanos", "copos", "csp", "cup", "cups",
+		"dente", "dentes", "envelope", "envelopes", "fahrenheit", "fatia", "fatias", "fl oz", "fluid ounce", "fluid ounces",
+		"folha", "folhas", "g", "gallon", "gallons", "gr", "grama", "gramas", "graus celsius", "graus fahrenheit",
+		"kg", "kilo", "kilos", "l", "lata", "latas", "lb", "lbs", "libra", "libras", "litro", "litros", "lt", "maco", "macos",
+		"mg", "miligrama", "miligramas", "mililitro", "mililitros", "ml", "onca", "oncas", "ounce", "ounces", "oz",
+		"pacote", "pacotes", "pint", "pints", "pitada", "pitadas", "pound", "pounds", "quart", "quarts",
+		"quilo", "quilograma", "quilogramas", "quilos", "ramo", "ramos", "tablespoon", "tablespoons", "tablete", "tabletes",
+		"tbsp", "teaspoon", "teaspoons", "tsp", "un", "un.", "unidade", "unidades", "xic", "xic.",
+		"xicara (cha)", "xicara de cha", "xicara", "xicaras (cha)", "xicaras de cha", "xicaras", "°c", "°f", "ºc", "ºf",
+	} {
+		unidadesCongeladas[alias] = true
+	}
+}
+
+var (
+	fracoesUnicodeCongeladas = map[string]float64{
+		"½": 1.0 / 2, "⅓": 1.0 / 3, "⅔": 2.0 / 3, "¼": 1.0 / 4, "¾": 3.0 / 4, "⅛": 1.0 / 8,
+	}
+	numerosPorExtensoCongelados = map[string]float64{
+		"um": 1, "uma": 1, "dois": 2, "duas": 2, "três": 3, "tres": 3, "quatro": 4, "cinco": 5,
+		"meio": 0.5, "meia": 0.5,
+	}
+	palavrasIgnoradasCongeladas = map[string]bool{
+		"de": true, "do": true, "da": true, "dos": true, "das": true, "e": true, "com": true, "em": true,
+	}
+	observacoesOpcionaisCongeladas = []string{"a gosto", "q.b", "qb", "quanto baste", "opcional", "para decorar", "para servir"}
+
+	reQuantidadeCongelada = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d+[½⅓⅔¼¾⅛]|[½⅓⅔¼¾⅛]|\d+(?:[.,]\d+)?)`)
+	reFaixaCongelada      = regexp.MustCompile(`^\s*(?:[-–]|a\s|ou\s)\s*`)
+	reMarcadorCongelado   = regexp.MustCompile(`^[-*•·]\s*`)
+	reDeCongelado         = regexp.MustCompile(`^(?:de|do|da|dos|das)\s+`)
+	reAGostoCongelado     = regexp.MustCompile(`(?i)\s+(a gosto|q\.?\s?b\.?|quanto baste)$`)
+
+	removedorAcentosCongelado = strings.NewReplacer(
+		"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+		"é", "e", "ê", "e", "è", "e", "ë", "e",
+		"í", "i", "î", "i", "ì", "i", "ï", "i",
+		"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+		"ú", "u", "û", "u", "ù", "u", "ü", "u",
+		"ç", "c",
+	)
+)
+
+// Partes de um ingrediente que a normalizacao usa
+type ingredienteCongelado struct {
+	Quantidade    *float64 `json:"quantidade"`
+	QuantidadeMax *float64 `json:"quantidade_max"`
+	Nome          string   `json:"nome"`
+	Observacao    string   `json:"observacao"`
+}
+
+// Calcula ingredientes_normalizados das receitas que ainda nao tem a coluna preenchida.
+// No SQLite (sqlite true) as listas de textos ficam em JSON em vez de arrays do Postgres.
+func preencherIngredientesNormalizados(ctx context.Context, tx *sql.Tx, sqlite bool) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, ingredientes, ingredientes_estruturados FROM receitas WHERE ingredientes_normalizados IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+
+	type pendente struct {
+		id           string
+		ingredientes []string
+		estruturados []ingredienteCongelado
+	}
+	var pendentes []pendente
+	for rows.Next() {
+		var receita pendente
+		var ingredientes, estruturados []byte
+		if err := rows.Scan(&receita.id, &ingredientes, &estruturados); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if sqlite {
+			err = json.Unmarshal(ingredientes, &receita.ingredientes)
+		} else {
+			err = pq.Array(&receita.ingredientes).Scan(ingredientes)
+		}
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if estruturados != nil {
+			if err := json.Unmarshal(estruturados, &receita.estruturados); err != nil {
+				rows.Close()
+				return 0, err
+			}
+		}
+		pendentes = append(pendentes, receita)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, receita := range pendentes {
+		nomes := normalizarIngredientes(receita.ingredientes, receita.estruturados)
+		var normalizados any = pq.Array(nomes)
+		if sqlite {
+			dados, err := json.Marshal(nomes)
+			if err != nil {
+				return 0, err
+			}
+			normalizados = string(dados)
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE receitas SET ingredientes_normalizados = $1 WHERE id = $2 AND ingredientes_normalizados IS NULL`,
+			normalizados, receita.id)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(pendentes), nil
+}
+
+// Nomes normalizados dos ingredientes obrigatorios. A forma estruturada vale
+// quando e valida; senao cada linha de texto passa pelo parser, e as linhas com
+// quantidade invalida viram so o nome.
+func normalizarIngredientes(linhas []string, estruturados []ingredienteCongelado) []string {
+	for _, ing := range estruturados {
+		if strings.TrimSpace(ing.Nome) == "" || !quantidadeCongeladaValida(ing) {
+			estruturados = nil
+			break
+		}
+	}
+	if len(estruturados) == 0 {
+		estruturados = nil
+		for _, linha := range linhas {
+			if strings.TrimSpace(linha) == "" {
+				continue
+			}
+			ing := lerIngredienteCongelado(linha)
+			if !quantidadeCongeladaValida(ing) {
+				ing = ingredienteCongelado{Nome: strings.TrimSpace(linha)}
+			}
+			estruturados = append(estruturados, ing)
+		}
+	}
+
+	nomes := []string{}
+	for _, ing := range estruturados {
+		if nome := nomeNormalizadoCongelado(ing.Nome); nome != "" && !opcionalCongelado(ing.Observacao) {
+			nomes = append(nomes, nome)
+		}
+	}
+	return nomes
+}
+
+func quantidadeCongeladaValida(ing ingredienteCongelado) bool {
+	positiva := func(q *float64) bool {
+		return q == nil || (*q > 0 && !math.IsInf(*q, 0))
+	}
+	if ing.QuantidadeMax != nil && ing.Quantidade == nil {
+		return false
+	}
+	return positiva(ing.Quantidade) && positiva(ing.QuantidadeMax)
+}
+
+func lerIngredienteCongelado(texto string) ingredienteCongelado {
+	texto = strings.TrimSpace(reMarcadorCongelado.ReplaceAllString(strings.TrimSpace(texto), ""))
+	var ing ingredienteCongelado
+
+	if valor, resto, ok := lerQuantidadeCongelada(texto); ok {
+		q := math.Round(valor*1e4) / 1e4
+		ing.Quantidade = &q
+		texto = strings.TrimSpace(resto)
+
+		if loc := reFaixaCongelada.FindStringIndex(texto); loc != nil {
+			if maximo, resto, ok := lerQuantidadeCongelada(texto[loc[1]:]); ok && maximo > valor {
+				qm := math.Round(maximo*1e4) / 1e4
+				ing.QuantidadeMax = &qm
+				texto = strings.TrimSpace(resto)
+			}
+		}
+
+		palavras := strings.Fields(texto)
+		for n := min(len(palavras), 4); n > 0; n-- {
+			// Uma unidade nao pode ser o texto inteiro ("2 folhas" sao as folhas)
+			if unidadesCongeladas[normalizarCongelado(strings.Join(palavras[:n], " "))] {
+				if n < len(palavras) {
+					texto = reDeCongelado.ReplaceAllString(strings.Join(palavras[n:], " "), "")
+				}
+				break
+			}
+		}
+	}
+
+	if m := reAGostoCongelado.FindStringSubmatchIndex(texto); m != nil {
+		ing.Observacao = strings.ToLower(texto[m[2]:m[3]])
+		texto = texto[:m[0]]
+	} else if abre := strings.LastIndex(texto, "("); abre > 0 && strings.HasSuffix(texto, ")") {
+		ing.Observacao = strings.TrimSpace(texto[abre+1 : len(texto)-1])
+		texto = texto[:abre]
+	} else if nome, obs, ok := strings.Cut(texto, ","); ok {
+		ing.Observacao = strings.TrimSpace(obs)
+		texto = nome
+	}
+
+	ing.Nome = strings.TrimSpace(texto)
+	return ing
+}
+
+func lerQuantidadeCongelada(texto string) (float64, string, bool) {
+	if m := reQuantidadeCongelada.FindString(texto); m != "" {
+		if valor, ok := lerNumeroCongelado(m); ok {
+			return valor, texto[len(m):], true
+		}
+	}
+	palavra, resto, _ := strings.Cut(texto, " ")
+	if valor, ok := numerosPorExtensoCongelados[strings.ToLower(palavra)]; ok && resto != "" {
+		return valor, " " + resto, true
+	}
+	return 0, texto, false
+}
+
+func lerNumeroCongelado(texto string) (float64, bool) {
+	texto = strings.TrimSpace(texto)
+	if partes := strings.Fields(texto); len(partes) == 2 {
+		inteiro, ok1 := lerNumeroCongelado(partes[0])
+		fracao, ok2 := lerNumeroCongelado(partes[1])
+		return inteiro + fracao, ok1 && ok2
+	}
+	if num, den, ok := strings.Cut(texto, "/"); ok {
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	for simbolo, valor := range fracoesUnicodeCongeladas {
+		if strings.HasSuffix(texto, simbolo) {
+			inteiro := strings.TrimSuffix(texto, simbolo)
+			if inteiro == "" {
+				return valor, true
+			}
+			n, err := strconv.ParseFloat(inteiro, 64)
+			return n + valor, err == nil
+		}
+	}
+	n, err := strconv.ParseFloat(strings.Replace(texto, ",", ".", 1), 64)
+	return n, err == nil
+}
+
+func normalizarCongelado(texto string) string {
+	return strings.Join(strings.Fields(removedorAcentosCongelado.Replace(strings.ToLower(texto))), " ")
+}
+
+func nomeNormalizadoCongelado(nome string) string {
+	var palavras []string
+	for _, p := range strings.Fields(normalizarCongelado(nome)) {
+		p = strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, singularCongelado(p))
+		if p != "" && !palavrasIgnoradasCongeladas[p] {
+			palavras = append(palavras, p)
+		}
+	}
+	return strings.Join(palavras, " ")
+}
+
+func singularCongelado(p string) string {
+	if len(p) <= 3 {
+		return p
+	}
+	switch {
+	case strings.HasSuffix(p, "oes"), strings.HasSuffix(p, "aes"):
+		return p[:len(p)-3] + "ao"
+	case strings.HasSuffix(p, "ais"):
+		return p[:len(p)-3] + "al"
+	case strings.HasSuffix(p, "eis"):
+		return p[:len(p)-3] + "el"
+	case strings.HasSuffix(p, "res"), strings.HasSuffix(p, "zes"):
+		return p[:len(p)-2]
+	case strings.HasSuffix(p, "ns"):
+		return p[:len(p)-2] + "m"
+	case strings.HasSuffix(p, "s") && !strings.HasSuffix(p, "ss") && !strings.HasSuffix(p, "is") && !strings.HasSuffix(p, "us"):
+		return p[:len(p)-1]
+	}
+	return p
+}
+
+func opcionalCongelado(observacao string) bool {
+	observacao = normalizarCongelado(observacao)
+	for _, opcional := range observacoesOpcionaisCongeladas {
+		if strings.Contains(observacao, opcional) {
+			return true
+		}
+	}
+	return false
+}
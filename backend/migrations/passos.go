@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Codigo da migration 0014, copiado de models e units como estava quando ela
+// foi escrita. Nao atualize junto com a aplicacao.
+
+// Passo como era gravado na coluna passos
+type passoCongelado struct {
+	Texto              string   `json:"texto"`
+	DuracaoSegundos    *int     `json:"duracao_segundos,omitempty"`
+	Temperatura        *float64 `json:"temperatura,omitempty"`
+	UnidadeTemperatura string   `json:"unidade_temperatura,omitempty"`
+}
+
+// Maior duracao aceita para um passo: 30 dias
+const duracaoMaximaCongelada = 30 * 24 * 60 * 60
+
+var (
+	reNumeracaoPassoCongelada = regexp.MustCompile(`(?i)^\s*(?:(?:passo\s+)?\d+\s*[.):\-–]|[-*•])\s+`)
+	reLinhaEmBrancoCongelada  = regexp.MustCompile(`\n\s*\n`)
+	reDuracaoCongelada        = regexp.MustCompile(`(?i)\b(\d+)(?:\s*(?:a|-|–)\s*(\d+))?\s*(segundos?|seg|minutos?|min|horas?|h)\b`)
+	reTemperaturaCongelada    = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:[°º]\s*([CF])\b|graus(?:\s+(celsius|fahrenheit))?)`)
+)
+
+// Divide em passos as instrucoes das receitas que ainda nao tem a coluna passos.
+// O texto original de "instrucoes" nao e alterado.
+func preencherPassos(ctx context.Context, tx *sql.Tx) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, instrucoes FROM receitas WHERE passos IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+
+	type pendente struct{ id, instrucoes string }
+	var pendentes []pendente
+	for rows.Next() {
+		var receita pendente
+		if err := rows.Scan(&receita.id, &receita.instrucoes); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pendentes = append(pendentes, receita)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, receita := range pendentes {
+		passos, err := json.Marshal(dividirInstrucoesCongelado(receita.instrucoes))
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE receitas SET passos = $1 WHERE id = $2 AND passos IS NULL`, string(passos), receita.id)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(pendentes), nil
+}
+
+// Cada linha numerada (ou com marcador) comeca um passo; sem numeracao, cada
+// paragrafo e um passo
+func dividirInstrucoesCongelado(texto string) []passoCongelado {
+	texto = strings.ReplaceAll(texto, "\r\n", "\n")
+
+	var blocos []string
+	linhas := strings.Split(texto, "\n")
+	numeradas := 0
+	for _, linha := range linhas {
+		if reNumeracaoPassoCongelada.MatchString(linha) {
+			numeradas++
+		}
+	}
+
+	if numeradas > 0 {
+		var atual []string
+		for _, linha := range linhas {
+			if reNumeracaoPassoCongelada.MatchString(linha) {
+				blocos = append(blocos, strings.Join(atual, " "))
+				atual = []string{reNumeracaoPassoCongelada.ReplaceAllString(linha, "")}
+				continue
+			}
+			atual = append(atual, linha)
+		}
+		blocos = append(blocos, strings.Join(atual, " "))
+	} else {
+		blocos = reLinhaEmBrancoCongelada.Split(texto, -1)
+	}
+
+	passos := []passoCongelado{}
+	for _, bloco := range blocos {
+		if bloco = strings.Join(strings.Fields(bloco), " "); bloco != "" {
+			passos = append(passos, novoPassoCongelado(bloco))
+		}
+	}
+	return passos
+}
+
+// Passo com o timer e a temperatura que o texto mencionar
+func novoPassoCongelado(texto string) passoCongelado {
+	passo := passoCongelado{Texto: texto}
+	if m := reDuracaoCongelada.FindStringSubmatch(texto); m != nil {
+		valor, _ := strconv.Atoi(m[1])
+		if maximo, err := strconv.Atoi(m[2]); err == nil && maximo > valor {
+			valor = maximo
+		}
+		unidade := strings.ToLower(m[3])
+		switch {
+		case strings.HasPrefix(unidade, "h"):
+			valor *= 3600
+		case strings.HasPrefix(unidade, "min"):
+			valor *= 60
+		}
+		if valor > 0 && valor <= duracaoMaximaCongelada {
+			passo.DuracaoSegundos = &valor
+		}
+	}
+	if m := reTemperaturaCongelada.FindStringSubmatch(texto); m != nil {
+		if valor, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64); err == nil {
+			passo.Temperatura = &valor
+			passo.UnidadeTemperatura = "°C"
+			if strings.EqualFold(m[2], "F") || strings.EqualFold(m[3], "fahrenheit") {
+				passo.UnidadeTemperatura = "°F"
+			}
+		}
+	}
+	return passo
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+)
+
+// Migrations de dados que dependem de codigo Go. Nao tem down: os dados
+// preenchidos somem junto com a coluna quando a migration anterior e desfeita.
+//
+// O codigo de cada uma fica congelado neste pacote (normalizados.go e
+// passos.go), com o comportamento da aplicacao na epoca em que a migration foi
+// escrita. Mudar o parser ou a divisao de passos em models nao pode mudar o
+// resultado de uma migration ja aplicada em outros bancos.
+func preenchimentos(driver string) []Migracao {
+	normalizados := func(ctx context.Context, tx *sql.Tx) (int, error) {
+		return preencherIngredientesNormalizados(ctx, tx, driver == database.DriverSQLite)
+	}
+	return []Migracao{
+		{Versao: 10, Nome: "preencher_ingredientes_normalizados", Up: preencher("ingredientes normalizados", normalizados)},
+		{Versao: 14, Nome: "preencher_passos", Up: preencher("instruções divididas em passos", preencherPassos)},
+	}
+}
+
+func preencher(descricao string, fn func(context.Context, *sql.Tx) (int, error)) Funcao {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		n, err := fn(ctx, tx)
+		if n > 0 {
+			slog.InfoContext(ctx, "Migrations: "+descricao, "receitas", n)
+		}
+		return err
+	}
+}
@@ -0,0 +1,9 @@
+-- Tabela original das receitas. As migrations ate 0015 usam IF NOT EXISTS
+-- porque os bancos criados antes delas ja tem parte do esquema.
+CREATE TABLE IF NOT EXISTS receitas (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	nome TEXT NOT NULL,
+	descricao TEXT NOT NULL,
+	ingredientes TEXT[] NOT NULL,
+	instrucoes TEXT NOT NULL
+);
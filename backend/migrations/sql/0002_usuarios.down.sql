@@ -0,0 +1,2 @@
+ALTER TABLE receitas DROP COLUMN IF EXISTS autor_id;
+DROP TABLE IF EXISTS users;
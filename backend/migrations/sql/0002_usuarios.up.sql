@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS users (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	username TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	criado_em TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Receitas criadas antes da coluna existir ficam sem autor e so podem ser alteradas por admins
+ALTER TABLE receitas ADD COLUMN IF NOT EXISTS autor_id UUID REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+-- Os papeis se perdem; quem voltar para antes desta migration volta ao modelo sem papeis
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
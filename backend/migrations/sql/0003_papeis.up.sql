@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'reader';
+
+-- Antes das migrations a aplicacao criava a coluna na inicializacao, com
+-- DEFAULT 'user'. Nesses bancos o ADD COLUMN acima nao faz nada e as contas
+-- continuam com "user", que podia criar receitas; elas viram editores. Precisa
+-- rodar antes da constraint, que recusa "user".
+UPDATE users SET role = 'editor' WHERE role = 'user';
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'reader';
+DO $$ BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'users_role_check') THEN
+		ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'editor', 'reader'));
+	END IF;
+END $$;
@@ -0,0 +1,20 @@
+-- Cada login abre uma sessao. Revogar a sessao invalida o refresh token
+-- e todos os access tokens emitidos para ela (claim "sid").
+CREATE TABLE IF NOT EXISTS sessions (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	criado_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+	expira_em TIMESTAMPTZ NOT NULL,
+	revogada_em TIMESTAMPTZ
+);
+
+-- Apenas o hash SHA-256 do refresh token e salvo. Um token usado
+-- uma segunda vez indica vazamento e derruba a sessao inteira.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	token_hash TEXT PRIMARY KEY,
+	session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+	criado_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+	expira_em TIMESTAMPTZ NOT NULL,
+	usado_em TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_id_idx ON refresh_tokens (session_id);
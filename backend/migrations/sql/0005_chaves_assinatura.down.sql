@@ -0,0 +1,2 @@
+-- Os tokens emitidos deixam de ser validados
+DROP TABLE IF EXISTS signing_keys;
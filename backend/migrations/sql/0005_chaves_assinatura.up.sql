@@ -0,0 +1,8 @@
+-- Chaves de assinatura dos tokens (PEM PKCS#8), compartilhadas entre as replicas
+CREATE TABLE IF NOT EXISTS signing_keys (
+	kid TEXT PRIMARY KEY,
+	alg TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	criado_em TIMESTAMPTZ NOT NULL DEFAULT now(),
+	expira_em TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE receitas DROP COLUMN IF EXISTS porcoes;
+ALTER TABLE receitas DROP COLUMN IF EXISTS ingredientes_estruturados;
@@ -0,0 +1,4 @@
+-- Fica NULL nas receitas antigas; nelas a forma estruturada e gerada na leitura a partir de "ingredientes"
+ALTER TABLE receitas ADD COLUMN IF NOT EXISTS ingredientes_estruturados JSONB;
+
+ALTER TABLE receitas ADD COLUMN IF NOT EXISTS porcoes INTEGER CHECK (porcoes > 0);
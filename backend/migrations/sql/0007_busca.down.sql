@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS receitas_busca_trigger ON receitas;
+DROP FUNCTION IF EXISTS receitas_busca_atualizar();
+ALTER TABLE receitas DROP COLUMN IF EXISTS busca;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS portugues_sem_acento;
+-- A extensao unaccent fica: outros esquemas do banco podem usa-la
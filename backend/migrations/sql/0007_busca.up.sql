@@ -0,0 +1,41 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Portugues sem diferenciar acentos. O nome e models.ConfiguracaoBusca.
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'portugues_sem_acento') THEN
+		CREATE TEXT SEARCH CONFIGURATION portugues_sem_acento (COPY = portuguese);
+		ALTER TEXT SEARCH CONFIGURATION portugues_sem_acento
+			ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+	END IF;
+END
+$$;
+
+ALTER TABLE receitas ADD COLUMN IF NOT EXISTS busca tsvector;
+
+-- O nome pesa mais que ingredientes e descricao, que pesam mais que as instrucoes
+CREATE OR REPLACE FUNCTION receitas_busca_atualizar() RETURNS trigger AS $$
+BEGIN
+	NEW.busca :=
+		setweight(to_tsvector('portugues_sem_acento', coalesce(NEW.nome, '')), 'A') ||
+		setweight(to_tsvector('portugues_sem_acento', array_to_string(NEW.ingredientes, ' ')), 'B') ||
+		setweight(to_tsvector('portugues_sem_acento', coalesce(NEW.descricao, '')), 'B') ||
+		setweight(to_tsvector('portugues_sem_acento', coalesce(NEW.instrucoes, '')), 'C');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'receitas_busca_trigger') THEN
+		CREATE TRIGGER receitas_busca_trigger
+			BEFORE INSERT OR UPDATE OF nome, descricao, ingredientes, instrucoes ON receitas
+			FOR EACH ROW EXECUTE FUNCTION receitas_busca_atualizar();
+	END IF;
+END
+$$;
+
+-- Preenche as receitas criadas antes da coluna; o UPDATE dispara o trigger
+UPDATE receitas SET nome = nome WHERE busca IS NULL;
+
+CREATE INDEX IF NOT EXISTS receitas_busca_idx ON receitas USING GIN (busca);
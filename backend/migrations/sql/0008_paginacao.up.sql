@@ -0,0 +1,6 @@
+-- Receitas antigas recebem a data em que a coluna foi criada
+ALTER TABLE receitas ADD COLUMN IF NOT EXISTS criado_em TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Indices da paginacao por cursor; o id desempata valores iguais
+CREATE INDEX IF NOT EXISTS receitas_nome_id_idx ON receitas (nome, id);
+CREATE INDEX IF NOT EXISTS receitas_criado_em_id_idx ON receitas (criado_em, id);
@@ -0,0 +1 @@
+ALTER TABLE receitas DROP COLUMN IF EXISTS ingredientes_normalizados;
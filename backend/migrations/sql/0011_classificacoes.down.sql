@@ -0,0 +1,6 @@
+ALTER TABLE receitas DROP COLUMN IF EXISTS cozinha_id;
+ALTER TABLE receitas DROP COLUMN IF EXISTS categoria_id;
+DROP TABLE IF EXISTS receita_tags;
+DROP TABLE IF EXISTS cozinhas;
+DROP TABLE IF EXISTS categorias;
+DROP TABLE IF EXISTS tags;
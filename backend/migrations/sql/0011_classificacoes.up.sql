@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS tags (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	nome TEXT NOT NULL,
+	slug TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS categorias (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	nome TEXT NOT NULL,
+	slug TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS cozinhas (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	nome TEXT NOT NULL,
+	slug TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS receita_tags (
+	receita_id UUID NOT NULL REFERENCES receitas(id) ON DELETE CASCADE,
+	tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	PRIMARY KEY (receita_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS receita_tags_tag_id_idx ON receita_tags (tag_id);
+
+-- Remover a categoria ou a cozinha deixa as receitas sem classificacao
+ALTER TABLE receitas ADD COLUMN IF NOT EXISTS categoria_id UUID REFERENCES categorias(id) ON DELETE SET NULL;
+ALTER TABLE receitas ADD COLUMN IF NOT EXISTS cozinha_id UUID REFERENCES cozinhas(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS receitas_categoria_id_idx ON receitas (categoria_id);
+CREATE INDEX IF NOT EXISTS receitas_cozinha_id_idx ON receitas (cozinha_id);
@@ -0,0 +1,6 @@
+ALTER TABLE receitas
+	DROP COLUMN IF EXISTS dificuldade,
+	DROP COLUMN IF EXISTS tempo_total,
+	DROP COLUMN IF EXISTS tempo_descanso,
+	DROP COLUMN IF EXISTS tempo_cozimento,
+	DROP COLUMN IF EXISTS tempo_preparo;
@@ -0,0 +1,10 @@
+-- Minutos
+ALTER TABLE receitas
+	ADD COLUMN IF NOT EXISTS tempo_preparo INTEGER CHECK (tempo_preparo >= 0),
+	ADD COLUMN IF NOT EXISTS tempo_cozimento INTEGER CHECK (tempo_cozimento >= 0),
+	ADD COLUMN IF NOT EXISTS tempo_descanso INTEGER CHECK (tempo_descanso >= 0),
+	ADD COLUMN IF NOT EXISTS tempo_total INTEGER CHECK (tempo_total >= 0);
+
+ALTER TABLE receitas ADD COLUMN IF NOT EXISTS dificuldade TEXT CHECK (dificuldade IN ('facil', 'media', 'dificil'));
+
+CREATE INDEX IF NOT EXISTS receitas_tempo_total_idx ON receitas (tempo_total);
@@ -0,0 +1,2 @@
+-- Os arquivos continuam no storage
+DROP TABLE IF EXISTS imagens;
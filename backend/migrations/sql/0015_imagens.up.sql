@@ -0,0 +1,14 @@
+-- Uma imagem por receita e passo; passo NULL e a capa. Os arquivos ficam no
+-- storage, sob "chave", e saem junto com a linha.
+CREATE TABLE IF NOT EXISTS imagens (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	receita_id UUID NOT NULL REFERENCES receitas(id) ON DELETE CASCADE,
+	passo INTEGER CHECK (passo >= 0),
+	chave TEXT NOT NULL,
+	extensao TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	largura INTEGER NOT NULL,
+	altura INTEGER NOT NULL,
+	criado_em TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS imagens_receita_passo_idx ON imagens (receita_id, COALESCE(passo, -1));
@@ -0,0 +1,11 @@
+-- Equivalentes do esquema do Postgres (migrations/sql) no SQLite:
+--   UUID vira TEXT, com um UUID v4 aleatorio como padrao no lugar de gen_random_uuid();
+--   TEXT[] e JSONB viram TEXT com o array ou objeto em JSON;
+--   TIMESTAMPTZ vira TIMESTAMP, sempre em UTC, no formato do driver.
+CREATE TABLE receitas (
+	id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	nome TEXT NOT NULL,
+	descricao TEXT NOT NULL,
+	ingredientes TEXT NOT NULL,
+	instrucoes TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE receitas DROP COLUMN autor_id;
+DROP TABLE IF EXISTS users;
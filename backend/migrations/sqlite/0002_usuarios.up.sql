@@ -0,0 +1,8 @@
+CREATE TABLE users (
+	id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	username TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+ALTER TABLE receitas ADD COLUMN autor_id TEXT REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+-- O SQLite so e suportado desde as migrations: nao ha contas com o antigo papel
+-- "user" para converter, como no Postgres.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'reader' CHECK (role IN ('admin', 'editor', 'reader'));
@@ -0,0 +1,16 @@
+CREATE TABLE sessions (
+	id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	criado_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	expira_em TIMESTAMP NOT NULL,
+	revogada_em TIMESTAMP
+);
+
+CREATE TABLE refresh_tokens (
+	token_hash TEXT PRIMARY KEY,
+	session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+	criado_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	expira_em TIMESTAMP NOT NULL,
+	usado_em TIMESTAMP
+);
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
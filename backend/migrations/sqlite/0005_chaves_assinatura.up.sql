@@ -0,0 +1,7 @@
+CREATE TABLE signing_keys (
+	kid TEXT PRIMARY KEY,
+	alg TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+	expira_em TIMESTAMP NOT NULL
+);
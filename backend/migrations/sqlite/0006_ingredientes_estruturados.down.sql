@@ -0,0 +1,2 @@
+ALTER TABLE receitas DROP COLUMN porcoes;
+ALTER TABLE receitas DROP COLUMN ingredientes_estruturados;
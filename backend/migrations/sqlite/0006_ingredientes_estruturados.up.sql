@@ -0,0 +1,3 @@
+ALTER TABLE receitas ADD COLUMN ingredientes_estruturados TEXT;
+
+ALTER TABLE receitas ADD COLUMN porcoes INTEGER CHECK (porcoes > 0);
@@ -0,0 +1 @@
+-- Nada a desfazer
@@ -0,0 +1,2 @@
+-- Sem equivalente: no SQLite a busca textual e feita pela aplicacao (repository.SQLite).
+-- A migration existe para manter a mesma numeracao do Postgres.
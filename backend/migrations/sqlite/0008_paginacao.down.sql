@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS receitas_criado_em_id_idx;
+DROP INDEX IF EXISTS receitas_nome_id_idx;
+ALTER TABLE receitas DROP COLUMN criado_em;
@@ -0,0 +1,21 @@
+-- O SQLite nao aceita ADD COLUMN com padrao calculado, entao a tabela e
+-- recriada com a coluna. Receitas antigas recebem a data desta migration.
+CREATE TABLE receitas_nova (
+	id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	nome TEXT NOT NULL,
+	descricao TEXT NOT NULL,
+	ingredientes TEXT NOT NULL,
+	instrucoes TEXT NOT NULL,
+	autor_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+	ingredientes_estruturados TEXT,
+	porcoes INTEGER CHECK (porcoes > 0),
+	criado_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+INSERT INTO receitas_nova (id, nome, descricao, ingredientes, instrucoes, autor_id, ingredientes_estruturados, porcoes)
+	SELECT id, nome, descricao, ingredientes, instrucoes, autor_id, ingredientes_estruturados, porcoes FROM receitas;
+DROP TABLE receitas;
+ALTER TABLE receitas_nova RENAME TO receitas;
+
+-- Indices da paginacao por cursor; o id desempata valores iguais
+CREATE INDEX receitas_nome_id_idx ON receitas (nome, id);
+CREATE INDEX receitas_criado_em_id_idx ON receitas (criado_em, id);
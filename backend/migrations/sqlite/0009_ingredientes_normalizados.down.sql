@@ -0,0 +1 @@
+ALTER TABLE receitas DROP COLUMN ingredientes_normalizados;
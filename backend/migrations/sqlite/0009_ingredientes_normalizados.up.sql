@@ -0,0 +1,2 @@
+-- Preenchida pela aplicacao em cada escrita; as receitas antigas pela migration 0010
+ALTER TABLE receitas ADD COLUMN ingredientes_normalizados TEXT;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS receitas_cozinha_id_idx;
+DROP INDEX IF EXISTS receitas_categoria_id_idx;
+ALTER TABLE receitas DROP COLUMN cozinha_id;
+ALTER TABLE receitas DROP COLUMN categoria_id;
+DROP TABLE IF EXISTS receita_tags;
+DROP TABLE IF EXISTS cozinhas;
+DROP TABLE IF EXISTS categorias;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,30 @@
+CREATE TABLE tags (
+	id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	nome TEXT NOT NULL,
+	slug TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE categorias (
+	id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	nome TEXT NOT NULL,
+	slug TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE cozinhas (
+	id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	nome TEXT NOT NULL,
+	slug TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE receita_tags (
+	receita_id TEXT NOT NULL REFERENCES receitas(id) ON DELETE CASCADE,
+	tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	PRIMARY KEY (receita_id, tag_id)
+);
+CREATE INDEX receita_tags_tag_id_idx ON receita_tags (tag_id);
+
+-- Remover a categoria ou a cozinha deixa as receitas sem classificacao
+ALTER TABLE receitas ADD COLUMN categoria_id TEXT REFERENCES categorias(id) ON DELETE SET NULL;
+ALTER TABLE receitas ADD COLUMN cozinha_id TEXT REFERENCES cozinhas(id) ON DELETE SET NULL;
+CREATE INDEX receitas_categoria_id_idx ON receitas (categoria_id);
+CREATE INDEX receitas_cozinha_id_idx ON receitas (cozinha_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS receitas_tempo_total_idx;
+ALTER TABLE receitas DROP COLUMN dificuldade;
+ALTER TABLE receitas DROP COLUMN tempo_total;
+ALTER TABLE receitas DROP COLUMN tempo_descanso;
+ALTER TABLE receitas DROP COLUMN tempo_cozimento;
+ALTER TABLE receitas DROP COLUMN tempo_preparo;
@@ -0,0 +1,9 @@
+-- Minutos
+ALTER TABLE receitas ADD COLUMN tempo_preparo INTEGER CHECK (tempo_preparo >= 0);
+ALTER TABLE receitas ADD COLUMN tempo_cozimento INTEGER CHECK (tempo_cozimento >= 0);
+ALTER TABLE receitas ADD COLUMN tempo_descanso INTEGER CHECK (tempo_descanso >= 0);
+ALTER TABLE receitas ADD COLUMN tempo_total INTEGER CHECK (tempo_total >= 0);
+
+ALTER TABLE receitas ADD COLUMN dificuldade TEXT CHECK (dificuldade IN ('facil', 'media', 'dificil'));
+
+CREATE INDEX receitas_tempo_total_idx ON receitas (tempo_total);
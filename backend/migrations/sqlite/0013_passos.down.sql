@@ -0,0 +1,2 @@
+-- O texto de "instrucoes" continua com o modo de preparo
+ALTER TABLE receitas DROP COLUMN passos;
@@ -0,0 +1,2 @@
+-- Fica NULL nas receitas antigas ate a migration 0014 dividir as instrucoes
+ALTER TABLE receitas ADD COLUMN passos TEXT;
@@ -0,0 +1,14 @@
+-- Uma imagem por receita e passo; passo NULL e a capa. Os arquivos ficam no
+-- storage, sob "chave", e saem junto com a linha.
+CREATE TABLE imagens (
+	id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))),
+	receita_id TEXT NOT NULL REFERENCES receitas(id) ON DELETE CASCADE,
+	passo INTEGER CHECK (passo >= 0),
+	chave TEXT NOT NULL,
+	extensao TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	largura INTEGER NOT NULL,
+	altura INTEGER NOT NULL,
+	criado_em TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+CREATE UNIQUE INDEX imagens_receita_passo_idx ON imagens (receita_id, COALESCE(passo, -1));
@@ -0,0 +1,12 @@
+package models
+
+// Resultado de uma busca textual, presente apenas nas respostas de ?q=
+type ResultadoBusca struct {
+	Relevancia float64 `json:"relevancia"`
+	Nome       string  `json:"nome"`   // Nome com os termos encontrados entre <mark></mark>
+	Trecho     string  `json:"trecho"` // Trechos da descricao, ingredientes e instrucoes com os termos marcados
+}
+
+// Configuracao de busca usada pela coluna "busca" e pelas consultas: portugues com stemming,
+// sem diferenciar acentos ("acucar" encontra "açúcar")
+const ConfiguracaoBusca = "portugues_sem_acento" // Criada pela migration 0007_busca
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+	"github.com/google/uuid"
+)
+
+// Tag, categoria ou cozinha. As receitas se referem a elas pelo slug.
+type Classificacao struct {
+	ID            uuid.UUID `json:"id"`
+	Nome          string    `json:"nome"`
+	Slug          string    `json:"slug"`
+	TotalReceitas int       `json:"total_receitas"` // Calculado na leitura, ignorado na entrada
+}
+
+// Tipo de classificacao e a tabela onde fica. As receitas tem varias tags,
+// mas uma categoria e uma cozinha.
+type TipoClassificacao struct {
+	Tabela string
+	Rotulo string // Usado nas mensagens de erro
+	Campo  string // Campo da receita que guarda os slugs deste tipo
+	// Subconsulta que conta as receitas da linha atual de Tabela. Referencia a
+	// tabela pelo nome, sem alias, porque o RETURNING do SQLite nao aceita alias.
+	ContagemSQL string
+}
+
+var (
+	TipoTag       = TipoClassificacao{Tabela: "tags", Rotulo: "tag", Campo: "tags", ContagemSQL: `(SELECT count(*) FROM receita_tags WHERE tag_id = tags.id)`}
+	TipoCategoria = TipoClassificacao{Tabela: "categorias", Rotulo: "categoria", Campo: "categoria", ContagemSQL: `(SELECT count(*) FROM receitas WHERE categoria_id = categorias.id)`}
+	TipoCozinha   = TipoClassificacao{Tabela: "cozinhas", Rotulo: "cozinha", Campo: "cozinha", ContagemSQL: `(SELECT count(*) FROM receitas WHERE cozinha_id = cozinhas.id)`}
+)
+
+const tamanhoMaximoClassificacao = 50
+
+var (
+	reSlug          = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	reSeparadorSlug = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Slug a partir de um nome: "Rápido & fácil" vira "rapido-facil"
+func Slug(nome string) string {
+	return strings.Trim(reSeparadorSlug.ReplaceAllString(units.Normalizar(nome), "-"), "-")
+}
+
+// Slugs dos nomes, sem vazios nem repetidos
+func Slugs(nomes []string) []string {
+	slugs := []string{}
+	vistos := map[string]bool{}
+	for _, nome := range nomes {
+		if slug := Slug(nome); slug != "" && !vistos[slug] {
+			vistos[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs
+}
+
+// Preenche o slug a partir do nome quando vazio e valida os dois
+func (classificacao *Classificacao) Preparar() error {
+	classificacao.Nome = strings.TrimSpace(classificacao.Nome)
+	if classificacao.Slug == "" {
+		classificacao.Slug = Slug(classificacao.Nome)
+	}
+	if classificacao.Nome == "" || len(classificacao.Nome) > tamanhoMaximoClassificacao {
+		return ErroCampo("nome", "nome deve ter entre 1 e %d caracteres", tamanhoMaximoClassificacao)
+	}
+	if !reSlug.MatchString(classificacao.Slug) || len(classificacao.Slug) > tamanhoMaximoClassificacao {
+		return ErroCampo("slug", "slug inválido: use letras minúsculas, números e hífens (até %d caracteres)", tamanhoMaximoClassificacao)
+	}
+	return nil
+}
+
+// Slug de uma classificacao que nao existe, informado em uma receita ou filtro
+type ClassificacaoDesconhecidaError struct {
+	Tipo TipoClassificacao
+	Slug string
+}
+
+func (e *ClassificacaoDesconhecidaError) Error() string {
+	return fmt.Sprintf("%s desconhecida: %q", e.Tipo.Rotulo, e.Slug)
+}
+
+// Normaliza os slugs de tags, categoria e cozinha da receita, aceitando nomes
+// ("Rápido" vira "rapido") e removendo tags repetidas
+func (receita *Receita) PrepararClassificacoes() {
+	receita.Tags = Slugs(receita.Tags)
+	receita.Categoria = Slug(receita.Categoria)
+	receita.Cozinha = Slug(receita.Cozinha)
+}
+
+// Contagem de receitas por classificacao na listagem (?facetas=true)
+type Faceta struct {
+	Slug  string `json:"slug"`
+	Nome  string `json:"nome"`
+	Total int    `json:"total"`
+}
+
+type Facetas struct {
+	Tags       []Faceta `json:"tags"`
+	Categorias []Faceta `json:"categorias"`
+	Cozinhas   []Faceta `json:"cozinhas"`
+}
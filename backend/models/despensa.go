@@ -0,0 +1,106 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+)
+
+// Resultado do filtro por ingredientes disponiveis, presente apenas nas respostas de ?tem=
+type ResultadoDespensa struct {
+	Faltando             int      `json:"faltando"`
+	IngredientesFaltando []string `json:"ingredientes_faltando"`
+}
+
+// Palavras ignoradas na comparacao de nomes ("farinha de trigo" vira "farinha trigo")
+var palavrasIgnoradas = map[string]bool{
+	"de": true, "do": true, "da": true, "dos": true, "das": true, "e": true, "com": true, "em": true,
+}
+
+// Observacoes que tornam um ingrediente dispensavel para o filtro
+var observacoesOpcionais = []string{"a gosto", "q.b", "qb", "quanto baste", "opcional", "para decorar", "para servir"}
+
+// Nome usado na comparacao de ingredientes: sem acentos, no singular, sem
+// preposicoes e apenas com letras e numeros ("Ovos" e "ovo" ficam iguais)
+func NomeNormalizado(nome string) string {
+	var palavras []string
+	for _, p := range strings.Fields(units.Singular(units.Normalizar(nome))) {
+		p = strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, p)
+		if p != "" && !palavrasIgnoradas[p] {
+			palavras = append(palavras, p)
+		}
+	}
+	return strings.Join(palavras, " ")
+}
+
+// Indica se o ingrediente pode faltar sem impedir a receita ("sal a gosto", "hortelã para decorar")
+func (ing Ingrediente) Opcional() bool {
+	observacao := units.Normalizar(ing.Observacao)
+	for _, opcional := range observacoesOpcionais {
+		if strings.Contains(observacao, opcional) {
+			return true
+		}
+	}
+	return false
+}
+
+// Nomes normalizados dos ingredientes obrigatorios, gravados em ingredientes_normalizados
+func (receita Receita) IngredientesNormalizados() []string {
+	nomes := []string{}
+	for _, ing := range receita.IngredientesEstruturados {
+		if nome := NomeNormalizado(ing.Nome); nome != "" && !ing.Opcional() {
+			nomes = append(nomes, nome)
+		}
+	}
+	return nomes
+}
+
+// Um termo disponivel cobre o ingrediente quando todas as suas palavras aparecem,
+// em sequencia, no nome do ingrediente: "farinha" cobre "farinha trigo".
+// Mesma regra da consulta em SQL (FaltandoSQL).
+func IngredienteCoberto(nomeNormalizado string, disponiveis []string) bool {
+	for _, termo := range disponiveis {
+		if strings.Contains(" "+nomeNormalizado+" ", " "+termo+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Ingredientes obrigatorios da receita que nao estao entre os disponiveis (ja normalizados)
+func (receita Receita) ConferirDespensa(disponiveis []string) ResultadoDespensa {
+	resultado := ResultadoDespensa{IngredientesFaltando: []string{}}
+	for _, ing := range receita.IngredientesEstruturados {
+		nome := NomeNormalizado(ing.Nome)
+		if nome == "" || ing.Opcional() || IngredienteCoberto(nome, disponiveis) {
+			continue
+		}
+		resultado.IngredientesFaltando = append(resultado.IngredientesFaltando, ing.Nome)
+	}
+	resultado.Faltando = len(resultado.IngredientesFaltando)
+	return resultado
+}
+
+// Expressao que conta, para cada receita, os ingredientes obrigatorios cobertos e os que faltam.
+// Recebe o placeholder do array de termos disponiveis, ja normalizados por NomeNormalizado.
+func FaltandoSQL(placeholder string) string {
+	coberto := `EXISTS (SELECT 1 FROM unnest(` + placeholder + `::text[]) AS termo WHERE ' ' || ing || ' ' LIKE '% ' || termo || ' %')`
+	return `LATERAL (
+		SELECT count(*) FILTER (WHERE NOT ` + coberto + `) AS faltando,
+			count(*) FILTER (WHERE ` + coberto + `) AS usados
+		FROM unnest(receitas.ingredientes_normalizados) AS ing
+	) AS despensa`
+}
+
+// Versao de FaltandoSQL para o SQLite, onde os nomes e os termos ficam em JSON.
+// Retorna as duas colunas, faltando e usados, para o SELECT de uma subconsulta.
+func FaltandoSQLite(placeholder string) string {
+	coberto := `EXISTS (SELECT 1 FROM json_each(` + placeholder + `) AS termo WHERE ' ' || ing.value || ' ' LIKE '% ' || termo.value || ' %')`
+	return `(SELECT count(*) FROM json_each(receitas.ingredientes_normalizados) AS ing WHERE NOT ` + coberto + `) AS faltando,
+		(SELECT count(*) FROM json_each(receitas.ingredientes_normalizados) AS ing WHERE ` + coberto + `) AS usados`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"math"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+)
+
+// Retorna uma copia da receita com todas as quantidades multiplicadas por fator
+func (receita Receita) Escalar(fator float64) Receita {
+	escalada := receita
+	escalada.IngredientesEstruturados = make([]Ingrediente, len(receita.IngredientesEstruturados))
+	escalada.Ingredientes = make([]string, len(receita.IngredientesEstruturados))
+	for i, ing := range receita.IngredientesEstruturados {
+		escalada.IngredientesEstruturados[i] = ing.Escalar(fator)
+		escalada.Ingredientes[i] = escalada.IngredientesEstruturados[i].String()
+	}
+	if receita.Porcoes > 0 {
+		escalada.Porcoes = max(1, int(math.Round(float64(receita.Porcoes)*fator)))
+	}
+	return escalada
+}
+
+// Multiplica a quantidade, troca para a unidade mais adequada do mesmo sistema
+// (16 colheres de sopa viram 1 xícara) e arredonda para valores usados na cozinha
+func (ing Ingrediente) Escalar(fator float64) Ingrediente {
+	if ing.Quantidade == nil {
+		return ing
+	}
+
+	quantidade := *ing.Quantidade * fator
+	var maximo float64
+	if ing.QuantidadeMax != nil {
+		maximo = *ing.QuantidadeMax * fator
+	}
+
+	u, ok := units.Buscar(ing.Unidade)
+	if ok && u.Automatica {
+		if nova := units.Padrao.MelhorUnidade(quantidade, u, u.Sistema); nova != nil && nova != u {
+			quantidade, _ = units.Padrao.Converter(quantidade, u, nova, ing.Nome)
+			maximo, _ = units.Padrao.Converter(maximo, u, nova, ing.Nome)
+			u = nova
+			ing.Unidade = nova.Nome
+		}
+	}
+
+	q := units.Arredondar(quantidade, u)
+	ing.Quantidade = &q
+	if ing.QuantidadeMax != nil {
+		qm := units.Arredondar(maximo, u)
+		ing.QuantidadeMax = &qm
+	}
+	return ing
+}
+
+// Retorna uma copia da receita com as quantidades e as temperaturas dos
+// passos no sistema de unidades pedido
+func (receita Receita) ParaSistema(sistema units.Sistema) Receita {
+	convertida := receita
+	convertida.IngredientesEstruturados = make([]Ingrediente, len(receita.IngredientesEstruturados))
+	convertida.Ingredientes = make([]string, len(receita.IngredientesEstruturados))
+	for i, ing := range receita.IngredientesEstruturados {
+		convertida.IngredientesEstruturados[i] = ing.ParaSistema(sistema)
+		convertida.Ingredientes[i] = convertida.IngredientesEstruturados[i].String()
+	}
+	convertida.Passos = make([]Passo, len(receita.Passos))
+	for i, passo := range receita.Passos {
+		convertida.Passos[i] = passo.ParaSistema(sistema)
+	}
+	convertida.Instrucoes = RenderizarPassos(convertida.Passos)
+	return convertida
+}
+
+// Converte a quantidade para o sistema pedido. Ingredientes sem quantidade,
+// sem unidade ou com unidades de contagem ficam como estao.
+func (ing Ingrediente) ParaSistema(sistema units.Sistema) Ingrediente {
+	u, ok := units.Buscar(ing.Unidade)
+	if ing.Quantidade == nil || !ok {
+		return ing
+	}
+
+	quantidade, destino := units.Padrao.ParaSistema(*ing.Quantidade, u, sistema, ing.Nome)
+	if destino == u {
+		return ing
+	}
+
+	q := units.Arredondar(quantidade, destino)
+	ing.Quantidade = &q
+	if ing.QuantidadeMax != nil {
+		maximo, err := units.Padrao.Converter(*ing.QuantidadeMax, u, destino, ing.Nome)
+		if err == nil {
+			qm := units.Arredondar(maximo, destino)
+			ing.QuantidadeMax = &qm
+		}
+	}
+	ing.Unidade = destino.Nome
+	return ing
+}
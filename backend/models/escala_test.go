@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+)
+
+func TestIngredienteEscalar(t *testing.T) {
+	casos := []struct {
+		texto    string
+		fator    float64
+		esperado string
+	}{
+		{"8 colheres de sopa de manteiga", 2, "1 xícara de manteiga"},
+		{"1 colher de sopa de sal", 16, "1 xícara de sal"},
+		{"3 colheres de chá de fermento", 1, "1 colher de sopa de fermento"},
+		{"1 xícara de leite", 0.5, "1/2 xícara de leite"},
+		{"1 xícara de leite", 1.0 / 3, "1/3 xícara de leite"},
+		{"3 ovos", 0.5, "1 1/2 ovos"},
+		{"1 ovo", 0.1, "1/8 ovo"}, // Nunca some
+		{"250 g de farinha", 1.5, "375 g de farinha"},
+		{"750 g de carne", 2, "1 1/2 kg de carne"},
+		{"1 kg de batata", 0.33, "330 g de batata"},
+		{"500 ml de leite", 3, "1,5 l de leite"},
+		{"2 a 3 tomates", 2, "4 a 6 tomates"},
+		{"1 a 2 xícaras de caldo", 8, "8 a 16 xícaras de caldo"},
+		{"sal a gosto", 3, "sal a gosto"},
+	}
+	for _, caso := range casos {
+		if escalado := ParseIngrediente(caso.texto).Escalar(caso.fator).String(); escalado != caso.esperado {
+			t.Errorf("%q x %v = %q, esperado %q", caso.texto, caso.fator, escalado, caso.esperado)
+		}
+	}
+}
+
+// Dobrar e depois dividir por dois volta a medida original
+func TestIngredienteEscalarIdaEVolta(t *testing.T) {
+	for _, texto := range []string{"1 xícara de açúcar", "2 colheres de sopa de azeite", "1/2 colher de chá de sal", "500 g de carne", "3 ovos", "200 ml de leite"} {
+		for _, fator := range []float64{2, 3, 4} {
+			ing := ParseIngrediente(texto)
+			if volta := ing.Escalar(fator).Escalar(1 / fator).String(); volta != ing.String() {
+				t.Errorf("%q x %v e de volta = %q", texto, fator, volta)
+			}
+		}
+	}
+}
+
+func TestReceitaEscalar(t *testing.T) {
+	receita := Receita{Porcoes: 4, Ingredientes: []string{"2 xícaras de farinha de trigo", "3 ovos"}}
+	if err := receita.PrepararIngredientes(); err != nil {
+		t.Fatal(err)
+	}
+
+	escalada := receita.Escalar(1.5)
+	if escalada.Porcoes != 6 || escalada.Ingredientes[0] != "3 xícaras de farinha de trigo" || escalada.Ingredientes[1] != "4 1/2 ovos" {
+		t.Fatalf("receita x 1,5 = %d porções, %q", escalada.Porcoes, escalada.Ingredientes)
+	}
+	if receita.Ingredientes[0] != "2 xícaras de farinha de trigo" {
+		t.Fatalf("Escalar alterou a receita original: %q", receita.Ingredientes)
+	}
+	if minima := receita.Escalar(0.1); minima.Porcoes != 1 {
+		t.Fatalf("porções de receita x 0,1 = %d, esperado 1", minima.Porcoes)
+	}
+}
+
+func TestIngredienteParaSistema(t *testing.T) {
+	casos := []struct {
+		texto    string
+		sistema  units.Sistema
+		esperado string
+	}{
+		{"2 xícaras de farinha de trigo", units.Metrico, "240 g de farinha de trigo"},
+		{"1 xícara de leite", units.Metrico, "240 ml de leite"},
+		{"2 a 3 xícaras de leite", units.Metrico, "480 a 720 ml de leite"},
+		{"1 xícara de leite", units.Imperial, "1 cup de leite"},
+		{"240 ml de leite", units.Caseiro, "1 xícara de leite"},
+		{"500 g de farinha de trigo", units.Caseiro, "4 1/8 xícaras de farinha de trigo"},
+		{"1 lb de carne", units.Metrico, "455 g de carne"},
+		{"500 g de carne", units.Imperial, "1 1/8 lb de carne"},
+		{"500 g de carne", units.Caseiro, "500 g de carne"},
+		{"1 cup de açúcar", units.Caseiro, "1 xícara de açúcar"},
+		{"3 ovos", units.Imperial, "3 ovos"},
+		{"sal a gosto", units.Metrico, "sal a gosto"},
+	}
+	for _, caso := range casos {
+		if convertido := ParseIngrediente(caso.texto).ParaSistema(caso.sistema).String(); convertido != caso.esperado {
+			t.Errorf("%q no %s = %q, esperado %q", caso.texto, caso.sistema, convertido, caso.esperado)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/imagens"
+	"github.com/google/uuid"
+)
+
+// Imagem enviada para a receita: a capa ou a foto de um passo
+type Imagem struct {
+	ID         uuid.UUID         `json:"id"`
+	URL        string            `json:"url"`        // Arquivo original
+	Miniaturas map[string]string `json:"miniaturas"` // URL por tamanho: pequena, media e grande
+	Largura    int               `json:"largura"`    // Da original, em pixels
+	Altura     int               `json:"altura"`
+
+	Passo    *int   `json:"-"` // Posicao (a partir de 0) do passo; nil para a capa
+	Chave    string `json:"-"` // Prefixo dos arquivos no storage
+	Extensao string `json:"-"` // Da original
+}
+
+// Chave da original no storage
+func (imagem Imagem) ArquivoOriginal() string {
+	return imagem.Chave + "/original." + imagem.Extensao
+}
+
+// Chave da miniatura de um dos imagens.Tamanhos
+func (imagem Imagem) ArquivoMiniatura(tamanho string) string {
+	return imagem.Chave + "/" + tamanho + ".jpg"
+}
+
+// Todas as chaves gravadas para a imagem
+func (imagem Imagem) Arquivos() []string {
+	arquivos := []string{imagem.ArquivoOriginal()}
+	for _, tamanho := range imagens.Tamanhos {
+		arquivos = append(arquivos, imagem.ArquivoMiniatura(tamanho.Nome))
+	}
+	return arquivos
+}
+
+// Preenche as URLs a partir da chave, com a funcao URL do storage
+func (imagem *Imagem) PrepararURLs(url func(chave string) string) {
+	imagem.URL = url(imagem.ArquivoOriginal())
+	imagem.Miniaturas = make(map[string]string, len(imagens.Tamanhos))
+	for _, tamanho := range imagens.Tamanhos {
+		imagem.Miniaturas[tamanho.Nome] = url(imagem.ArquivoMiniatura(tamanho.Nome))
+	}
+}
+
+// Coloca cada imagem no lugar: a capa na receita e as fotos nos passos.
+// Fotos de passos que nao existem mais sao ignoradas.
+func (receita *Receita) AnexarImagens(lista []Imagem) {
+	receita.Capa = nil
+	for i := range receita.Passos {
+		receita.Passos[i].Foto = nil
+	}
+	for i := range lista {
+		imagem := lista[i]
+		switch {
+		case imagem.Passo == nil:
+			receita.Capa = &imagem
+		case *imagem.Passo >= 0 && *imagem.Passo < len(receita.Passos):
+			receita.Passos[*imagem.Passo].Foto = &imagem
+		}
+	}
+}
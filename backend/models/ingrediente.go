@@ -0,0 +1,222 @@
+package models
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+)
+
+// Ingrediente estruturado: "2 xícaras de farinha de trigo, peneirada" vira
+// Quantidade 2, Unidade "xícara", Nome "farinha de trigo" e Observacao "peneirada".
+type Ingrediente struct {
+	Quantidade    *float64 `json:"quantidade,omitempty"`
+	QuantidadeMax *float64 `json:"quantidade_max,omitempty"` // Fim da faixa em "2 a 3 tomates"
+	Unidade       string   `json:"unidade,omitempty"`        // Nome canonico no singular (ver units.Padrao)
+	Nome          string   `json:"nome"`
+	Observacao    string   `json:"observacao,omitempty"`
+}
+
+var fracoesUnicode = map[string]float64{
+	"½": 1.0 / 2, "⅓": 1.0 / 3, "⅔": 2.0 / 3, "¼": 1.0 / 4, "¾": 3.0 / 4, "⅛": 1.0 / 8,
+}
+
+var numerosPorExtenso = map[string]float64{
+	"um": 1, "uma": 1, "dois": 2, "duas": 2, "três": 3, "tres": 3, "quatro": 4, "cinco": 5,
+	"meio": 0.5, "meia": 0.5,
+}
+
+var (
+	reQuantidade = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d+[½⅓⅔¼¾⅛]|[½⅓⅔¼¾⅛]|\d+(?:[.,]\d+)?)`)
+	reFaixa      = regexp.MustCompile(`^\s*(?:[-–]|a\s|ou\s)\s*`)
+	reMarcador   = regexp.MustCompile(`^[-*•·]\s*`)
+	reDe         = regexp.MustCompile(`^(?:de|do|da|dos|das)\s+`)
+	reAGosto     = regexp.MustCompile(`(?i)\s+(a gosto|q\.?\s?b\.?|quanto baste)$`)
+)
+
+// Converte "1 1/2", "1/2", "1½", "0,5" ou "2" em numero
+func parseNumero(texto string) (float64, bool) {
+	texto = strings.TrimSpace(texto)
+	if partes := strings.Fields(texto); len(partes) == 2 {
+		inteiro, ok1 := parseNumero(partes[0])
+		fracao, ok2 := parseNumero(partes[1])
+		return inteiro + fracao, ok1 && ok2
+	}
+	if num, den, ok := strings.Cut(texto, "/"); ok {
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	for simbolo, valor := range fracoesUnicode {
+		if strings.HasSuffix(texto, simbolo) {
+			inteiro := strings.TrimSuffix(texto, simbolo)
+			if inteiro == "" {
+				return valor, true
+			}
+			n, err := strconv.ParseFloat(inteiro, 64)
+			return n + valor, err == nil
+		}
+	}
+	n, err := strconv.ParseFloat(strings.Replace(texto, ",", ".", 1), 64)
+	return n, err == nil
+}
+
+// Le uma quantidade no inicio do texto e retorna o valor e o restante
+func lerQuantidade(texto string) (float64, string, bool) {
+	if m := reQuantidade.FindString(texto); m != "" {
+		if valor, ok := parseNumero(m); ok {
+			return valor, texto[len(m):], true
+		}
+	}
+	palavra, resto, _ := strings.Cut(texto, " ")
+	if valor, ok := numerosPorExtenso[strings.ToLower(palavra)]; ok && resto != "" {
+		return valor, " " + resto, true
+	}
+	return 0, texto, false
+}
+
+// Procura a unidade mais longa no inicio do texto
+func lerUnidade(texto string) (*units.Unidade, string) {
+	palavras := strings.Fields(texto)
+	for n := min(len(palavras), 4); n > 0; n-- {
+		candidato := strings.Join(palavras[:n], " ")
+		if u, ok := units.Buscar(candidato); ok {
+			// Uma unidade nao pode ser o texto inteiro ("2 folhas" sao as folhas)
+			if n == len(palavras) {
+				return nil, texto
+			}
+			return u, strings.Join(palavras[n:], " ")
+		}
+	}
+	return nil, texto
+}
+
+// Converte uma linha de texto livre em ingrediente estruturado.
+// Linhas que nao seguem o formato viram apenas o Nome.
+func ParseIngrediente(texto string) Ingrediente {
+	texto = strings.TrimSpace(reMarcador.ReplaceAllString(strings.TrimSpace(texto), ""))
+	var ing Ingrediente
+
+	if valor, resto, ok := lerQuantidade(texto); ok {
+		q := units.Casas(valor, 4)
+		ing.Quantidade = &q
+		texto = strings.TrimSpace(resto)
+
+		if loc := reFaixa.FindStringIndex(texto); loc != nil {
+			if maximo, resto, ok := lerQuantidade(texto[loc[1]:]); ok && maximo > valor {
+				qm := units.Casas(maximo, 4)
+				ing.QuantidadeMax = &qm
+				texto = strings.TrimSpace(resto)
+			}
+		}
+
+		if u, resto := lerUnidade(texto); u != nil {
+			ing.Unidade = u.Nome
+			texto = reDe.ReplaceAllString(strings.TrimSpace(resto), "")
+		}
+	}
+
+	if m := reAGosto.FindStringSubmatchIndex(texto); m != nil {
+		ing.Observacao = strings.ToLower(texto[m[2]:m[3]])
+		texto = texto[:m[0]]
+	} else if abre := strings.LastIndex(texto, "("); abre > 0 && strings.HasSuffix(texto, ")") {
+		ing.Observacao = strings.TrimSpace(texto[abre+1 : len(texto)-1])
+		texto = texto[:abre]
+	} else if nome, obs, ok := strings.Cut(texto, ","); ok {
+		ing.Observacao = strings.TrimSpace(obs)
+		texto = nome
+	}
+
+	ing.Nome = strings.TrimSpace(texto)
+	return ing
+}
+
+// Indica se as quantidades sao positivas e finitas. Uma faixa exige o inicio.
+func (ing Ingrediente) quantidadeValida() bool {
+	positiva := func(q *float64) bool {
+		return q == nil || (*q > 0 && !math.IsInf(*q, 0))
+	}
+	if ing.QuantidadeMax != nil && ing.Quantidade == nil {
+		return false
+	}
+	return positiva(ing.Quantidade) && positiva(ing.QuantidadeMax)
+}
+
+// Formata uma quantidade para exibicao: "1 1/2" quando a unidade usa fracoes, "1,5" caso contrario
+func FormatarQuantidade(valor float64, fracoes bool) string {
+	if !fracoes {
+		return formatarDecimal(valor)
+	}
+
+	inteiro := math.Floor(valor)
+	resto := valor - inteiro
+	for _, f := range units.FracoesComuns {
+		if math.Abs(resto-f.Valor) >= 0.02 {
+			continue
+		}
+		if f.Valor == 1 {
+			inteiro++
+		}
+		switch {
+		case inteiro == 0 && f.Texto != "":
+			return f.Texto
+		case f.Texto == "":
+			return strconv.FormatFloat(inteiro, 'f', 0, 64)
+		default:
+			return strconv.FormatFloat(inteiro, 'f', 0, 64) + " " + f.Texto
+		}
+	}
+	return formatarDecimal(valor)
+}
+
+// Decimal com virgula e no maximo duas casas
+func formatarDecimal(valor float64) string {
+	return strings.Replace(strconv.FormatFloat(units.Casas(valor, 2), 'f', -1, 64), ".", ",", 1)
+}
+
+// Texto do ingrediente para exibicao, no formato aceito por ParseIngrediente
+func (ing Ingrediente) String() string {
+	var partes []string
+	u, temUnidade := units.Buscar(ing.Unidade)
+
+	if ing.Quantidade != nil {
+		fracoes := !temUnidade || u.Fracoes
+		qtd := FormatarQuantidade(*ing.Quantidade, fracoes)
+		if ing.QuantidadeMax != nil {
+			qtd += " a " + FormatarQuantidade(*ing.QuantidadeMax, fracoes)
+		}
+		partes = append(partes, qtd)
+	}
+
+	if ing.Unidade != "" {
+		unidade := ing.Unidade
+		if temUnidade {
+			unidade = u.Nome
+			maior := ing.Quantidade
+			if ing.QuantidadeMax != nil {
+				maior = ing.QuantidadeMax
+			}
+			if maior != nil && *maior > 1 {
+				unidade = u.Plural
+			}
+		}
+		partes = append(partes, unidade, "de")
+	}
+
+	partes = append(partes, ing.Nome)
+	texto := strings.Join(partes, " ")
+
+	switch {
+	case ing.Observacao == "":
+	case reAGosto.MatchString(" " + ing.Observacao):
+		texto += " " + ing.Observacao
+	default:
+		texto += ", " + ing.Observacao
+	}
+	return texto
+}
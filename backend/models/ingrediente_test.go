@@ -0,0 +1,97 @@
+package models
+
+import "testing"
+
+func quantidade(valor float64) *float64 {
+	return &valor
+}
+
+func mesmaQuantidade(a, b *float64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func TestParseIngrediente(t *testing.T) {
+	casos := []struct {
+		texto    string
+		esperado Ingrediente
+		exibicao string // Resultado de String(); vazio se igual ao texto
+	}{
+		{"2 xícaras de farinha de trigo, peneirada", Ingrediente{Quantidade: quantidade(2), Unidade: "xícara", Nome: "farinha de trigo", Observacao: "peneirada"}, ""},
+		{"1 1/2 xícara de açúcar", Ingrediente{Quantidade: quantidade(1.5), Unidade: "xícara", Nome: "açúcar"}, "1 1/2 xícaras de açúcar"},
+		{"1/2 colher de chá de sal", Ingrediente{Quantidade: quantidade(0.5), Unidade: "colher de chá", Nome: "sal"}, ""},
+		{"½ xícara de leite", Ingrediente{Quantidade: quantidade(0.5), Unidade: "xícara", Nome: "leite"}, "1/2 xícara de leite"},
+		{"1½ kg de carne", Ingrediente{Quantidade: quantidade(1.5), Unidade: "kg", Nome: "carne"}, "1 1/2 kg de carne"},
+		{"0,5 kg de batata", Ingrediente{Quantidade: quantidade(0.5), Unidade: "kg", Nome: "batata"}, "1/2 kg de batata"},
+		{"meia xícara de óleo", Ingrediente{Quantidade: quantidade(0.5), Unidade: "xícara", Nome: "óleo"}, "1/2 xícara de óleo"},
+		{"três ovos", Ingrediente{Quantidade: quantidade(3), Nome: "ovos"}, "3 ovos"},
+		{"- 4 ovos", Ingrediente{Quantidade: quantidade(4), Nome: "ovos"}, "4 ovos"},
+		{"2 a 3 tomates", Ingrediente{Quantidade: quantidade(2), QuantidadeMax: quantidade(3), Nome: "tomates"}, ""},
+		{"2-3 dentes de alho", Ingrediente{Quantidade: quantidade(2), QuantidadeMax: quantidade(3), Unidade: "dente", Nome: "alho"}, "2 a 3 dentes de alho"},
+		{"1 ou 2 pimentas", Ingrediente{Quantidade: quantidade(1), QuantidadeMax: quantidade(2), Nome: "pimentas"}, "1 a 2 pimentas"},
+		{"sal a gosto", Ingrediente{Nome: "sal", Observacao: "a gosto"}, ""},
+		{"pimenta-do-reino q.b.", Ingrediente{Nome: "pimenta-do-reino", Observacao: "q.b."}, ""},
+		{"manteiga (para untar)", Ingrediente{Nome: "manteiga", Observacao: "para untar"}, "manteiga, para untar"},
+		{"1 lata de creme de leite", Ingrediente{Quantidade: quantidade(1), Unidade: "lata", Nome: "creme de leite"}, ""},
+		// A unidade nao pode ser o nome inteiro
+		{"2 folhas", Ingrediente{Quantidade: quantidade(2), Nome: "folhas"}, ""},
+		// Faixa decrescente nao e faixa
+		{"3 a 2 cebolas", Ingrediente{Quantidade: quantidade(3), Nome: "a 2 cebolas"}, ""},
+	}
+	for _, caso := range casos {
+		t.Run(caso.texto, func(t *testing.T) {
+			ing := ParseIngrediente(caso.texto)
+			if !mesmaQuantidade(ing.Quantidade, caso.esperado.Quantidade) || !mesmaQuantidade(ing.QuantidadeMax, caso.esperado.QuantidadeMax) ||
+				ing.Unidade != caso.esperado.Unidade || ing.Nome != caso.esperado.Nome || ing.Observacao != caso.esperado.Observacao {
+				t.Fatalf("ParseIngrediente(%q) = %s, esperado %s", caso.texto, descrever(ing), descrever(caso.esperado))
+			}
+			exibicao := caso.exibicao
+			if exibicao == "" {
+				exibicao = caso.texto
+			}
+			if texto := ing.String(); texto != exibicao {
+				t.Fatalf("String() = %q, esperado %q", texto, exibicao)
+			}
+			// O texto exibido volta ao mesmo ingrediente
+			if relido := ParseIngrediente(ing.String()); descrever(relido) != descrever(ing) {
+				t.Fatalf("releitura de %q = %s, esperado %s", ing.String(), descrever(relido), descrever(ing))
+			}
+		})
+	}
+}
+
+func TestQuantidadeValida(t *testing.T) {
+	casos := []struct {
+		texto  string
+		valida bool
+	}{
+		{"2 ovos", true},
+		{"2 a 3 ovos", true},
+		{"sal a gosto", true},
+		{"0 ovos", false},
+		{"0,0 kg de farinha", false},
+		{"0/2 ovos", false},
+		{"0,00001 g de sal", false}, // Arredonda para zero
+	}
+	for _, caso := range casos {
+		if valida := ParseIngrediente(caso.texto).quantidadeValida(); valida != caso.valida {
+			t.Errorf("%q: quantidadeValida = %v, esperado %v", caso.texto, valida, caso.valida)
+		}
+	}
+
+	if (Ingrediente{Nome: "ovos", QuantidadeMax: quantidade(2)}).quantidadeValida() {
+		t.Error("faixa sem inicio aceita")
+	}
+	if (Ingrediente{Nome: "ovos", Quantidade: quantidade(-1)}).quantidadeValida() {
+		t.Error("quantidade negativa aceita")
+	}
+}
+
+func descrever(ing Ingrediente) string {
+	texto := func(q *float64) string {
+		if q == nil {
+			return "-"
+		}
+		return FormatarQuantidade(*q, false)
+	}
+	return "{" + texto(ing.Quantidade) + " " + texto(ing.QuantidadeMax) + " " + ing.Unidade + " | " + ing.Nome + " | " + ing.Observacao + "}"
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+)
+
+// Passo do modo de preparo
+type Passo struct {
+	Texto              string   `json:"texto"`
+	DuracaoSegundos    *int     `json:"duracao_segundos,omitempty"` // Timer do passo
+	Temperatura        *float64 `json:"temperatura,omitempty"`
+	UnidadeTemperatura string   `json:"unidade_temperatura,omitempty" enums:"°C,°F"` // °C quando omitida
+	Ingredientes       []int    `json:"ingredientes,omitempty"`                      // Posicoes (a partir de 0) em ingredientes_estruturados
+	Imagem             string   `json:"imagem,omitempty"`                            // URL http(s) ou caminho servido pela API
+	Foto               *Imagem  `json:"foto,omitempty"`                              // Enviada em /receitas/{id}/passos/{passo}/imagem, ignorada na entrada
+}
+
+const tamanhoMaximoImagem = 2048
+
+var (
+	// "1.", "2)", "3 -", "Passo 4:" ou marcadores no inicio da linha
+	reNumeracaoPasso = regexp.MustCompile(`(?i)^\s*(?:(?:passo\s+)?\d+\s*[.):\-–]|[-*•])\s+`)
+	reLinhaEmBranco  = regexp.MustCompile(`\n\s*\n`)
+	// "40 minutos", "1 hora", "30 a 40 min"; em faixas vale o maior valor
+	reDuracao = regexp.MustCompile(`(?i)\b(\d+)(?:\s*(?:a|-|–)\s*(\d+))?\s*(segundos?|seg|minutos?|min|horas?|h)\b`)
+)
+
+// Divide um texto livre em passos: cada linha numerada (ou com marcador) comeca
+// um passo; sem numeracao, cada paragrafo e um passo. Duracao e temperatura
+// mencionadas no texto viram o timer e a temperatura do passo.
+func DividirInstrucoes(texto string) []Passo {
+	texto = strings.ReplaceAll(texto, "\r\n", "\n")
+
+	var blocos []string
+	linhas := strings.Split(texto, "\n")
+	numeradas := 0
+	for _, linha := range linhas {
+		if reNumeracaoPasso.MatchString(linha) {
+			numeradas++
+		}
+	}
+
+	if numeradas > 0 {
+		var atual []string
+		for _, linha := range linhas {
+			if reNumeracaoPasso.MatchString(linha) {
+				blocos = append(blocos, strings.Join(atual, " "))
+				atual = []string{reNumeracaoPasso.ReplaceAllString(linha, "")}
+				continue
+			}
+			atual = append(atual, linha)
+		}
+		blocos = append(blocos, strings.Join(atual, " "))
+	} else {
+		blocos = reLinhaEmBranco.Split(texto, -1)
+	}
+
+	passos := []Passo{}
+	for _, bloco := range blocos {
+		if bloco = strings.Join(strings.Fields(bloco), " "); bloco != "" {
+			passos = append(passos, novoPasso(bloco))
+		}
+	}
+	return passos
+}
+
+// Passo a partir do texto, com o timer e a temperatura que ele mencionar
+func novoPasso(texto string) Passo {
+	passo := Passo{Texto: texto}
+	if m := reDuracao.FindStringSubmatch(texto); m != nil {
+		valor, _ := strconv.Atoi(m[1])
+		if maximo, err := strconv.Atoi(m[2]); err == nil && maximo > valor {
+			valor = maximo
+		}
+		unidade := strings.ToLower(m[3])
+		switch {
+		case strings.HasPrefix(unidade, "h"):
+			valor *= 3600
+		case strings.HasPrefix(unidade, "min"):
+			valor *= 60
+		}
+		if valor > 0 && valor <= TempoMaximo*60 {
+			passo.DuracaoSegundos = &valor
+		}
+	}
+	if valor, u, ok := units.PrimeiraTemperatura(texto); ok {
+		passo.Temperatura = &valor
+		passo.UnidadeTemperatura = u.Nome
+	}
+	return passo
+}
+
+// Texto corrido dos passos, para os clientes que leem "instrucoes"
+func RenderizarPassos(passos []Passo) string {
+	if len(passos) == 1 {
+		return passos[0].Texto
+	}
+	linhas := make([]string, len(passos))
+	for i, passo := range passos {
+		linhas[i] = strconv.Itoa(i+1) + ". " + passo.Texto
+	}
+	return strings.Join(linhas, "\n")
+}
+
+// Deixa passos e instrucoes consistentes, como PrepararIngredientes: a lista de
+// passos tem prioridade; sem ela o texto de "instrucoes" e dividido em passos.
+// Chame depois de PrepararIngredientes, que define as posicoes validas dos ingredientes.
+func (receita *Receita) PrepararPassos() error {
+	for i := range receita.Passos {
+		passo := &receita.Passos[i]
+		passo.Texto = strings.TrimSpace(passo.Texto)
+		passo.Foto = nil
+		if passo.Texto == "" {
+			return ErroCampo(fmt.Sprintf("passos[%d].texto", i), "passo %d sem texto", i+1)
+		}
+		if passo.DuracaoSegundos != nil && (*passo.DuracaoSegundos <= 0 || *passo.DuracaoSegundos > TempoMaximo*60) {
+			return ErroCampo(fmt.Sprintf("passos[%d].duracao_segundos", i), "passo %d com duração inválida", i+1)
+		}
+
+		if passo.Temperatura == nil {
+			passo.UnidadeTemperatura = ""
+		} else {
+			if passo.UnidadeTemperatura == "" {
+				passo.UnidadeTemperatura = "°C"
+			}
+			u, ok := units.Buscar(passo.UnidadeTemperatura)
+			if !ok || u.Dimensao != units.Temperatura {
+				return ErroCampo(fmt.Sprintf("passos[%d].unidade_temperatura", i), "passo %d com unidade de temperatura inválida: %q", i+1, passo.UnidadeTemperatura)
+			}
+			passo.UnidadeTemperatura = u.Nome
+		}
+
+		for _, indice := range passo.Ingredientes {
+			if indice < 0 || indice >= len(receita.IngredientesEstruturados) {
+				return ErroCampo(fmt.Sprintf("passos[%d].ingredientes", i), "passo %d referencia o ingrediente %d, que não existe", i+1, indice)
+			}
+		}
+
+		if passo.Imagem != "" {
+			endereco, err := url.Parse(passo.Imagem)
+			valida := err == nil && len(passo.Imagem) <= tamanhoMaximoImagem &&
+				((endereco.Scheme == "http" || endereco.Scheme == "https") && endereco.Host != "" ||
+					endereco.Scheme == "" && endereco.Host == "" && strings.HasPrefix(endereco.Path, "/"))
+			if !valida {
+				return ErroCampo(fmt.Sprintf("passos[%d].imagem", i), "passo %d com imagem inválida: use uma URL http(s)", i+1)
+			}
+		}
+	}
+
+	if len(receita.Passos) == 0 {
+		receita.Passos = DividirInstrucoes(receita.Instrucoes)
+	}
+	receita.Instrucoes = RenderizarPassos(receita.Passos)
+	return nil
+}
+
+// Converte a temperatura do passo e as mencionadas no texto para o sistema pedido
+func (passo Passo) ParaSistema(sistema units.Sistema) Passo {
+	passo.Texto = units.ConverterTemperaturas(passo.Texto, sistema)
+	if passo.Temperatura == nil {
+		return passo
+	}
+	u, ok := units.Buscar(passo.UnidadeTemperatura)
+	if !ok {
+		return passo
+	}
+	valor, destino := units.Padrao.ParaSistema(*passo.Temperatura, u, sistema, "")
+	if destino != u {
+		convertida := units.Arredondar(valor, destino)
+		passo.Temperatura = &convertida
+		passo.UnidadeTemperatura = destino.Nome
+	}
+	return passo
+}
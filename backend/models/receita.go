@@ -1,24 +1,85 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Receita struct {
-	ID           uuid.UUID `json:"id"`
-	Nome         string    `json:"nome"`
-	Descricao    string    `json:"descricao"`
-	Ingredientes []string  `json:"ingredientes"`
-	Instrucoes   string    `json:"instrucoes"`
+	ID                       uuid.UUID          `json:"id"`
+	Nome                     string             `json:"nome"`
+	Descricao                string             `json:"descricao"`
+	Ingredientes             []string           `json:"ingredientes"` // Texto de cada ingrediente, gerado a partir da forma estruturada
+	IngredientesEstruturados []Ingrediente      `json:"ingredientes_estruturados"`
+	Instrucoes               string             `json:"instrucoes"` // Texto gerado a partir dos passos
+	Passos                   []Passo            `json:"passos"`
+	Capa                     *Imagem            `json:"capa,omitempty"`            // Enviada em /receitas/{id}/capa, ignorada na entrada
+	Tags                     []string           `json:"tags"`                      // Slugs das tags
+	Categoria                string             `json:"categoria,omitempty"`       // Slug da categoria
+	Cozinha                  string             `json:"cozinha,omitempty"`         // Slug da cozinha
+	TempoPreparo             *int               `json:"tempo_preparo,omitempty"`   // Minutos
+	TempoCozimento           *int               `json:"tempo_cozimento,omitempty"` // Minutos
+	TempoDescanso            *int               `json:"tempo_descanso,omitempty"`  // Minutos
+	TempoTotal               *int               `json:"tempo_total,omitempty"`     // Minutos; sem valor, soma dos demais
+	Dificuldade              string             `json:"dificuldade,omitempty" enums:"facil,media,dificil"`
+	Porcoes                  int                `json:"porcoes,omitempty"`  // Quantas porcoes a receita rende, 0 se nao informado
+	AutorID                  *uuid.UUID         `json:"autor_id,omitempty"` // Preenchido pelo token, ignorado na entrada
+	CriadoEm                 time.Time          `json:"criado_em"`          // Definido pelo banco, ignorado na entrada
+	Busca                    *ResultadoBusca    `json:"busca,omitempty"`    // Apenas nas buscas com ?q=, ignorado na entrada
+	Despensa                 *ResultadoDespensa `json:"despensa,omitempty"` // Apenas nos filtros com ?tem=, ignorado na entrada
 }
 
-// Migration
-const (
-	TableName = "receitas"
-
-	CreateTableQuery = `CREATE TABLE IF NOT EXISTS receitas (
-		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-		nome TEXT NOT NULL,
-		descricao TEXT NOT NULL,
-		ingredientes TEXT[] NOT NULL,
-		instrucoes TEXT NOT NULL
-	)`
-)
+// Deixa os dois formatos de ingredientes consistentes. A lista estruturada
+// tem prioridade; sem ela cada linha de "ingredientes" e convertida pelo parser,
+// o que mantem compativeis os clientes que ainda enviam apenas texto. As
+// quantidades das duas formas precisam ser positivas: "0 ovos" e recusado.
+func (receita *Receita) PrepararIngredientes() error {
+	return receita.prepararIngredientes(false)
+}
+
+// Como PrepararIngredientes, para receitas lidas do banco. Receitas antigas
+// gravadas apenas como texto podem ter linhas que o parser le com quantidade
+// invalida; essas linhas viram so o nome, em vez de impedir a leitura.
+func (receita *Receita) PrepararIngredientesGravados() error {
+	return receita.prepararIngredientes(true)
+}
+
+func (receita *Receita) prepararIngredientes(gravados bool) error {
+	for i, ing := range receita.IngredientesEstruturados {
+		if strings.TrimSpace(ing.Nome) == "" {
+			return ErroCampo(fmt.Sprintf("ingredientes_estruturados[%d].nome", i), "ingrediente %d sem nome", i+1)
+		}
+		if !ing.quantidadeValida() {
+			return ErroCampo(fmt.Sprintf("ingredientes_estruturados[%d].quantidade", i), "ingrediente %d com quantidade inválida", i+1)
+		}
+	}
+
+	if len(receita.IngredientesEstruturados) == 0 {
+		receita.IngredientesEstruturados = make([]Ingrediente, 0, len(receita.Ingredientes))
+		for i, linha := range receita.Ingredientes {
+			if strings.TrimSpace(linha) == "" {
+				continue
+			}
+			ing := ParseIngrediente(linha)
+			if !ing.quantidadeValida() {
+				if !gravados {
+					receita.IngredientesEstruturados = nil
+					return ErroCampo(fmt.Sprintf("ingredientes[%d]", i), "ingrediente %d com quantidade inválida", i+1)
+				}
+				ing = Ingrediente{Nome: strings.TrimSpace(linha)}
+			}
+			receita.IngredientesEstruturados = append(receita.IngredientesEstruturados, ing)
+		}
+	}
+
+	receita.Ingredientes = make([]string, len(receita.IngredientesEstruturados))
+	for i, ing := range receita.IngredientesEstruturados {
+		receita.Ingredientes[i] = ing.String()
+	}
+	return nil
+}
+
+const TableName = "receitas"
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPrepararIngredientes(t *testing.T) {
+	receita := Receita{Ingredientes: []string{"2 xícaras de farinha", "", "sal a gosto"}}
+	if err := receita.PrepararIngredientes(); err != nil {
+		t.Fatal(err)
+	}
+	if len(receita.IngredientesEstruturados) != 2 || receita.Ingredientes[1] != "sal a gosto" {
+		t.Fatalf("ingredientes = %q, estruturados = %+v", receita.Ingredientes, receita.IngredientesEstruturados)
+	}
+
+	casos := []struct {
+		nome    string
+		receita Receita
+		campo   string
+	}{
+		{"texto com zero", Receita{Ingredientes: []string{"3 ovos", "0 ovos"}}, "ingredientes[1]"},
+		{"texto com fracao zero", Receita{Ingredientes: []string{"0/3 xícara de leite"}}, "ingredientes[0]"},
+		{"estruturado com zero", Receita{IngredientesEstruturados: []Ingrediente{{Nome: "ovos", Quantidade: quantidade(0)}}}, "ingredientes_estruturados[0].quantidade"},
+		{"estruturado negativo", Receita{IngredientesEstruturados: []Ingrediente{{Nome: "ovos", Quantidade: quantidade(-2)}}}, "ingredientes_estruturados[0].quantidade"},
+		{"faixa sem inicio", Receita{IngredientesEstruturados: []Ingrediente{{Nome: "ovos", QuantidadeMax: quantidade(2)}}}, "ingredientes_estruturados[0].quantidade"},
+		{"estruturado sem nome", Receita{IngredientesEstruturados: []Ingrediente{{Quantidade: quantidade(2)}}}, "ingredientes_estruturados[0].nome"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			var invalido *ErroValidacao
+			if err := caso.receita.PrepararIngredientes(); !errors.As(err, &invalido) || invalido.Campo != caso.campo {
+				t.Fatalf("erro = %v, esperado no campo %q", err, caso.campo)
+			}
+		})
+	}
+}
+
+func TestPrepararIngredientesGravados(t *testing.T) {
+	// Receitas antigas, gravadas so como texto, continuam legiveis
+	receita := Receita{Ingredientes: []string{"0 ovos", "2 tomates"}}
+	if err := receita.PrepararIngredientesGravados(); err != nil {
+		t.Fatal(err)
+	}
+	if ing := receita.IngredientesEstruturados[0]; ing.Quantidade != nil || ing.Nome != "0 ovos" {
+		t.Fatalf("linha invalida = %+v, esperado apenas o nome", ing)
+	}
+	if ing := receita.IngredientesEstruturados[1]; ing.Quantidade == nil || *ing.Quantidade != 2 {
+		t.Fatalf("linha valida = %+v", ing)
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+)
+
+// Niveis de dificuldade aceitos
+const (
+	DificuldadeFacil   = "facil"
+	DificuldadeMedia   = "media"
+	DificuldadeDificil = "dificil"
+)
+
+// Limite de cada tempo, em minutos (30 dias cobrem fermentacoes e curas longas)
+const TempoMaximo = 30 * 24 * 60
+
+// Normaliza "Fácil" ou "MÉDIA" para o valor gravado
+func ParseDificuldade(texto string) (string, error) {
+	switch d := units.Normalizar(texto); d {
+	case DificuldadeFacil, DificuldadeMedia, DificuldadeDificil:
+		return d, nil
+	}
+	return "", ErroCampo("dificuldade", "dificuldade inválida: %q (use facil, media ou dificil)", texto)
+}
+
+// Valida os tempos e a dificuldade. Sem tempo total, ele vira a soma dos
+// outros tempos informados; informado, nao pode ser menor que preparo + cozimento.
+func (receita *Receita) PrepararTempos() error {
+	tempos := []struct {
+		nome  string
+		valor *int
+	}{
+		{"tempo_preparo", receita.TempoPreparo},
+		{"tempo_cozimento", receita.TempoCozimento},
+		{"tempo_descanso", receita.TempoDescanso},
+		{"tempo_total", receita.TempoTotal},
+	}
+	for _, t := range tempos {
+		if t.valor != nil && (*t.valor < 0 || *t.valor > TempoMaximo) {
+			return ErroCampo(t.nome, "%s deve ficar entre 0 e %d minutos", t.nome, TempoMaximo)
+		}
+	}
+
+	soma, informados := 0, 0
+	for _, t := range tempos[:3] {
+		if t.valor != nil {
+			soma += *t.valor
+			informados++
+		}
+	}
+	if receita.TempoTotal == nil && informados > 0 {
+		total := min(soma, TempoMaximo)
+		receita.TempoTotal = &total
+	}
+	if receita.TempoTotal != nil && *receita.TempoTotal < valorOuZero(receita.TempoPreparo)+valorOuZero(receita.TempoCozimento) {
+		return ErroCampo("tempo_total", "tempo_total não pode ser menor que tempo_preparo + tempo_cozimento")
+	}
+
+	if strings.TrimSpace(receita.Dificuldade) != "" {
+		dificuldade, err := ParseDificuldade(receita.Dificuldade)
+		if err != nil {
+			return err
+		}
+		receita.Dificuldade = dificuldade
+	} else {
+		receita.Dificuldade = ""
+	}
+	return nil
+}
+
+func valorOuZero(valor *int) int {
+	if valor == nil {
+		return 0
+	}
+	return *valor
+}
@@ -1,9 +1,44 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
-	ID       uuid.UUID `json:"id"`
-	Username string    `json:"username"`
-	Password string    `json:"password"`
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	Password     string    `json:"password,omitempty"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	CriadoEm     time.Time `json:"criado_em"`
+}
+
+// Papeis de usuario, do maior para o menor privilegio.
+// admin altera e remove qualquer receita e gerencia usuarios,
+// editor cria e altera as proprias receitas, reader apenas consulta.
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleReader = "reader"
+)
+
+// Nivel de cada papel, usado para comparar privilegios
+var roleLevels = map[string]int{
+	RoleReader: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// Indica se o papel informado existe
+func RoleValida(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// Indica se role tem pelo menos os privilegios de required
+func RoleAtLeast(role, required string) bool {
+	level, ok := roleLevels[role]
+	return ok && level >= roleLevels[required]
 }
@@ -0,0 +1,19 @@
+package models
+
+import "fmt"
+
+// Valor invalido em um campo da entrada. A mensagem e escrita para o cliente
+// e vai na resposta junto com o campo.
+type ErroValidacao struct {
+	Campo    string // Caminho no JSON (passos[0].texto) ou nome do parametro da URL
+	Mensagem string
+}
+
+func (err *ErroValidacao) Error() string {
+	return err.Mensagem
+}
+
+// Cria um ErroValidacao com a mensagem formatada
+func ErroCampo(campo, formato string, args ...any) error {
+	return &ErroValidacao{Campo: campo, Mensagem: fmt.Sprintf(formato, args...)}
+}
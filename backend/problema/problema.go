@@ -0,0 +1,132 @@
+// Package problema escreve as respostas de erro da API no formato
+// application/problem+json (RFC 7807). Cada erro tem um codigo estavel, que
+// define o status e o titulo; o detalhe e escrito para o cliente e nunca traz
+// mensagens internas (do banco, do decoder JSON). O request_id e o trace_id
+// permitem achar a requisicao nos logs e no trace.
+package problema
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Content-Type das respostas de erro
+const ContentType = "application/problem+json"
+
+// Prefixo do campo type; o codigo completa o URI
+const prefixoTipo = "urn:receitas:problema:"
+
+// Codigo do erro, estavel entre versoes para os clientes poderem tratar cada caso
+type Codigo string
+
+const (
+	CodigoRequisicaoInvalida   Codigo = "requisicao_invalida"   // JSON malformado, ID ou parametro invalido
+	CodigoValidacao            Codigo = "validacao"             // Campos com valores invalidos, listados em erros
+	CodigoNaoAutenticado       Codigo = "nao_autenticado"       // Token ausente, invalido, expirado ou de sessao encerrada
+	CodigoCredenciaisInvalidas Codigo = "credenciais_invalidas" // Usuario ou senha errados no login
+	CodigoRefreshInvalido      Codigo = "refresh_invalido"      // Refresh token invalido, expirado ou ja usado
+	CodigoSemPermissao         Codigo = "sem_permissao"         // Papel insuficiente ou receita de outro autor
+	CodigoNaoEncontrado        Codigo = "nao_encontrado"        // Recurso ou rota inexistente
+	CodigoMetodoNaoPermitido   Codigo = "metodo_nao_permitido"  // Rota existe, mas nao com este metodo
+	CodigoConflito             Codigo = "conflito"              // Valor unico ja usado ou alteracao concorrente
+	CodigoMuitoGrande          Codigo = "muito_grande"          // Corpo ou imagem acima do limite
+	CodigoTipoNaoSuportado     Codigo = "tipo_nao_suportado"    // Formato de imagem nao aceito
+	CodigoErroInterno          Codigo = "erro_interno"          // Falha do servidor; o detalhe fica so no log
+)
+
+type definicao struct {
+	status int
+	titulo string
+}
+
+var definicoes = map[Codigo]definicao{
+	CodigoRequisicaoInvalida:   {http.StatusBadRequest, "Requisição inválida"},
+	CodigoValidacao:            {http.StatusBadRequest, "Dados inválidos"},
+	CodigoNaoAutenticado:       {http.StatusUnauthorized, "Não autenticado"},
+	CodigoCredenciaisInvalidas: {http.StatusUnauthorized, "Credenciais inválidas"},
+	CodigoRefreshInvalido:      {http.StatusUnauthorized, "Refresh token inválido"},
+	CodigoSemPermissao:         {http.StatusForbidden, "Sem permissão"},
+	CodigoNaoEncontrado:        {http.StatusNotFound, "Não encontrado"},
+	CodigoMetodoNaoPermitido:   {http.StatusMethodNotAllowed, "Método não permitido"},
+	CodigoConflito:             {http.StatusConflict, "Conflito"},
+	CodigoMuitoGrande:          {http.StatusRequestEntityTooLarge, "Conteúdo muito grande"},
+	CodigoTipoNaoSuportado:     {http.StatusUnsupportedMediaType, "Tipo de mídia não suportado"},
+	CodigoErroInterno:          {http.StatusInternalServerError, "Erro interno do servidor"},
+}
+
+// Corpo das respostas de erro
+type Problema struct {
+	Type      string  `json:"type" example:"urn:receitas:problema:validacao"`
+	Title     string  `json:"title" example:"Dados inválidos"`
+	Status    int     `json:"status" example:"400"`
+	Detail    string  `json:"detail,omitempty" example:"passo 1 sem texto"`
+	Instance  string  `json:"instance,omitempty" example:"/api/receitas"`
+	Codigo    Codigo  `json:"codigo" example:"validacao"`
+	RequestID string  `json:"request_id,omitempty" example:"3f1c9a0e-6f0b-4c1e-9a57-2b8f0f7d9e11"`
+	TraceID   string  `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	Erros     []Campo `json:"erros,omitempty"`
+}
+
+// Erro de um campo, nas respostas com codigo validacao
+type Campo struct {
+	Campo    string `json:"campo" example:"passos[0].texto"`
+	Mensagem string `json:"mensagem" example:"passo 1 sem texto"`
+}
+
+// Escreve o erro com o status e o titulo do codigo
+func Escrever(w http.ResponseWriter, r *http.Request, codigo Codigo, detalhe string) {
+	escrever(w, novo(r, codigo, detalhe))
+}
+
+// Escreve um erro de validacao com o detalhe de cada campo
+func Validacao(w http.ResponseWriter, r *http.Request, detalhe string, campos ...Campo) {
+	problema := novo(r, CodigoValidacao, detalhe)
+	problema.Erros = campos
+	escrever(w, problema)
+}
+
+// Escreve o erro 500 generico; a causa deve ir para o log antes
+func Interno(w http.ResponseWriter, r *http.Request) {
+	Escrever(w, r, CodigoErroInterno, "")
+}
+
+// Handler das rotas inexistentes, para o router
+func RotaNaoEncontrada(w http.ResponseWriter, r *http.Request) {
+	Escrever(w, r, CodigoNaoEncontrado, "Rota não encontrada")
+}
+
+// Handler dos metodos nao aceitos em uma rota existente, para o router
+func MetodoNaoPermitido(w http.ResponseWriter, r *http.Request) {
+	Escrever(w, r, CodigoMetodoNaoPermitido, "Método "+r.Method+" não aceito nesta rota")
+}
+
+func novo(r *http.Request, codigo Codigo, detalhe string) *Problema {
+	def, ok := definicoes[codigo]
+	if !ok {
+		codigo, def = CodigoErroInterno, definicoes[CodigoErroInterno]
+	}
+	problema := &Problema{
+		Type:      prefixoTipo + string(codigo),
+		Title:     def.titulo,
+		Status:    def.status,
+		Detail:    detalhe,
+		Instance:  r.URL.Path,
+		Codigo:    codigo,
+		RequestID: logging.RequestID(r.Context()),
+	}
+	if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+		problema.TraceID = span.TraceID().String()
+	}
+	return problema
+}
+
+func escrever(w http.ResponseWriter, problema *Problema) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(problema.Status)
+	json.NewEncoder(w).Encode(problema)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+)
+
+const usoPromote = `uso: promote <usuario>
+  torna admin um usuário já cadastrado`
+
+// Subcomando "promote". O /register so cria readers; e assim que o primeiro
+// admin e criado, por quem tem acesso ao servidor e ao banco.
+func executarPromote(ctx context.Context, db *sql.DB, args []string, saida io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(usoPromote)
+	}
+
+	var user models.User
+	query := `UPDATE users SET role = $1 WHERE username = $2 RETURNING id, username, role`
+	err := db.QueryRowContext(ctx, query, models.RoleAdmin, args[0]).Scan(&user.ID, &user.Username, &user.Role)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("usuário %q não encontrado", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(saida, "%s agora é %s\n", user.Username, user.Role)
+	return nil
+}
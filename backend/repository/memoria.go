@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/google/uuid"
+)
+
+// Receitas em memoria, perdidas ao reiniciar. Serve para testes e demonstracoes
+// sem banco. Diferencas em relacao ao Postgres: tags, categorias e cozinhas nao
+// sao conferidas (qualquer slug e aceito e o nome nas facetas e o proprio slug)
+// e a busca textual compara palavras normalizadas em vez de usar stemming.
+type Memoria struct {
+	mu       sync.RWMutex
+	receitas map[uuid.UUID]models.Receita
+	// Imagens por receita, indexadas pelo passo; -1 e a capa
+	imagens map[uuid.UUID]map[int]models.Imagem
+}
+
+// Construtor de Memoria, vazia
+func NewMemoria() *Memoria {
+	return &Memoria{receitas: map[uuid.UUID]models.Receita{}, imagens: map[uuid.UUID]map[int]models.Imagem{}}
+}
+
+const posicaoCapa = -1
+
+func posicaoImagem(passo *int) int {
+	if passo == nil {
+		return posicaoCapa
+	}
+	return *passo
+}
+
+// Copia as listas da receita, para que quem a recebe possa altera-la sem mexer no que esta guardado
+func copiarReceita(receita models.Receita) models.Receita {
+	receita.Ingredientes = slices.Clone(receita.Ingredientes)
+	receita.IngredientesEstruturados = slices.Clone(receita.IngredientesEstruturados)
+	receita.Passos = slices.Clone(receita.Passos)
+	receita.Tags = slices.Clone(receita.Tags)
+	return receita
+}
+
+// Copia da receita guardada com as imagens anexadas. Chame com o lock.
+func (memoria *Memoria) ler(id uuid.UUID) (models.Receita, bool) {
+	guardada, ok := memoria.receitas[id]
+	if !ok {
+		return models.Receita{}, false
+	}
+	receita := copiarReceita(guardada)
+	receita.AnexarImagens(memoria.listaImagens(id))
+	return receita, true
+}
+
+// Imagens da receita. Chame com o lock.
+func (memoria *Memoria) listaImagens(id uuid.UUID) []models.Imagem {
+	var lista []models.Imagem
+	for _, imagem := range memoria.imagens[id] {
+		lista = append(lista, imagem)
+	}
+	return lista
+}
+
+// Copia da receita sem os campos calculados na leitura, como fica guardada
+func paraGuardar(receita models.Receita) models.Receita {
+	receita = copiarReceita(receita)
+	receita.Busca, receita.Despensa, receita.Capa = nil, nil, nil
+	for i := range receita.Passos {
+		receita.Passos[i].Foto = nil
+	}
+	if receita.Tags == nil {
+		receita.Tags = []string{}
+	}
+	return receita
+}
+
+func (memoria *Memoria) Obter(ctx context.Context, id uuid.UUID) (models.Receita, error) {
+	memoria.mu.RLock()
+	defer memoria.mu.RUnlock()
+	receita, ok := memoria.ler(id)
+	if !ok {
+		return receita, ErrNaoEncontrada
+	}
+	return receita, nil
+}
+
+func (memoria *Memoria) Autor(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+	memoria.mu.RLock()
+	defer memoria.mu.RUnlock()
+	receita, ok := memoria.receitas[id]
+	if !ok {
+		return nil, ErrNaoEncontrada
+	}
+	return receita.AutorID, nil
+}
+
+func (memoria *Memoria) Criar(ctx context.Context, receita *models.Receita) error {
+	memoria.mu.Lock()
+	defer memoria.mu.Unlock()
+	receita.ID = uuid.New()
+	receita.CriadoEm = time.Now().UTC()
+	memoria.receitas[receita.ID] = paraGuardar(*receita)
+	return nil
+}
+
+func (memoria *Memoria) Atualizar(ctx context.Context, receita *models.Receita) ([]models.Imagem, error) {
+	memoria.mu.Lock()
+	defer memoria.mu.Unlock()
+	anterior, ok := memoria.receitas[receita.ID]
+	if !ok {
+		return nil, ErrNaoEncontrada
+	}
+	receita.CriadoEm = anterior.CriadoEm
+	guardada := paraGuardar(*receita)
+	guardada.AutorID = anterior.AutorID
+	memoria.receitas[receita.ID] = guardada
+
+	// As fotos ficam com a posicao do passo; as de passos que sairam sao removidas
+	var removidas []models.Imagem
+	for posicao, imagem := range memoria.imagens[receita.ID] {
+		if posicao >= len(receita.Passos) {
+			removidas = append(removidas, imagem)
+			delete(memoria.imagens[receita.ID], posicao)
+		}
+	}
+	receita.AnexarImagens(memoria.listaImagens(receita.ID))
+	return removidas, nil
+}
+
+func (memoria *Memoria) Remover(ctx context.Context, id uuid.UUID) ([]models.Imagem, error) {
+	memoria.mu.Lock()
+	defer memoria.mu.Unlock()
+	if _, ok := memoria.receitas[id]; !ok {
+		return nil, ErrNaoEncontrada
+	}
+	removidas := memoria.listaImagens(id)
+	delete(memoria.receitas, id)
+	delete(memoria.imagens, id)
+	return removidas, nil
+}
+
+func (memoria *Memoria) SalvarImagem(ctx context.Context, receitaID uuid.UUID, imagem models.Imagem, contentType string) ([]models.Imagem, error) {
+	memoria.mu.Lock()
+	defer memoria.mu.Unlock()
+	if _, ok := memoria.receitas[receitaID]; !ok {
+		return nil, ErrNaoEncontrada
+	}
+	if memoria.imagens[receitaID] == nil {
+		memoria.imagens[receitaID] = map[int]models.Imagem{}
+	}
+	posicao := posicaoImagem(imagem.Passo)
+	var antigas []models.Imagem
+	if antiga, ok := memoria.imagens[receitaID][posicao]; ok {
+		antigas = append(antigas, antiga)
+	}
+	memoria.imagens[receitaID][posicao] = imagem
+	return antigas, nil
+}
+
+func (memoria *Memoria) RemoverImagem(ctx context.Context, receitaID uuid.UUID, passo *int) ([]models.Imagem, error) {
+	memoria.mu.Lock()
+	defer memoria.mu.Unlock()
+	posicao := posicaoImagem(passo)
+	imagem, ok := memoria.imagens[receitaID][posicao]
+	if !ok {
+		return nil, nil
+	}
+	delete(memoria.imagens[receitaID], posicao)
+	return []models.Imagem{imagem}, nil
+}
@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"html"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+)
+
+// Pesos dos campos na relevancia, os mesmos de ts_rank_cd para A, B e C
+const (
+	pesoNome         = 1.0
+	pesoIngredientes = 0.4
+	pesoDescricao    = 0.4
+	pesoInstrucoes   = 0.2
+)
+
+// Palavras de contexto em volta do primeiro termo encontrado, no trecho da busca
+const palavrasTrecho = 10
+
+func (memoria *Memoria) Listar(ctx context.Context, consulta Consulta) (Pagina, error) {
+	return memoria.listar(consulta, nil)
+}
+
+// Aceita "frase exata", -excluir e OR, como o Postgres
+func (memoria *Memoria) Buscar(ctx context.Context, texto string, consulta Consulta) (Pagina, error) {
+	return memoria.listar(consulta, parseBusca(texto))
+}
+
+func (memoria *Memoria) listar(filtros Consulta, busca *buscaMemoria) (Pagina, error) {
+	memoria.mu.RLock()
+	receitas := make([]models.Receita, 0, len(memoria.receitas))
+	for id := range memoria.receitas {
+		receita, _ := memoria.ler(id)
+		receitas = append(receitas, receita)
+	}
+	memoria.mu.RUnlock()
+	return listarNaAplicacao(receitas, filtros, busca), nil
+}
+
+// Filtra, ordena e pagina receitas ja carregadas, com a busca e a despensa
+// calculadas em Go
+func listarNaAplicacao(receitas []models.Receita, filtros Consulta, busca *buscaMemoria) Pagina {
+	selecionadas := []models.Receita{}
+	for _, receita := range receitas {
+		if busca != nil {
+			resultado, ok := busca.conferir(receita)
+			if !ok {
+				continue
+			}
+			receita.Busca = &resultado
+		}
+		if !atende(&receita, filtros) {
+			continue
+		}
+		selecionadas = append(selecionadas, receita)
+	}
+
+	pagina := Pagina{Total: len(selecionadas)}
+	if filtros.Facetas {
+		pagina.Facetas = contarFacetas(selecionadas)
+	}
+
+	slices.SortFunc(selecionadas, func(a, b models.Receita) int {
+		return compararPosicao(filtros.Ordem, chaveOrdenacao(filtros.Ordem, a), a.ID.String(), chaveOrdenacao(filtros.Ordem, b), b.ID.String())
+	})
+	if filtros.Cursor != nil {
+		chave := chaveCursor(filtros.Cursor.Valor)
+		id := filtros.Cursor.ID.String()
+		inicio := 0
+		for inicio < len(selecionadas) &&
+			compararPosicao(filtros.Ordem, chaveOrdenacao(filtros.Ordem, selecionadas[inicio]), selecionadas[inicio].ID.String(), chave, id) <= 0 {
+			inicio++
+		}
+		selecionadas = selecionadas[inicio:]
+	}
+	pagina.Receitas = selecionadas[:min(len(selecionadas), filtros.Limite+1)]
+	paginar(&pagina, filtros)
+	return pagina
+}
+
+// Aplica os filtros da consulta e preenche Receita.Despensa
+func atende(receita *models.Receita, filtros Consulta) bool {
+	if len(filtros.Disponiveis) > 0 {
+		usados := 0
+		for _, nome := range receita.IngredientesNormalizados() {
+			if models.IngredienteCoberto(nome, filtros.Disponiveis) {
+				usados++
+			}
+		}
+		despensa := receita.ConferirDespensa(filtros.Disponiveis)
+		if usados == 0 || despensa.Faltando > filtros.FaltandoMax {
+			return false
+		}
+		receita.Despensa = &despensa
+	}
+	for _, tag := range filtros.Tags {
+		if !slices.Contains(receita.Tags, tag) {
+			return false
+		}
+	}
+	if filtros.Categoria != "" && receita.Categoria != filtros.Categoria {
+		return false
+	}
+	if filtros.Cozinha != "" && receita.Cozinha != filtros.Cozinha {
+		return false
+	}
+	// Como no SQL, receitas sem o tempo informado nao passam nos filtros de tempo
+	if filtros.TempoMin != nil && (receita.TempoTotal == nil || *receita.TempoTotal < *filtros.TempoMin) {
+		return false
+	}
+	if filtros.TempoMax != nil && (receita.TempoTotal == nil || *receita.TempoTotal > *filtros.TempoMax) {
+		return false
+	}
+	if filtros.PreparoMax != nil && (receita.TempoPreparo == nil || *receita.TempoPreparo > *filtros.PreparoMax) {
+		return false
+	}
+	if len(filtros.Dificuldades) > 0 && !slices.Contains(filtros.Dificuldades, receita.Dificuldade) {
+		return false
+	}
+	return true
+}
+
+// Valor comparavel do campo de ordenacao: texto para nome, numero para os demais
+type chaveMemoria struct {
+	texto  string
+	numero float64
+}
+
+func chaveOrdenacao(ordem Ordenacao, receita models.Receita) chaveMemoria {
+	return chaveCursor(ordem.Valor(receita))
+}
+
+// Converte o valor guardado no cursor (Ordenacao.Valor) em chave
+func chaveCursor(valor any) chaveMemoria {
+	switch valor := valor.(type) {
+	case string:
+		return chaveMemoria{texto: units.Normalizar(valor) + "\x00" + valor}
+	case time.Time:
+		return chaveMemoria{numero: float64(valor.UnixNano())}
+	case float64:
+		return chaveMemoria{numero: valor}
+	case int:
+		return chaveMemoria{numero: float64(valor)}
+	}
+	return chaveMemoria{}
+}
+
+// Compara duas posicoes (chave, id) na ordem pedida
+func compararPosicao(ordem Ordenacao, chaveA chaveMemoria, idA string, chaveB chaveMemoria, idB string) int {
+	resultado := cmp.Or(cmp.Compare(chaveA.texto, chaveB.texto), cmp.Compare(chaveA.numero, chaveB.numero), cmp.Compare(idA, idB))
+	if ordem.Desc {
+		return -resultado
+	}
+	return resultado
+}
+
+// Facetas sem as tabelas de classificacao: o nome e o slug
+func contarFacetas(receitas []models.Receita) models.Facetas {
+	tags, categorias, cozinhas := map[string]int{}, map[string]int{}, map[string]int{}
+	for _, receita := range receitas {
+		for _, tag := range receita.Tags {
+			tags[tag]++
+		}
+		if receita.Categoria != "" {
+			categorias[receita.Categoria]++
+		}
+		if receita.Cozinha != "" {
+			cozinhas[receita.Cozinha]++
+		}
+	}
+	return models.Facetas{Tags: listaFacetas(tags), Categorias: listaFacetas(categorias), Cozinhas: listaFacetas(cozinhas)}
+}
+
+func listaFacetas(contagem map[string]int) []models.Faceta {
+	facetas := []models.Faceta{}
+	for slug, total := range contagem {
+		facetas = append(facetas, models.Faceta{Slug: slug, Nome: slug, Total: total})
+	}
+	slices.SortFunc(facetas, func(a, b models.Faceta) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(a.Nome, b.Nome))
+	})
+	return facetas
+}
+
+// Busca ja interpretada: alternativas separadas por OR, cada uma com termos
+// (palavras ou frases) obrigatorios e excluidos, ja normalizados
+type buscaMemoria struct {
+	alternativas []alternativaBusca
+}
+
+type alternativaBusca struct {
+	termos    []string
+	excluidos []string
+}
+
+func parseBusca(texto string) *buscaMemoria {
+	busca := &buscaMemoria{}
+	atual := alternativaBusca{}
+	fechar := func() {
+		if len(atual.termos) > 0 {
+			busca.alternativas = append(busca.alternativas, atual)
+		}
+		atual = alternativaBusca{}
+	}
+
+	for texto = strings.TrimSpace(texto); texto != ""; texto = strings.TrimSpace(texto) {
+		excluir := strings.HasPrefix(texto, "-")
+		if excluir {
+			texto = texto[1:]
+		}
+		var termo string
+		if strings.HasPrefix(texto, `"`) {
+			fim := strings.Index(texto[1:], `"`)
+			if fim < 0 {
+				fim = len(texto) - 1
+			}
+			termo, texto = texto[1:fim+1], texto[min(fim+2, len(texto)):]
+		} else {
+			fim := strings.IndexAny(texto, " \t\n")
+			if fim < 0 {
+				fim = len(texto)
+			}
+			termo, texto = texto[:fim], texto[fim:]
+			if termo == "OR" && !excluir {
+				fechar()
+				continue
+			}
+		}
+		if termo = models.NomeNormalizado(termo); termo == "" {
+			continue
+		}
+		if excluir {
+			atual.excluidos = append(atual.excluidos, termo)
+		} else {
+			atual.termos = append(atual.termos, termo)
+		}
+	}
+	fechar()
+	return busca
+}
+
+// Indica se a receita casa com a busca e calcula a relevancia e os trechos marcados
+func (busca *buscaMemoria) conferir(receita models.Receita) (models.ResultadoBusca, bool) {
+	alternativa, relevancia, ok := busca.relevancia(receita.Nome, receita.Ingredientes, receita.Descricao, receita.Instrucoes)
+	if !ok {
+		return models.ResultadoBusca{}, false
+	}
+	return models.ResultadoBusca{
+		Relevancia: relevancia,
+		Nome:       marcar(receita.Nome, alternativa.termos, 0),
+		Trecho:     marcar(strings.Join([]string{receita.Descricao, strings.Join(receita.Ingredientes, ", "), receita.Instrucoes}, " "), alternativa.termos, palavrasTrecho),
+	}, true
+}
+
+// Primeira alternativa da busca que casa com os campos da receita, e a relevancia nela
+func (busca *buscaMemoria) relevancia(nome string, ingredientes []string, descricao, instrucoes string) (alternativaBusca, float64, bool) {
+	campos := []struct {
+		texto string
+		peso  float64
+	}{
+		{nome, pesoNome},
+		{strings.Join(ingredientes, ", "), pesoIngredientes},
+		{descricao, pesoDescricao},
+		{instrucoes, pesoInstrucoes},
+	}
+	normalizados := make([]string, len(campos))
+	for i, campo := range campos {
+		normalizados[i] = " " + models.NomeNormalizado(campo.texto) + " "
+	}
+	todo := strings.Join(normalizados, " ")
+
+	for _, alternativa := range busca.alternativas {
+		casou := true
+		for _, termo := range alternativa.termos {
+			casou = casou && strings.Contains(todo, " "+termo+" ")
+		}
+		for _, termo := range alternativa.excluidos {
+			casou = casou && !strings.Contains(todo, " "+termo+" ")
+		}
+		if !casou {
+			continue
+		}
+
+		relevancia := 0.0
+		for _, termo := range alternativa.termos {
+			for i, campo := range campos {
+				relevancia += float64(strings.Count(normalizados[i], " "+termo+" ")) * campo.peso
+			}
+		}
+		// Mesma precisao do real do Postgres, para o cursor voltar igual
+		return alternativa, float64(float32(relevancia)), true
+	}
+	return alternativaBusca{}, 0, false
+}
+
+// Escapa o HTML e envolve em <mark> as palavras dos termos. Com contexto > 0
+// retorna apenas as palavras em volta da primeira marcada.
+func marcar(texto string, termos []string, contexto int) string {
+	palavras := strings.Fields(texto)
+	marcadas := make([]bool, len(palavras))
+	primeira := -1
+	for _, termo := range termos {
+		quantas := len(strings.Fields(termo))
+		for i := 0; i+quantas <= len(palavras); i++ {
+			if models.NomeNormalizado(strings.Join(palavras[i:i+quantas], " ")) != termo {
+				continue
+			}
+			for j := i; j < i+quantas; j++ {
+				marcadas[j] = true
+			}
+			if primeira < 0 || i < primeira {
+				primeira = i
+			}
+		}
+	}
+
+	inicio, fim := 0, len(palavras)
+	if contexto > 0 {
+		inicio, fim = max(0, primeira-contexto), min(len(palavras), max(primeira, 0)+contexto)
+	}
+	saida := make([]string, 0, fim-inicio)
+	for i := inicio; i < fim; i++ {
+		palavra := html.EscapeString(palavras[i])
+		if marcadas[i] {
+			palavra = "<mark>" + palavra + "</mark>"
+		}
+		saida = append(saida, palavra)
+	}
+	return strings.Join(saida, " ")
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Receitas no PostgreSQL, com o esquema do pacote migrations
+type Postgres struct {
+	bancoSQL
+}
+
+// Construtor de Postgres
+func NewPostgres(dbConnection *sql.DB) *Postgres {
+	return &Postgres{bancoSQL{DBConnection: dbConnection}}
+}
+
+// Colunas lidas nas consultas de receitas, na ordem esperada por scanReceita
+const receitaColumns = `id, nome, descricao, ingredientes, ingredientes_estruturados, instrucoes, passos, porcoes, autor_id, criado_em, tempo_preparo, tempo_cozimento, tempo_descanso, tempo_total, dificuldade, ` + classificacaoColumns + `, ` + imagensColumn
+
+// Colunas de receitaColumns com os slugs de categoria, cozinha e tags
+const classificacaoColumns = `(SELECT slug FROM categorias WHERE id = receitas.categoria_id) AS categoria,
+	(SELECT slug FROM cozinhas WHERE id = receitas.cozinha_id) AS cozinha,
+	ARRAY(SELECT t.slug FROM receita_tags rt JOIN tags t ON t.id = rt.tag_id WHERE rt.receita_id = receitas.id ORDER BY t.slug) AS tags`
+
+// Coluna de receitaColumns com as imagens enviadas (capa e fotos dos passos)
+const imagensColumn = `(SELECT json_agg(json_build_object('id', id, 'passo', passo, 'chave', chave, 'extensao', extensao, 'largura', largura, 'altura', altura))
+	FROM imagens WHERE imagens.receita_id = receitas.id) AS imagens`
+
+// Le uma linha com as colunas de receitaColumns, seguidas das colunas em extras
+func scanReceita(row rowScanner, receita *models.Receita, extras ...any) error {
+	var autorID uuid.NullUUID
+	var estruturados, passos, imagens []byte
+	var porcoes sql.NullInt64
+	var categoria, cozinha, dificuldade sql.NullString
+	var preparo, cozimento, descanso, total sql.NullInt64
+	dest := []any{&receita.ID, &receita.Nome, &receita.Descricao, pq.Array(&receita.Ingredientes), &estruturados, &receita.Instrucoes, &passos, &porcoes, &autorID, &receita.CriadoEm,
+		&preparo, &cozimento, &descanso, &total, &dificuldade, &categoria, &cozinha, pq.Array(&receita.Tags), &imagens}
+	err := row.Scan(append(dest, extras...)...)
+	if err != nil {
+		return err
+	}
+	receita.TempoPreparo = intOuNil(preparo)
+	receita.TempoCozimento = intOuNil(cozimento)
+	receita.TempoDescanso = intOuNil(descanso)
+	receita.TempoTotal = intOuNil(total)
+	receita.Dificuldade = dificuldade.String
+	receita.Categoria = categoria.String
+	receita.Cozinha = cozinha.String
+	receita.Porcoes = int(porcoes.Int64)
+	if autorID.Valid {
+		receita.AutorID = &autorID.UUID
+	}
+	if estruturados != nil {
+		if err := json.Unmarshal(estruturados, &receita.IngredientesEstruturados); err != nil {
+			return err
+		}
+	}
+	if passos != nil {
+		if err := json.Unmarshal(passos, &receita.Passos); err != nil {
+			return err
+		}
+	}
+	if err := receita.PrepararIngredientesGravados(); err != nil {
+		return err
+	}
+	if err := receita.PrepararPassos(); err != nil {
+		return err
+	}
+	return anexarImagens(receita, imagens)
+}
+
+func (postgres *Postgres) Obter(ctx context.Context, id uuid.UUID) (models.Receita, error) {
+	var receita models.Receita
+	err := scanReceita(postgres.DBConnection.QueryRowContext(ctx, `SELECT `+receitaColumns+` FROM receitas WHERE id = $1`, id), &receita)
+	if err == sql.ErrNoRows {
+		return receita, ErrNaoEncontrada
+	}
+	return receita, err
+}
+
+func (postgres *Postgres) Criar(ctx context.Context, receita *models.Receita) error {
+	estruturados, passos, err := colunasJSON(*receita)
+	if err != nil {
+		return err
+	}
+
+	tx, err := postgres.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	categoriaID, cozinhaID, err := resolverCategoriaCozinha(ctx, tx, *receita)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO receitas (nome, descricao, ingredientes, ingredientes_estruturados, ingredientes_normalizados, instrucoes, passos, porcoes, autor_id, categoria_id, cozinha_id,
+		tempo_preparo, tempo_cozimento, tempo_descanso, tempo_total, dificuldade)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id, criado_em`
+	err = tx.QueryRowContext(ctx, query, receita.Nome, receita.Descricao, pq.Array(receita.Ingredientes), estruturados, pq.Array(receita.IngredientesNormalizados()), receita.Instrucoes, passos, nullPositivo(receita.Porcoes), nullUUID(receita.AutorID), categoriaID, cozinhaID,
+		nullInt(receita.TempoPreparo), nullInt(receita.TempoCozimento), nullInt(receita.TempoDescanso), nullInt(receita.TempoTotal), nullTexto(receita.Dificuldade)).Scan(&receita.ID, &receita.CriadoEm)
+	if err != nil {
+		return err
+	}
+	if err := gravarTags(ctx, tx, receita.ID, receita.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (postgres *Postgres) Atualizar(ctx context.Context, receita *models.Receita) ([]models.Imagem, error) {
+	estruturados, passos, err := colunasJSON(*receita)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := postgres.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	categoriaID, cozinhaID, err := resolverCategoriaCozinha(ctx, tx, *receita)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE receitas SET nome = $1, descricao = $2, ingredientes = $3, ingredientes_estruturados = $4, ingredientes_normalizados = $5, instrucoes = $6, passos = $7, porcoes = $8, categoria_id = $9, cozinha_id = $10,
+		tempo_preparo = $11, tempo_cozimento = $12, tempo_descanso = $13, tempo_total = $14, dificuldade = $15 WHERE id = $16 RETURNING criado_em`
+	err = tx.QueryRowContext(ctx, query, receita.Nome, receita.Descricao, pq.Array(receita.Ingredientes), estruturados, pq.Array(receita.IngredientesNormalizados()), receita.Instrucoes, passos, nullPositivo(receita.Porcoes), categoriaID, cozinhaID,
+		nullInt(receita.TempoPreparo), nullInt(receita.TempoCozimento), nullInt(receita.TempoDescanso), nullInt(receita.TempoTotal), nullTexto(receita.Dificuldade), receita.ID).Scan(&receita.CriadoEm)
+	if err == sql.ErrNoRows {
+		return nil, ErrNaoEncontrada
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := gravarTags(ctx, tx, receita.ID, receita.Tags); err != nil {
+		return nil, err
+	}
+	// As fotos ficam com a posicao do passo; as de passos que sairam sao removidas
+	removidas, err := lerImagensRemovidas(tx.QueryContext(ctx, `DELETE FROM imagens WHERE receita_id = $1 AND passo >= $2 RETURNING chave, extensao`, receita.ID, len(receita.Passos)))
+	if err != nil {
+		return nil, err
+	}
+	var imagens []byte
+	if err := tx.QueryRowContext(ctx, `SELECT `+imagensColumn+` FROM receitas WHERE id = $1`, receita.ID).Scan(&imagens); err != nil {
+		return nil, err
+	}
+	if err := anexarImagens(receita, imagens); err != nil {
+		return nil, err
+	}
+	return removidas, tx.Commit()
+}
+
+// Substitui as tags da receita; todas precisam existir
+func gravarTags(ctx context.Context, tx *sql.Tx, receitaID uuid.UUID, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM receita_tags WHERE receita_id = $1`, receitaID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	var faltando sql.NullString
+	err := tx.QueryRowContext(ctx, `SELECT min(slug) FROM unnest($1::text[]) AS slug WHERE slug NOT IN (SELECT slug FROM tags)`, pq.Array(tags)).Scan(&faltando)
+	if err != nil {
+		return err
+	}
+	if faltando.Valid {
+		return &models.ClassificacaoDesconhecidaError{Tipo: models.TipoTag, Slug: faltando.String}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO receita_tags (receita_id, tag_id) SELECT $1, id FROM tags WHERE slug = ANY($2)`, receitaID, pq.Array(tags))
+	return err
+}
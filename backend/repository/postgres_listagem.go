@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/lib/pq"
+)
+
+// Expressao SQL de cada campo de ordenacao e o tipo do valor guardado no cursor
+var colunasOrdenacao = map[string]struct{ expressao, tipo string }{
+	"nome":       {"nome", "text"},
+	"criado_em":  {"criado_em", "timestamptz"},
+	"relevancia": {"ts_rank_cd(busca, consulta)", "real"},
+	"faltando":   {"despensa.faltando", "bigint"},
+}
+
+// Clausula ORDER BY
+func ordemSQL(ordem Ordenacao) string {
+	direcao := "ASC"
+	if ordem.Desc {
+		direcao = "DESC"
+	}
+	return fmt.Sprintf("%s %s, id %s", colunasOrdenacao[ordem.Campo].expressao, direcao, direcao)
+}
+
+// Condicao que seleciona as receitas depois do cursor na ordem escolhida
+func depoisSQL(ordem Ordenacao, consulta *consultaSQL, cursor Cursor) string {
+	coluna := colunasOrdenacao[ordem.Campo]
+	operador := ">"
+	if ordem.Desc {
+		operador = "<"
+	}
+	return fmt.Sprintf("(%s, id) %s (%s::%s, %s)", coluna.expressao, operador, consulta.param(cursor.Valor), coluna.tipo, consulta.param(cursor.ID))
+}
+
+// Escapa o HTML do texto antes do ts_headline, ja que a resposta traz <mark>
+func escaparHTML(expressao string) string {
+	return `replace(replace(replace(` + expressao + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`
+}
+
+// Colunas extras da busca textual, lidas depois de receitaColumns: relevancia, nome e trecho marcados.
+// Exigem "consulta" (o tsquery) no FROM.
+var colunasBusca = `,
+		ts_rank_cd(busca, consulta) AS relevancia,
+		ts_headline('` + models.ConfiguracaoBusca + `', ` + escaparHTML("nome") + `, consulta,
+			'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+		ts_headline('` + models.ConfiguracaoBusca + `',
+			` + escaparHTML(`concat_ws(' ', descricao, array_to_string(ingredientes, ', '), instrucoes)`) + `, consulta,
+			'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=8, FragmentDelimiter=" … "')`
+
+func (postgres *Postgres) Listar(ctx context.Context, consulta Consulta) (Pagina, error) {
+	return postgres.listar(ctx, "", consulta)
+}
+
+// Aceita a sintaxe de busca na web: "frase exata", -excluir e OR
+func (postgres *Postgres) Buscar(ctx context.Context, texto string, consulta Consulta) (Pagina, error) {
+	return postgres.listar(ctx, texto, consulta)
+}
+
+func (postgres *Postgres) listar(ctx context.Context, busca string, filtros Consulta) (Pagina, error) {
+	var pagina Pagina
+	consulta := consultaSQL{from: "receitas"}
+	colunas := receitaColumns
+	if busca != "" {
+		consulta.from += ", websearch_to_tsquery('" + models.ConfiguracaoBusca + "', " + consulta.param(busca) + ") AS consulta"
+		consulta.condicoes = append(consulta.condicoes, "busca @@ consulta")
+		colunas += colunasBusca
+	}
+	if len(filtros.Disponiveis) > 0 {
+		consulta.from += ", " + models.FaltandoSQL(consulta.param(pq.Array(filtros.Disponiveis)))
+		consulta.condicoes = append(consulta.condicoes, "despensa.usados > 0", "despensa.faltando <= "+consulta.param(filtros.FaltandoMax))
+		colunas += ", despensa.faltando"
+	}
+	if len(filtros.Tags) > 0 {
+		// Receitas com todas as tags pedidas
+		consulta.condicoes = append(consulta.condicoes, "receitas.id IN (SELECT rt.receita_id FROM receita_tags rt JOIN tags t ON t.id = rt.tag_id WHERE t.slug = ANY("+
+			consulta.param(pq.Array(filtros.Tags))+") GROUP BY rt.receita_id HAVING count(*) = "+consulta.param(len(filtros.Tags))+")")
+	}
+	if filtros.TempoMin != nil {
+		consulta.condicoes = append(consulta.condicoes, "receitas.tempo_total >= "+consulta.param(*filtros.TempoMin))
+	}
+	if filtros.TempoMax != nil {
+		consulta.condicoes = append(consulta.condicoes, "receitas.tempo_total <= "+consulta.param(*filtros.TempoMax))
+	}
+	if filtros.PreparoMax != nil {
+		consulta.condicoes = append(consulta.condicoes, "receitas.tempo_preparo <= "+consulta.param(*filtros.PreparoMax))
+	}
+	if len(filtros.Dificuldades) > 0 {
+		consulta.condicoes = append(consulta.condicoes, "receitas.dificuldade = ANY("+consulta.param(pq.Array(filtros.Dificuldades))+")")
+	}
+	if filtros.Categoria != "" {
+		consulta.condicoes = append(consulta.condicoes, "receitas.categoria_id = (SELECT id FROM categorias WHERE slug = "+consulta.param(filtros.Categoria)+")")
+	}
+	if filtros.Cozinha != "" {
+		consulta.condicoes = append(consulta.condicoes, "receitas.cozinha_id = (SELECT id FROM cozinhas WHERE slug = "+consulta.param(filtros.Cozinha)+")")
+	}
+
+	// O total ignora o cursor: conta todas as paginas
+	err := postgres.DBConnection.QueryRowContext(ctx, "SELECT count(*) FROM "+consulta.from+consulta.where(), consulta.args...).Scan(&pagina.Total)
+	if err != nil {
+		return pagina, fmt.Errorf("erro ao contar receitas: %w", err)
+	}
+
+	if filtros.Facetas {
+		if pagina.Facetas, err = postgres.facetas(ctx, consulta); err != nil {
+			return pagina, fmt.Errorf("erro ao contar facetas: %w", err)
+		}
+	}
+
+	if filtros.Cursor != nil {
+		consulta.condicoes = append(consulta.condicoes, depoisSQL(filtros.Ordem, &consulta, *filtros.Cursor))
+	}
+	// Uma receita a mais indica que existe proxima pagina
+	query := "SELECT " + colunas + " FROM " + consulta.from + consulta.where() +
+		" ORDER BY " + ordemSQL(filtros.Ordem) + " LIMIT " + consulta.param(filtros.Limite+1)
+
+	rows, err := postgres.DBConnection.QueryContext(ctx, query, consulta.args...)
+	if err != nil {
+		return pagina, err
+	}
+	defer rows.Close()
+
+	pagina.Receitas = []models.Receita{}
+	for rows.Next() {
+		var receita models.Receita
+		var extras []any
+		var faltando int
+		if busca != "" {
+			receita.Busca = &models.ResultadoBusca{}
+			extras = append(extras, &receita.Busca.Relevancia, &receita.Busca.Nome, &receita.Busca.Trecho)
+		}
+		if len(filtros.Disponiveis) > 0 {
+			extras = append(extras, &faltando)
+		}
+		if err := scanReceita(rows, &receita, extras...); err != nil {
+			return pagina, err
+		}
+
+		if len(filtros.Disponiveis) > 0 {
+			// A contagem do banco define a ordem e o cursor; a lista de nomes vem da forma estruturada
+			despensa := receita.ConferirDespensa(filtros.Disponiveis)
+			despensa.Faltando = faltando
+			receita.Despensa = &despensa
+		}
+
+		pagina.Receitas = append(pagina.Receitas, receita)
+	}
+	if err := rows.Err(); err != nil {
+		return pagina, fmt.Errorf("erro ao ler receitas: %w", err)
+	}
+
+	paginar(&pagina, filtros)
+	return pagina, nil
+}
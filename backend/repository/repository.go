@@ -0,0 +1,139 @@
+// Package repository guarda as receitas. Os handlers falam com a interface
+// ReceitaRepository; Postgres e a implementacao de producao, SQLite serve para
+// instalacoes locais em um unico binario e Memoria para testes e demonstracoes
+// sem banco.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNaoEncontrada = errors.New("receita não encontrada")
+	// Outra requisicao gravou a mesma imagem ao mesmo tempo
+	ErrConflito = errors.New("conflito com uma alteração simultânea")
+)
+
+// Armazenamento das receitas e das imagens delas.
+//
+// As receitas lidas vem com PrepararIngredientesGravados e PrepararPassos
+// aplicados e com as imagens anexadas (models.Receita.AnexarImagens), sem as
+// URLs: quem serve os arquivos preenche com Imagem.PrepararURLs. As escritas recebem a
+// receita ja validada e retornam *models.ClassificacaoDesconhecidaError quando
+// a tag, categoria ou cozinha nao existe.
+type ReceitaRepository interface {
+	// Receitas que atendem aos filtros, uma pagina por vez
+	Listar(ctx context.Context, consulta Consulta) (Pagina, error)
+	// Como Listar, mas apenas as receitas que casam com o texto, com Receita.Busca preenchido
+	Buscar(ctx context.Context, texto string, consulta Consulta) (Pagina, error)
+	// ErrNaoEncontrada se o id nao existir
+	Obter(ctx context.Context, id uuid.UUID) (models.Receita, error)
+	// Autor da receita (nil nas receitas antigas, sem autor); ErrNaoEncontrada se o id nao existir
+	Autor(ctx context.Context, id uuid.UUID) (*uuid.UUID, error)
+	// Grava uma receita nova e preenche ID e CriadoEm
+	Criar(ctx context.Context, receita *models.Receita) error
+	// Substitui a receita de mesmo ID, preenche CriadoEm e as imagens, e retorna as
+	// fotos de passos que deixaram de existir, para que os arquivos sejam apagados
+	Atualizar(ctx context.Context, receita *models.Receita) ([]models.Imagem, error)
+	// Remove a receita e retorna as imagens dela
+	Remover(ctx context.Context, id uuid.UUID) ([]models.Imagem, error)
+	// Grava a imagem na posicao dela (capa ou passo) e retorna a que foi substituida
+	SalvarImagem(ctx context.Context, receitaID uuid.UUID, imagem models.Imagem, contentType string) ([]models.Imagem, error)
+	// Remove a imagem da posicao e a retorna; vazio se nao havia imagem
+	RemoverImagem(ctx context.Context, receitaID uuid.UUID, passo *int) ([]models.Imagem, error)
+}
+
+// Filtros e paginacao da listagem. Campos vazios nao filtram.
+type Consulta struct {
+	Disponiveis  []string // Ingredientes disponiveis, ja normalizados (models.NomeNormalizado)
+	FaltandoMax  int      // Com Disponiveis, quantos ingredientes podem faltar
+	Tags         []string // A receita precisa ter todas
+	Categoria    string
+	Cozinha      string
+	TempoMin     *int // Minutos
+	TempoMax     *int
+	PreparoMax   *int
+	Dificuldades []string
+	Ordem        Ordenacao
+	Limite       int
+	Cursor       *Cursor // Continua depois desta posicao
+	Facetas      bool    // Conta as receitas por tag, categoria e cozinha
+}
+
+// Pagina da listagem
+type Pagina struct {
+	Receitas []models.Receita
+	Total    int     // Receitas que atendem aos filtros, em todas as paginas
+	Proxima  *Cursor // nil na ultima pagina
+	Facetas  models.Facetas
+}
+
+// Campos aceitos na ordenacao
+var camposOrdenacao = []string{"nome", "criado_em", "relevancia", "faltando"}
+
+// Ordenacao da listagem: "nome" ou "-nome" (decrescente). O id desempata.
+type Ordenacao struct {
+	Campo string
+	Desc  bool
+}
+
+func ParseOrdenacao(texto string) (Ordenacao, error) {
+	ordem := Ordenacao{Campo: strings.TrimPrefix(texto, "-"), Desc: strings.HasPrefix(texto, "-")}
+	for _, campo := range camposOrdenacao {
+		if ordem.Campo == campo {
+			return ordem, nil
+		}
+	}
+	return Ordenacao{}, fmt.Errorf("sort inválido: %q (use nome, criado_em, relevancia ou faltando, com - para ordem decrescente)", texto)
+}
+
+func (ordem Ordenacao) String() string {
+	if ordem.Desc {
+		return "-" + ordem.Campo
+	}
+	return ordem.Campo
+}
+
+// Valor do campo de ordenacao de uma receita, como guardado no cursor: time.Time
+// para criado_em, float64 para relevancia, int para faltando e string para nome
+func (ordem Ordenacao) Valor(receita models.Receita) any {
+	switch ordem.Campo {
+	case "criado_em":
+		return receita.CriadoEm
+	case "relevancia":
+		if receita.Busca == nil {
+			return float64(0)
+		}
+		// A relevancia e um real (float4) no banco; o float64 lido volta igual no cast para real
+		return receita.Busca.Relevancia
+	case "faltando":
+		if receita.Despensa == nil {
+			return 0
+		}
+		return receita.Despensa.Faltando
+	}
+	return receita.Nome
+}
+
+// Posicao da ultima receita de uma pagina
+type Cursor struct {
+	Ordem string    `json:"o"`
+	Valor any       `json:"v"` // Do tipo retornado por Ordenacao.Valor para esta ordem
+	ID    uuid.UUID `json:"id"`
+}
+
+// Corta a lista lida com uma receita a mais (Limite+1) e calcula o cursor da proxima pagina
+func paginar(pagina *Pagina, consulta Consulta) {
+	if len(pagina.Receitas) <= consulta.Limite {
+		return
+	}
+	pagina.Receitas = pagina.Receitas[:consulta.Limite]
+	ultima := pagina.Receitas[len(pagina.Receitas)-1]
+	pagina.Proxima = &Cursor{Ordem: consulta.Ordem.String(), Valor: consulta.Ordem.Valor(ultima), ID: ultima.ID}
+}
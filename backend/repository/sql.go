@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/google/uuid"
+)
+
+// Consultas com o mesmo SQL no Postgres e no SQLite, compartilhadas pelas
+// duas implementacoes
+type bancoSQL struct {
+	DBConnection *sql.DB
+}
+
+// Interface comum entre *sql.Row e *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// Interface comum entre *sql.DB e *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Linha da tabela imagens como vem na coluna de imagens em JSON (imagensColumn)
+type linhaImagem struct {
+	ID       uuid.UUID `json:"id"`
+	Passo    *int      `json:"passo"`
+	Chave    string    `json:"chave"`
+	Extensao string    `json:"extensao"`
+	Largura  int       `json:"largura"`
+	Altura   int       `json:"altura"`
+}
+
+// Converte o JSON da coluna de imagens e coloca as imagens na receita
+func anexarImagens(receita *models.Receita, dados []byte) error {
+	var linhas []linhaImagem
+	if dados != nil {
+		if err := json.Unmarshal(dados, &linhas); err != nil {
+			return err
+		}
+	}
+	lista := make([]models.Imagem, len(linhas))
+	for i, linha := range linhas {
+		lista[i] = models.Imagem{ID: linha.ID, Passo: linha.Passo, Chave: linha.Chave, Extensao: linha.Extensao, Largura: linha.Largura, Altura: linha.Altura}
+	}
+	receita.AnexarImagens(lista)
+	return nil
+}
+
+func (banco *bancoSQL) Autor(ctx context.Context, id uuid.UUID) (*uuid.UUID, error) {
+	var autorID uuid.NullUUID
+	err := banco.DBConnection.QueryRowContext(ctx, `SELECT autor_id FROM receitas WHERE id = $1`, id).Scan(&autorID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNaoEncontrada
+	}
+	if err != nil || !autorID.Valid {
+		return nil, err
+	}
+	return &autorID.UUID, nil
+}
+
+func (banco *bancoSQL) Remover(ctx context.Context, id uuid.UUID) ([]models.Imagem, error) {
+	tx, err := banco.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	removidas, err := lerImagensRemovidas(tx.QueryContext(ctx, `DELETE FROM imagens WHERE receita_id = $1 RETURNING chave, extensao`, id))
+	if err != nil {
+		return nil, err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM receitas WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrNaoEncontrada
+	}
+	return removidas, tx.Commit()
+}
+
+func (banco *bancoSQL) SalvarImagem(ctx context.Context, receitaID uuid.UUID, imagem models.Imagem, contentType string) ([]models.Imagem, error) {
+	tx, err := banco.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	antigas, err := lerImagensRemovidas(tx.QueryContext(ctx, `DELETE FROM imagens WHERE receita_id = $1 AND passo IS NOT DISTINCT FROM $2 RETURNING chave, extensao`,
+		receitaID, nullInt(imagem.Passo)))
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO imagens (id, receita_id, passo, chave, extensao, content_type, largura, altura) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		imagem.ID, receitaID, nullInt(imagem.Passo), imagem.Chave, imagem.Extensao, contentType, imagem.Largura, imagem.Altura)
+	if database.ViolacaoUnica(err) {
+		return nil, ErrConflito
+	}
+	if database.ViolacaoChaveEstrangeira(err) {
+		return nil, ErrNaoEncontrada
+	}
+	if err != nil {
+		return nil, err
+	}
+	return antigas, tx.Commit()
+}
+
+func (banco *bancoSQL) RemoverImagem(ctx context.Context, receitaID uuid.UUID, passo *int) ([]models.Imagem, error) {
+	return lerImagensRemovidas(banco.DBConnection.QueryContext(ctx, `DELETE FROM imagens WHERE receita_id = $1 AND passo IS NOT DISTINCT FROM $2 RETURNING chave, extensao`,
+		receitaID, nullInt(passo)))
+}
+
+// Le as chaves e extensoes devolvidas por um DELETE ... RETURNING chave, extensao
+func lerImagensRemovidas(rows *sql.Rows, err error) ([]models.Imagem, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var removidas []models.Imagem
+	for rows.Next() {
+		var imagem models.Imagem
+		if err := rows.Scan(&imagem.Chave, &imagem.Extensao); err != nil {
+			return nil, err
+		}
+		removidas = append(removidas, imagem)
+	}
+	return removidas, rows.Err()
+}
+
+// ID da categoria ou cozinha pelo slug; vazio vira NULL
+func resolverClassificacao(ctx context.Context, db queryRower, tipo models.TipoClassificacao, slug string) (uuid.NullUUID, error) {
+	var id uuid.NullUUID
+	if slug == "" {
+		return id, nil
+	}
+	err := db.QueryRowContext(ctx, `SELECT id FROM `+tipo.Tabela+` WHERE slug = $1`, slug).Scan(&id)
+	if err == sql.ErrNoRows {
+		return id, &models.ClassificacaoDesconhecidaError{Tipo: tipo, Slug: slug}
+	}
+	return id, err
+}
+
+// IDs da categoria e da cozinha informadas na receita
+func resolverCategoriaCozinha(ctx context.Context, db queryRower, receita models.Receita) (uuid.NullUUID, uuid.NullUUID, error) {
+	categoriaID, err := resolverClassificacao(ctx, db, models.TipoCategoria, receita.Categoria)
+	if err != nil {
+		return categoriaID, uuid.NullUUID{}, err
+	}
+	cozinhaID, err := resolverClassificacao(ctx, db, models.TipoCozinha, receita.Cozinha)
+	return categoriaID, cozinhaID, err
+}
+
+// Ingredientes estruturados e passos, gravados como JSONB no Postgres e como texto no SQLite
+func colunasJSON(receita models.Receita) ([]byte, []byte, error) {
+	estruturados, err := json.Marshal(receita.IngredientesEstruturados)
+	if err != nil {
+		return nil, nil, err
+	}
+	passos, err := json.Marshal(receita.Passos)
+	return estruturados, passos, err
+}
+
+// Zero vira NULL no banco (valor nao informado)
+func nullPositivo(valor int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(valor), Valid: valor > 0}
+}
+
+// nil vira NULL no banco
+func nullInt(valor *int) sql.NullInt64 {
+	if valor == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*valor), Valid: true}
+}
+
+func intOuNil(valor sql.NullInt64) *int {
+	if !valor.Valid {
+		return nil
+	}
+	v := int(valor.Int64)
+	return &v
+}
+
+// Texto vazio vira NULL no banco
+func nullTexto(valor string) sql.NullString {
+	return sql.NullString{String: valor, Valid: valor != ""}
+}
+
+func nullUUID(valor *uuid.UUID) uuid.NullUUID {
+	if valor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *valor, Valid: true}
+}
+
+// Monta o FROM e o WHERE da listagem com parametros numerados
+type consultaSQL struct {
+	from      string
+	condicoes []string
+	args      []any
+}
+
+// Adiciona um argumento e retorna o placeholder dele ($1, $2...)
+func (consulta *consultaSQL) param(valor any) string {
+	consulta.args = append(consulta.args, valor)
+	return "$" + strconv.Itoa(len(consulta.args))
+}
+
+func (consulta *consultaSQL) where() string {
+	if len(consulta.condicoes) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(consulta.condicoes, " AND ")
+}
+
+// Contagem de receitas por tag, categoria e cozinha com os mesmos filtros da
+// listagem. O SQL e o mesmo no Postgres e no SQLite.
+func (banco *bancoSQL) facetas(ctx context.Context, consulta consultaSQL) (models.Facetas, error) {
+	facetas := models.Facetas{}
+	var err error
+
+	facetas.Tags, err = banco.contarFaceta(ctx, consulta, "tags c, receita_tags rt", "rt.receita_id = receitas.id AND c.id = rt.tag_id")
+	if err != nil {
+		return facetas, err
+	}
+	facetas.Categorias, err = banco.contarFaceta(ctx, consulta, "categorias c", "c.id = receitas.categoria_id")
+	if err != nil {
+		return facetas, err
+	}
+	facetas.Cozinhas, err = banco.contarFaceta(ctx, consulta, "cozinhas c", "c.id = receitas.cozinha_id")
+	return facetas, err
+}
+
+// As tabelas entram separadas por virgula no FROM da listagem e a juncao vai para o WHERE
+func (banco *bancoSQL) contarFaceta(ctx context.Context, consulta consultaSQL, tabelas, juncao string) ([]models.Faceta, error) {
+	consulta.condicoes = append(consulta.condicoes[:len(consulta.condicoes):len(consulta.condicoes)], juncao)
+	query := `SELECT c.slug, c.nome, count(*) FROM ` + consulta.from + `, ` + tabelas + consulta.where() +
+		` GROUP BY c.slug, c.nome ORDER BY count(*) DESC, c.nome`
+
+	rows, err := banco.DBConnection.QueryContext(ctx, query, consulta.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facetas := []models.Faceta{}
+	for rows.Next() {
+		var faceta models.Faceta
+		if err := rows.Scan(&faceta.Slug, &faceta.Nome, &faceta.Total); err != nil {
+			return nil, err
+		}
+		facetas = append(facetas, faceta)
+	}
+	return facetas, rows.Err()
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/google/uuid"
+)
+
+// Receitas em um arquivo SQLite, com o esquema de migrations/sqlite. As listas
+// (ingredientes, tags) ficam em texto JSON. A listagem monta o SQL como no
+// Postgres; a busca textual usa funcoes em Go registradas na conexao.
+type SQLite struct {
+	bancoSQL
+}
+
+// Construtor de SQLite
+func NewSQLite(dbConnection *sql.DB) *SQLite {
+	return &SQLite{bancoSQL{DBConnection: dbConnection}}
+}
+
+// Colunas lidas nas consultas de receitas, na ordem esperada por scanReceitaSQLite
+const sqliteColumns = `id, nome, descricao, ingredientes, ingredientes_estruturados, instrucoes, passos, porcoes, autor_id, criado_em, tempo_preparo, tempo_cozimento, tempo_descanso, tempo_total, dificuldade,
+	(SELECT slug FROM categorias WHERE id = receitas.categoria_id) AS categoria,
+	(SELECT slug FROM cozinhas WHERE id = receitas.cozinha_id) AS cozinha,
+	(SELECT json_group_array(slug) FROM (SELECT t.slug FROM receita_tags rt JOIN tags t ON t.id = rt.tag_id WHERE rt.receita_id = receitas.id ORDER BY t.slug)) AS tags, ` + imagensSQLiteColumn
+
+// Coluna com as imagens enviadas, no mesmo JSON de imagensColumn
+const imagensSQLiteColumn = `(SELECT json_group_array(json_object('id', id, 'passo', passo, 'chave', chave, 'extensao', extensao, 'largura', largura, 'altura', altura))
+	FROM imagens WHERE imagens.receita_id = receitas.id) AS imagens`
+
+// Le uma linha com as colunas de sqliteColumns, seguidas das colunas em extras
+func scanReceitaSQLite(row rowScanner, receita *models.Receita, extras ...any) error {
+	var autorID uuid.NullUUID
+	var ingredientes, tags string
+	var estruturados, passos sql.NullString
+	var imagens []byte
+	var porcoes sql.NullInt64
+	var categoria, cozinha, dificuldade sql.NullString
+	var preparo, cozimento, descanso, total sql.NullInt64
+	dest := []any{&receita.ID, &receita.Nome, &receita.Descricao, &ingredientes, &estruturados, &receita.Instrucoes, &passos, &porcoes, &autorID, &receita.CriadoEm,
+		&preparo, &cozimento, &descanso, &total, &dificuldade, &categoria, &cozinha, &tags, &imagens}
+	err := row.Scan(append(dest, extras...)...)
+	if err != nil {
+		return err
+	}
+	receita.TempoPreparo = intOuNil(preparo)
+	receita.TempoCozimento = intOuNil(cozimento)
+	receita.TempoDescanso = intOuNil(descanso)
+	receita.TempoTotal = intOuNil(total)
+	receita.Dificuldade = dificuldade.String
+	receita.Categoria = categoria.String
+	receita.Cozinha = cozinha.String
+	receita.Porcoes = int(porcoes.Int64)
+	if autorID.Valid {
+		receita.AutorID = &autorID.UUID
+	}
+	if err := json.Unmarshal([]byte(ingredientes), &receita.Ingredientes); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(tags), &receita.Tags); err != nil {
+		return err
+	}
+	if estruturados.Valid {
+		if err := json.Unmarshal([]byte(estruturados.String), &receita.IngredientesEstruturados); err != nil {
+			return err
+		}
+	}
+	if passos.Valid {
+		if err := json.Unmarshal([]byte(passos.String), &receita.Passos); err != nil {
+			return err
+		}
+	}
+	if err := receita.PrepararIngredientesGravados(); err != nil {
+		return err
+	}
+	if err := receita.PrepararPassos(); err != nil {
+		return err
+	}
+	return anexarImagens(receita, imagens)
+}
+
+// Lista de textos como JSON; nil vira [] (a coluna e NOT NULL)
+func listaJSON(lista []string) (string, error) {
+	if lista == nil {
+		lista = []string{}
+	}
+	dados, err := json.Marshal(lista)
+	return string(dados), err
+}
+
+// Colunas em JSON da receita, como texto: ingredientes, normalizados, estruturados e passos
+func colunasJSONSQLite(receita models.Receita) ([]any, error) {
+	ingredientes, err := listaJSON(receita.Ingredientes)
+	if err != nil {
+		return nil, err
+	}
+	normalizados, err := listaJSON(receita.IngredientesNormalizados())
+	if err != nil {
+		return nil, err
+	}
+	estruturados, passos, err := colunasJSON(receita)
+	if err != nil {
+		return nil, err
+	}
+	return []any{ingredientes, normalizados, string(estruturados), string(passos)}, nil
+}
+
+func (sqlite *SQLite) Obter(ctx context.Context, id uuid.UUID) (models.Receita, error) {
+	var receita models.Receita
+	err := scanReceitaSQLite(sqlite.DBConnection.QueryRowContext(ctx, `SELECT `+sqliteColumns+` FROM receitas WHERE id = $1`, id), &receita)
+	if err == sql.ErrNoRows {
+		return receita, ErrNaoEncontrada
+	}
+	return receita, err
+}
+
+func (sqlite *SQLite) Criar(ctx context.Context, receita *models.Receita) error {
+	colunas, err := colunasJSONSQLite(*receita)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sqlite.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	categoriaID, cozinhaID, err := resolverCategoriaCozinha(ctx, tx, *receita)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO receitas (nome, descricao, ingredientes, ingredientes_normalizados, ingredientes_estruturados, passos, instrucoes, porcoes, autor_id, categoria_id, cozinha_id,
+		tempo_preparo, tempo_cozimento, tempo_descanso, tempo_total, dificuldade)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id, criado_em`
+	args := append([]any{receita.Nome, receita.Descricao}, colunas...)
+	args = append(args, receita.Instrucoes, nullPositivo(receita.Porcoes), nullUUID(receita.AutorID), categoriaID, cozinhaID,
+		nullInt(receita.TempoPreparo), nullInt(receita.TempoCozimento), nullInt(receita.TempoDescanso), nullInt(receita.TempoTotal), nullTexto(receita.Dificuldade))
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&receita.ID, &receita.CriadoEm); err != nil {
+		return err
+	}
+	if err := gravarTagsSQLite(ctx, tx, receita.ID, receita.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (sqlite *SQLite) Atualizar(ctx context.Context, receita *models.Receita) ([]models.Imagem, error) {
+	colunas, err := colunasJSONSQLite(*receita)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := sqlite.DBConnection.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	categoriaID, cozinhaID, err := resolverCategoriaCozinha(ctx, tx, *receita)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE receitas SET nome = $1, descricao = $2, ingredientes = $3, ingredientes_normalizados = $4, ingredientes_estruturados = $5, passos = $6, instrucoes = $7, porcoes = $8, categoria_id = $9, cozinha_id = $10,
+		tempo_preparo = $11, tempo_cozimento = $12, tempo_descanso = $13, tempo_total = $14, dificuldade = $15 WHERE id = $16 RETURNING criado_em`
+	args := append([]any{receita.Nome, receita.Descricao}, colunas...)
+	args = append(args, receita.Instrucoes, nullPositivo(receita.Porcoes), categoriaID, cozinhaID,
+		nullInt(receita.TempoPreparo), nullInt(receita.TempoCozimento), nullInt(receita.TempoDescanso), nullInt(receita.TempoTotal), nullTexto(receita.Dificuldade), receita.ID)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&receita.CriadoEm)
+	if err == sql.ErrNoRows {
+		return nil, ErrNaoEncontrada
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := gravarTagsSQLite(ctx, tx, receita.ID, receita.Tags); err != nil {
+		return nil, err
+	}
+	// As fotos ficam com a posicao do passo; as de passos que sairam sao removidas
+	removidas, err := lerImagensRemovidas(tx.QueryContext(ctx, `DELETE FROM imagens WHERE receita_id = $1 AND passo >= $2 RETURNING chave, extensao`, receita.ID, len(receita.Passos)))
+	if err != nil {
+		return nil, err
+	}
+	var imagens []byte
+	if err := tx.QueryRowContext(ctx, `SELECT `+imagensSQLiteColumn+` FROM receitas WHERE id = $1`, receita.ID).Scan(&imagens); err != nil {
+		return nil, err
+	}
+	if err := anexarImagens(receita, imagens); err != nil {
+		return nil, err
+	}
+	return removidas, tx.Commit()
+}
+
+// Como gravarTags, com a lista de slugs em JSON
+func gravarTagsSQLite(ctx context.Context, tx *sql.Tx, receitaID uuid.UUID, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM receita_tags WHERE receita_id = $1`, receitaID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	lista, err := listaJSON(tags)
+	if err != nil {
+		return err
+	}
+
+	var faltando sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT min(value) FROM json_each($1) WHERE value NOT IN (SELECT slug FROM tags)`, lista).Scan(&faltando)
+	if err != nil {
+		return err
+	}
+	if faltando.Valid {
+		return &models.ClassificacaoDesconhecidaError{Tipo: models.TipoTag, Slug: faltando.String}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO receita_tags (receita_id, tag_id) SELECT $1, id FROM tags WHERE slug IN (SELECT value FROM json_each($2))`, receitaID, lista)
+	return err
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/units"
+)
+
+// Funcoes em Go usadas pela listagem no SQLite, que nao tem collation sem
+// acentos nem busca textual como a do Postgres
+func init() {
+	database.RegistrarFuncaoSQLite("normalizar", units.Normalizar, true)
+	database.RegistrarFuncaoSQLite("relevancia_busca", relevanciaBuscaSQLite, true)
+}
+
+// Relevancia da receita na busca, ou NULL quando ela nao casa. Recebe o texto
+// da busca e as colunas nome, ingredientes (JSON), descricao e instrucoes.
+func relevanciaBuscaSQLite(texto, nome, ingredientes, descricao, instrucoes string) (any, error) {
+	var lista []string
+	if err := json.Unmarshal([]byte(ingredientes), &lista); err != nil {
+		return nil, err
+	}
+	_, relevancia, ok := parseBusca(texto).relevancia(nome, lista, descricao, instrucoes)
+	if !ok {
+		return nil, nil
+	}
+	return relevancia, nil
+}
+
+// Coluna de cada campo de ordenacao e as chaves comparadas, com %s no lugar da
+// coluna ou do valor do cursor. O nome e ordenado sem acentos e maiusculas,
+// como na Memoria, e desempatado pelo texto original.
+var colunasOrdenacaoSQLite = map[string]struct {
+	coluna string
+	chaves []string
+}{
+	"nome":       {"receitas.nome", []string{"normalizar(%s)", "%s"}},
+	"criado_em":  {"receitas.criado_em", []string{"%s"}},
+	"relevancia": {"receitas.relevancia", []string{"%s"}},
+	"faltando":   {"receitas.faltando", []string{"%s"}},
+}
+
+// Formato de criado_em gravado pelo padrao da coluna, strftime('%Y-%m-%d %H:%M:%f+00:00').
+// O cursor e comparado como texto, no mesmo formato, para usar o indice.
+const formatoCriadoEmSQLite = "2006-01-02 15:04:05.000+00:00"
+
+// Chaves de ordenacao aplicadas a uma coluna ou a um placeholder
+func chavesSQLite(ordem Ordenacao, expressao string) []string {
+	chaves := []string{}
+	for _, chave := range colunasOrdenacaoSQLite[ordem.Campo].chaves {
+		chaves = append(chaves, fmt.Sprintf(chave, expressao))
+	}
+	return chaves
+}
+
+// Clausula ORDER BY
+func ordemSQLite(ordem Ordenacao) string {
+	direcao := " ASC"
+	if ordem.Desc {
+		direcao = " DESC"
+	}
+	chaves := append(chavesSQLite(ordem, colunasOrdenacaoSQLite[ordem.Campo].coluna), "receitas.id")
+	return strings.Join(chaves, direcao+", ") + direcao
+}
+
+// Condicao que seleciona as receitas depois do cursor na ordem escolhida
+func depoisSQLite(ordem Ordenacao, consulta *consultaSQL, cursor Cursor) string {
+	operador := ">"
+	if ordem.Desc {
+		operador = "<"
+	}
+	valor := cursor.Valor
+	if momento, ok := valor.(time.Time); ok {
+		valor = momento.UTC().Format(formatoCriadoEmSQLite)
+	}
+	colunas := append(chavesSQLite(ordem, colunasOrdenacaoSQLite[ordem.Campo].coluna), "receitas.id")
+	valores := append(chavesSQLite(ordem, consulta.param(valor)), consulta.param(cursor.ID))
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(colunas, ", "), operador, strings.Join(valores, ", "))
+}
+
+func (sqlite *SQLite) Listar(ctx context.Context, consulta Consulta) (Pagina, error) {
+	return sqlite.listar(ctx, "", consulta)
+}
+
+// Aceita "frase exata", -excluir e OR, como o Postgres
+func (sqlite *SQLite) Buscar(ctx context.Context, texto string, consulta Consulta) (Pagina, error) {
+	return sqlite.listar(ctx, texto, consulta)
+}
+
+func (sqlite *SQLite) listar(ctx context.Context, busca string, filtros Consulta) (Pagina, error) {
+	var pagina Pagina
+	consulta := consultaSQL{from: "receitas"}
+	colunas := sqliteColumns
+
+	// A relevancia e a despensa sao colunas de uma subconsulta, para o WHERE,
+	// o ORDER BY e o cursor usarem o mesmo valor
+	var calculadas []string
+	if busca != "" {
+		calculadas = append(calculadas, "relevancia_busca("+consulta.param(busca)+", nome, ingredientes, descricao, instrucoes) AS relevancia")
+		consulta.condicoes = append(consulta.condicoes, "receitas.relevancia IS NOT NULL")
+		colunas += ", receitas.relevancia"
+	}
+	if len(filtros.Disponiveis) > 0 {
+		disponiveis, err := listaJSON(filtros.Disponiveis)
+		if err != nil {
+			return pagina, err
+		}
+		calculadas = append(calculadas, models.FaltandoSQLite(consulta.param(disponiveis)))
+		consulta.condicoes = append(consulta.condicoes, "receitas.usados > 0", "receitas.faltando <= "+consulta.param(filtros.FaltandoMax))
+		colunas += ", receitas.faltando"
+	}
+	if len(calculadas) > 0 {
+		consulta.from = "(SELECT receitas.*, " + strings.Join(calculadas, ", ") + " FROM receitas) AS receitas"
+	}
+
+	if len(filtros.Tags) > 0 {
+		tags, err := listaJSON(filtros.Tags)
+		if err != nil {
+			return pagina, err
+		}
+		// Receitas com todas as tags pedidas
+		consulta.condicoes = append(consulta.condicoes, "receitas.id IN (SELECT rt.receita_id FROM receita_tags rt JOIN tags t ON t.id = rt.tag_id WHERE t.slug IN (SELECT value FROM json_each("+
+			consulta.param(tags)+")) GROUP BY rt.receita_id HAVING count(*) = "+consulta.param(len(filtros.Tags))+")")
+	}
+	if filtros.TempoMin != nil {
+		consulta.condicoes = append(consulta.condicoes, "receitas.tempo_total >= "+consulta.param(*filtros.TempoMin))
+	}
+	if filtros.TempoMax != nil {
+		consulta.condicoes = append(consulta.condicoes, "receitas.tempo_total <= "+consulta.param(*filtros.TempoMax))
+	}
+	if filtros.PreparoMax != nil {
+		consulta.condicoes = append(consulta.condicoes, "receitas.tempo_preparo <= "+consulta.param(*filtros.PreparoMax))
+	}
+	if len(filtros.Dificuldades) > 0 {
+		dificuldades, err := listaJSON(filtros.Dificuldades)
+		if err != nil {
+			return pagina, err
+		}
+		consulta.condicoes = append(consulta.condicoes, "receitas.dificuldade IN (SELECT value FROM json_each("+consulta.param(dificuldades)+"))")
+	}
+	if filtros.Categoria != "" {
+		consulta.condicoes = append(consulta.condicoes, "receitas.categoria_id = (SELECT id FROM categorias WHERE slug = "+consulta.param(filtros.Categoria)+")")
+	}
+	if filtros.Cozinha != "" {
+		consulta.condicoes = append(consulta.condicoes, "receitas.cozinha_id = (SELECT id FROM cozinhas WHERE slug = "+consulta.param(filtros.Cozinha)+")")
+	}
+
+	// O total ignora o cursor: conta todas as paginas
+	err := sqlite.DBConnection.QueryRowContext(ctx, "SELECT count(*) FROM "+consulta.from+consulta.where(), consulta.args...).Scan(&pagina.Total)
+	if err != nil {
+		return pagina, fmt.Errorf("erro ao contar receitas: %w", err)
+	}
+
+	if filtros.Facetas {
+		if pagina.Facetas, err = sqlite.facetas(ctx, consulta); err != nil {
+			return pagina, fmt.Errorf("erro ao contar facetas: %w", err)
+		}
+	}
+
+	if filtros.Cursor != nil {
+		consulta.condicoes = append(consulta.condicoes, depoisSQLite(filtros.Ordem, &consulta, *filtros.Cursor))
+	}
+	// Uma receita a mais indica que existe proxima pagina
+	query := "SELECT " + colunas + " FROM " + consulta.from + consulta.where() +
+		" ORDER BY " + ordemSQLite(filtros.Ordem) + " LIMIT " + consulta.param(filtros.Limite+1)
+
+	rows, err := sqlite.DBConnection.QueryContext(ctx, query, consulta.args...)
+	if err != nil {
+		return pagina, err
+	}
+	defer rows.Close()
+
+	var termos *buscaMemoria
+	if busca != "" {
+		termos = parseBusca(busca)
+	}
+	pagina.Receitas = []models.Receita{}
+	for rows.Next() {
+		var receita models.Receita
+		var extras []any
+		var relevancia float64
+		var faltando int
+		if busca != "" {
+			extras = append(extras, &relevancia)
+		}
+		if len(filtros.Disponiveis) > 0 {
+			extras = append(extras, &faltando)
+		}
+		if err := scanReceitaSQLite(rows, &receita, extras...); err != nil {
+			return pagina, err
+		}
+
+		if termos != nil {
+			// Os trechos marcados saem apenas das receitas da pagina
+			resultado, _ := termos.conferir(receita)
+			resultado.Relevancia = relevancia
+			receita.Busca = &resultado
+		}
+		if len(filtros.Disponiveis) > 0 {
+			despensa := receita.ConferirDespensa(filtros.Disponiveis)
+			despensa.Faltando = faltando
+			receita.Despensa = &despensa
+		}
+
+		pagina.Receitas = append(pagina.Receitas, receita)
+	}
+	if err := rows.Err(); err != nil {
+		return pagina, fmt.Errorf("erro ao ler receitas: %w", err)
+	}
+
+	paginar(&pagina, filtros)
+	return pagina, nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/database"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/migrations"
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/models"
+)
+
+func novoSQLite(t *testing.T) *SQLite {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.AbrirSQLite(filepath.Join(t.TempDir(), "receitas.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrador, err := migrations.NewMigrador(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrador.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Os nomes ordenam como os slugs, para as facetas empatadas virem na mesma ordem da Memoria
+	_, err = db.ExecContext(ctx, `INSERT INTO tags (slug, nome) VALUES ('doce', 'Doce'), ('rapida', 'Rápida'), ('vegana', 'Vegana');
+		INSERT INTO categorias (slug, nome) VALUES ('bolo', 'Bolo'), ('prato-principal', 'Prato principal');
+		INSERT INTO cozinhas (slug, nome) VALUES ('brasileira', 'Brasileira'), ('italiana', 'Italiana')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSQLite(db)
+}
+
+func minutos(valor int) *int {
+	return &valor
+}
+
+// As mesmas receitas no SQLite e na Memoria
+func receitasListagem(t *testing.T) (*SQLite, *Memoria) {
+	t.Helper()
+	sqlite, memoria := novoSQLite(t), NewMemoria()
+	receitas := []models.Receita{
+		{Nome: "Bolo de cenoura", Descricao: "Bolo fofo com cobertura de chocolate", Ingredientes: []string{"3 cenouras", "2 xícaras de farinha de trigo", "3 ovos", "1 xícara de açúcar"},
+			Instrucoes: "Bata a cenoura com os ovos.\n\nAsse por 40 minutos.", Tags: []string{"doce"}, Categoria: "bolo", Cozinha: "brasileira", TempoPreparo: minutos(20), TempoTotal: minutos(60), Dificuldade: "facil"},
+		{Nome: "açaí na tigela", Descricao: "Sobremesa gelada", Ingredientes: []string{"200 g de açaí", "1 banana", "granola a gosto"},
+			Instrucoes: "Bata o açaí com a banana.", Tags: []string{"doce", "rapida", "vegana"}, Cozinha: "brasileira", TempoPreparo: minutos(5), TempoTotal: minutos(5), Dificuldade: "facil"},
+		{Nome: "Espaguete ao sugo", Descricao: "Massa com molho de tomate", Ingredientes: []string{"500 g de espaguete", "4 tomates", "2 dentes de alho", "azeite a gosto"},
+			Instrucoes: "Cozinhe o espaguete.\n\nRefogue o alho e o tomate.", Tags: []string{"vegana"}, Categoria: "prato-principal", Cozinha: "italiana", TempoPreparo: minutos(15), TempoTotal: minutos(30), Dificuldade: "media"},
+		{Nome: "Bolo de chocolate", Descricao: "Bolo de chocolate com ovos", Ingredientes: []string{"2 xícaras de farinha de trigo", "1 xícara de chocolate em pó", "4 ovos"},
+			Instrucoes: "Misture tudo e asse o bolo.", Tags: []string{"doce"}, Categoria: "bolo", TempoTotal: minutos(50), Dificuldade: "media"},
+		{Nome: "Omelete", Descricao: "Omelete simples", Ingredientes: []string{"3 ovos", "sal a gosto"},
+			Instrucoes: "Bata os ovos e frite.", Tags: []string{"rapida"}, TempoPreparo: minutos(10), TempoTotal: minutos(10), Dificuldade: "facil"},
+		{Nome: "Risoto de tomate", Descricao: "Arroz cremoso", Ingredientes: []string{"2 xícaras de arroz", "3 tomates", "1 cebola"},
+			Instrucoes: "Refogue a cebola, junte o arroz e o tomate.", Categoria: "prato-principal", Cozinha: "italiana", TempoTotal: minutos(40), Dificuldade: "dificil"},
+	}
+	for _, receita := range receitas {
+		if err := receita.PrepararTempos(); err != nil {
+			t.Fatal(err)
+		}
+		if err := receita.PrepararIngredientes(); err != nil {
+			t.Fatal(err)
+		}
+		if err := receita.PrepararPassos(); err != nil {
+			t.Fatal(err)
+		}
+		receita.PrepararClassificacoes()
+		copia := receita
+		if err := sqlite.Criar(context.Background(), &receita); err != nil {
+			t.Fatal(err)
+		}
+		if err := memoria.Criar(context.Background(), &copia); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sqlite, memoria
+}
+
+// Todas as paginas da listagem, seguindo o cursor
+func listarTudo(t *testing.T, repositorio ReceitaRepository, busca string, consulta Consulta) (Pagina, []models.Receita) {
+	t.Helper()
+	ctx := context.Background()
+	var primeira Pagina
+	var receitas []models.Receita
+	for paginas := 0; ; paginas++ {
+		var pagina Pagina
+		var err error
+		if busca != "" {
+			pagina, err = repositorio.Buscar(ctx, busca, consulta)
+		} else {
+			pagina, err = repositorio.Listar(ctx, consulta)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if paginas == 0 {
+			primeira = pagina
+		}
+		if len(pagina.Receitas) > consulta.Limite || pagina.Total != primeira.Total {
+			t.Fatalf("pagina %d com %d receitas e total %d", paginas, len(pagina.Receitas), pagina.Total)
+		}
+		receitas = append(receitas, pagina.Receitas...)
+		if pagina.Proxima == nil {
+			return primeira, receitas
+		}
+		if paginas > 10 {
+			t.Fatal("o cursor nao avanca")
+		}
+		consulta.Cursor = pagina.Proxima
+	}
+}
+
+// A listagem em SQL retorna o mesmo que a Memoria, pagina a pagina
+func TestListarSQLite(t *testing.T) {
+	sqlite, memoria := receitasListagem(t)
+
+	casos := []struct {
+		nome     string
+		busca    string
+		consulta Consulta
+		total    int
+	}{
+		{nome: "por nome", consulta: Consulta{Ordem: Ordenacao{Campo: "nome"}}, total: 6},
+		{nome: "por nome decrescente", consulta: Consulta{Ordem: Ordenacao{Campo: "nome", Desc: true}}, total: 6},
+		{nome: "tags", consulta: Consulta{Tags: []string{"doce", "rapida"}, Ordem: Ordenacao{Campo: "nome"}}, total: 1},
+		{nome: "categoria e cozinha", consulta: Consulta{Categoria: "prato-principal", Cozinha: "italiana", Ordem: Ordenacao{Campo: "nome"}}, total: 2},
+		{nome: "tempo", consulta: Consulta{TempoMin: minutos(10), TempoMax: minutos(50), PreparoMax: minutos(15), Ordem: Ordenacao{Campo: "nome"}}, total: 2},
+		{nome: "dificuldade", consulta: Consulta{Dificuldades: []string{"media", "dificil"}, Ordem: Ordenacao{Campo: "nome"}}, total: 3},
+		{nome: "despensa", consulta: Consulta{Disponiveis: []string{"ovo", "farinha trigo", "tomate"}, FaltandoMax: 2, Ordem: Ordenacao{Campo: "faltando"}}, total: 5},
+		{nome: "busca", busca: "bolo", consulta: Consulta{Ordem: Ordenacao{Campo: "relevancia", Desc: true}}, total: 2},
+		{nome: "busca com exclusao", busca: `ovos -"farinha de trigo"`, consulta: Consulta{Ordem: Ordenacao{Campo: "relevancia", Desc: true}}, total: 1},
+		{nome: "busca com OR e filtro", busca: "tomate OR banana", consulta: Consulta{Tags: []string{"vegana"}, Ordem: Ordenacao{Campo: "nome"}}, total: 2},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			caso.consulta.Limite = 2
+			caso.consulta.Facetas = true
+			paginaSQLite, listadas := listarTudo(t, sqlite, caso.busca, caso.consulta)
+			paginaMemoria, esperadas := listarTudo(t, memoria, caso.busca, caso.consulta)
+
+			if paginaSQLite.Total != caso.total || paginaMemoria.Total != caso.total || len(listadas) != caso.total {
+				t.Fatalf("total %d no SQLite (%d listadas) e %d na Memoria, esperado %d", paginaSQLite.Total, len(listadas), paginaMemoria.Total, caso.total)
+			}
+			if !reflect.DeepEqual(contagemFacetas(paginaSQLite.Facetas), contagemFacetas(paginaMemoria.Facetas)) {
+				t.Errorf("facetas = %+v, esperado %+v", paginaSQLite.Facetas, paginaMemoria.Facetas)
+			}
+			// Os ids sao diferentes nos dois; a ordem e conferida pelo valor do campo de ordenacao
+			if valores, esperados := valoresOrdenacao(caso.consulta.Ordem, listadas), valoresOrdenacao(caso.consulta.Ordem, esperadas); !reflect.DeepEqual(valores, esperados) {
+				t.Fatalf("ordem %v, esperado %v", valores, esperados)
+			}
+			if nomes, esperados := conjuntoNomes(listadas), conjuntoNomes(esperadas); !reflect.DeepEqual(nomes, esperados) {
+				t.Fatalf("receitas %v, esperado %v", nomes, esperados)
+			}
+			for _, receita := range listadas {
+				esperada := esperadas[indiceNome(esperadas, receita.Nome)]
+				if !reflect.DeepEqual(receita.Busca, esperada.Busca) || !reflect.DeepEqual(receita.Despensa, esperada.Despensa) {
+					t.Errorf("%s: busca %+v e despensa %+v, esperado %+v e %+v", receita.Nome, receita.Busca, receita.Despensa, esperada.Busca, esperada.Despensa)
+				}
+			}
+		})
+	}
+}
+
+func contagemFacetas(facetas models.Facetas) [][]models.Faceta {
+	listas := [][]models.Faceta{facetas.Tags, facetas.Categorias, facetas.Cozinhas}
+	for _, lista := range listas {
+		for i := range lista {
+			lista[i].Nome = ""
+		}
+	}
+	return listas
+}
+
+func valoresOrdenacao(ordem Ordenacao, receitas []models.Receita) []any {
+	valores := []any{}
+	for _, receita := range receitas {
+		valores = append(valores, ordem.Valor(receita))
+	}
+	return valores
+}
+
+func conjuntoNomes(receitas []models.Receita) map[string]bool {
+	nomes := map[string]bool{}
+	for _, receita := range receitas {
+		nomes[receita.Nome] = true
+	}
+	return nomes
+}
+
+func indiceNome(receitas []models.Receita, nome string) int {
+	for i, receita := range receitas {
+		if receita.Nome == nome {
+			return i
+		}
+	}
+	return -1
+}
+
+// O cursor de criado_em e comparado com o texto gravado, inclusive com zeros nos milissegundos
+func TestListarSQLiteCriadoEm(t *testing.T) {
+	sqlite, _ := receitasListagem(t)
+	ctx := context.Background()
+	datas := []string{"2026-01-02 10:00:00.100+00:00", "2026-01-02 10:00:00.100+00:00", "2026-01-02 10:00:00.120+00:00",
+		"2026-01-02 10:00:01.000+00:00", "2026-01-03 09:00:00.000+00:00", "2026-01-03 09:00:00.005+00:00"}
+	_, err := sqlite.DBConnection.ExecContext(ctx, `UPDATE receitas SET criado_em = json_extract($1, '$[' || (SELECT count(*) FROM receitas r WHERE r.nome < receitas.nome) || ']')`,
+		`["`+strings.Join(datas, `","`)+`"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, desc := range []bool{false, true} {
+		consulta := Consulta{Ordem: Ordenacao{Campo: "criado_em", Desc: desc}, Limite: 1}
+		_, listadas := listarTudo(t, sqlite, "", consulta)
+		consulta.Limite = len(datas)
+		_, todas := listarTudo(t, sqlite, "", consulta)
+		if len(listadas) != len(datas) || !reflect.DeepEqual(listadas, todas) {
+			t.Fatalf("desc=%v: %d receitas paginando uma a uma, esperado %d", desc, len(listadas), len(datas))
+		}
+		for i := 1; i < len(todas); i++ {
+			anterior, atual := todas[i-1], todas[i]
+			if compararPosicao(consulta.Ordem, chaveOrdenacao(consulta.Ordem, anterior), anterior.ID.String(), chaveOrdenacao(consulta.Ordem, atual), atual.ID.String()) >= 0 {
+				t.Fatalf("desc=%v: %s (%s) antes de %s (%s)", desc, anterior.Nome, anterior.CriadoEm, atual.Nome, atual.CriadoEm)
+			}
+		}
+	}
+}
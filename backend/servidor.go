@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Bruno-Fagundes/crud-receitas-culinarias/metrics"
+)
+
+// Serve ate ctx ser cancelado (SIGINT ou SIGTERM). Entao chama desligando (que
+// faz o /readyz falhar), continua atendendo por atraso para o balanceador tirar
+// a instancia de rotacao, para de aceitar conexoes e espera as requisicoes em
+// andamento por ate prazo; as que passarem disso sao cortadas.
+func servir(ctx context.Context, server *http.Server, atraso, prazo time.Duration, desligando func()) error {
+	erros := make(chan error, 1)
+	go func() {
+		erros <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-erros:
+		return err
+	case <-ctx.Done():
+	}
+
+	desligando()
+	if atraso > 0 {
+		slog.Info("Servidor: Desligando, readiness em falha", "atraso", atraso)
+		select {
+		case err := <-erros:
+			return err
+		case <-time.After(atraso):
+		}
+	}
+
+	slog.Info("Servidor: Desligando, aguardando as requisições em andamento", "prazo", prazo)
+	desligamento, cancel := context.WithTimeout(context.Background(), prazo)
+	defer cancel()
+	if err := server.Shutdown(desligamento); err != nil {
+		server.Close()
+		return fmt.Errorf("requisições interrompidas após %s: %w", prazo, err)
+	}
+	if err := <-erros; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Atende GET /metrics no ouvinte, o endereco interno de METRICS_ADDR, ate ctx
+// ser cancelado. Fica fora do router publico, sem CORS nem autenticacao.
+func servirMetricas(ctx context.Context, ouvinte net.Listener) {
+	rotas := http.NewServeMux()
+	rotas.Handle("GET /metrics", metrics.Handler())
+	server := &http.Server{Handler: rotas, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	slog.Info("Servidor: Métricas em /metrics", "endereco", ouvinte.Addr().String())
+	if err := server.Serve(ouvinte); !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Servidor: Erro no servidor de métricas", "erro", err)
+	}
+}
+
+// Tarefas em segundo plano (limpeza de sessoes, rotacao de chaves) que param
+// juntas no desligamento, antes do banco ser fechado
+type tarefas struct {
+	ctx      context.Context
+	cancelar context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func novasTarefas() *tarefas {
+	ctx, cancelar := context.WithCancel(context.Background())
+	return &tarefas{ctx: ctx, cancelar: cancelar}
+}
+
+// Roda fn em uma goroutine com o contexto cancelado por parar
+func (grupo *tarefas) iniciar(fn func(ctx context.Context)) {
+	grupo.wg.Add(1)
+	go func() {
+		defer grupo.wg.Done()
+		fn(grupo.ctx)
+	}()
+}
+
+// Cancela os trabalhos e espera todos retornarem
+func (grupo *tarefas) parar() {
+	grupo.cancelar()
+	grupo.wg.Wait()
+}
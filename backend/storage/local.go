@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// Guarda os arquivos em um diretorio local. Padrao em desenvolvimento e em
+// instalacoes com uma unica replica.
+type Local struct {
+	Diretorio string
+	URLBase   string // Prefixo das URLs publicas, normalmente "/midia"
+}
+
+// Construtor de Local. Cria o diretorio se ele nao existir.
+func NewLocal(diretorio, urlBase string) (*Local, error) {
+	if err := os.MkdirAll(diretorio, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{Diretorio: diretorio, URLBase: urlBase}, nil
+}
+
+func (local *Local) caminho(chave string) (string, error) {
+	if err := ValidarChave(chave); err != nil {
+		return "", err
+	}
+	return filepath.Join(local.Diretorio, filepath.FromSlash(chave)), nil
+}
+
+// Grava em um arquivo temporario e renomeia, para que leituras nunca vejam arquivos pela metade
+func (local *Local) Salvar(ctx context.Context, chave string, conteudo []byte, contentType string) error {
+	destino, err := local.caminho(chave)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(destino), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(destino), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(conteudo); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), destino)
+}
+
+// O content type vem da extensao da chave
+func (local *Local) Abrir(ctx context.Context, chave string) (io.ReadCloser, string, error) {
+	origem, err := local.caminho(chave)
+	if err != nil {
+		return nil, "", err
+	}
+	arquivo, err := os.Open(origem)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", ErrNaoEncontrado
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return arquivo, mime.TypeByExtension(filepath.Ext(origem)), nil
+}
+
+func (local *Local) Remover(ctx context.Context, chave string) error {
+	alvo, err := local.caminho(chave)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(alvo); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (local *Local) URL(chave string) string {
+	return urlSobBase(local.URLBase, chave)
+}
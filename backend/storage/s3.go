@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Guarda os arquivos em um bucket compativel com S3 (AWS, MinIO, R2...), com
+// enderecamento por caminho (endpoint/bucket/chave) e assinatura AWS SigV4.
+// Com um MinIO local da para testar sem conta na AWS.
+type S3 struct {
+	Endpoint  string // Ex.: https://s3.us-east-1.amazonaws.com ou http://localhost:9000
+	Regiao    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Prefixo das URLs publicas. Vazio serve os arquivos pela API em /midia,
+	// o que funciona com buckets privados.
+	URLPublica string
+
+	client *http.Client
+}
+
+// Construtor de S3. Nao acessa o bucket.
+func NewS3(endpoint, regiao, bucket, accessKey, secretKey, urlPublica string) (*S3, error) {
+	endereco, err := url.Parse(endpoint)
+	if err != nil || (endereco.Scheme != "http" && endereco.Scheme != "https") || endereco.Host == "" {
+		return nil, fmt.Errorf("endpoint S3 inválido: %q", endpoint)
+	}
+	if bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, errors.New("bucket e credenciais do S3 são obrigatórios")
+	}
+	if regiao == "" {
+		regiao = "us-east-1"
+	}
+	return &S3{
+		Endpoint:   strings.TrimRight(endpoint, "/"),
+		Regiao:     regiao,
+		Bucket:     bucket,
+		AccessKey:  accessKey,
+		SecretKey:  secretKey,
+		URLPublica: urlPublica,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s3 *S3) Salvar(ctx context.Context, chave string, conteudo []byte, contentType string) error {
+	resp, err := s3.requisicao(ctx, http.MethodPut, chave, conteudo, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return erroS3(resp)
+	}
+	return nil
+}
+
+func (s3 *S3) Abrir(ctx context.Context, chave string) (io.ReadCloser, string, error) {
+	resp, err := s3.requisicao(ctx, http.MethodGet, chave, nil, "")
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, "", ErrNaoEncontrado
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, "", erroS3(resp)
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
+// O S3 responde 204 mesmo para chaves inexistentes
+func (s3 *S3) Remover(ctx context.Context, chave string) error {
+	resp, err := s3.requisicao(ctx, http.MethodDelete, chave, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return erroS3(resp)
+	}
+	return nil
+}
+
+func (s3 *S3) URL(chave string) string {
+	if s3.URLPublica == "" {
+		return urlSobBase("/midia", chave)
+	}
+	return urlSobBase(s3.URLPublica, chave)
+}
+
+func (s3 *S3) requisicao(ctx context.Context, metodo, chave string, corpo []byte, contentType string) (*http.Response, error) {
+	if err := ValidarChave(chave); err != nil {
+		return nil, err
+	}
+	caminho := "/" + escaparCaminho(s3.Bucket) + "/" + escaparCaminho(chave)
+	req, err := http.NewRequestWithContext(ctx, metodo, s3.Endpoint+caminho, bytes.NewReader(corpo))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s3.assinar(req, caminho, corpo, time.Now().UTC())
+	return s3.client.Do(req)
+}
+
+// Assinatura AWS Signature Version 4 com os cabecalhos host, x-amz-content-sha256 e x-amz-date
+func (s3 *S3) assinar(req *http.Request, caminho string, corpo []byte, agora time.Time) {
+	data := agora.Format("20060102")
+	momento := agora.Format("20060102T150405Z")
+	hashCorpo := hexSHA256(corpo)
+	req.Header.Set("X-Amz-Date", momento)
+	req.Header.Set("X-Amz-Content-Sha256", hashCorpo)
+
+	const cabecalhosAssinados = "host;x-amz-content-sha256;x-amz-date"
+	canonica := strings.Join([]string{
+		req.Method,
+		caminho,
+		"", // Sem query string
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + hashCorpo,
+		"x-amz-date:" + momento,
+		"",
+		cabecalhosAssinados,
+		hashCorpo,
+	}, "\n")
+
+	escopo := data + "/" + s3.Regiao + "/s3/aws4_request"
+	paraAssinar := "AWS4-HMAC-SHA256\n" + momento + "\n" + escopo + "\n" + hexSHA256([]byte(canonica))
+
+	chave := hmacSHA256([]byte("AWS4"+s3.SecretKey), data)
+	chave = hmacSHA256(chave, s3.Regiao)
+	chave = hmacSHA256(chave, "s3")
+	chave = hmacSHA256(chave, "aws4_request")
+	assinatura := hex.EncodeToString(hmacSHA256(chave, paraAssinar))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3.AccessKey, escopo, cabecalhosAssinados, assinatura))
+}
+
+func hmacSHA256(chave []byte, dados string) []byte {
+	mac := hmac.New(sha256.New, chave)
+	mac.Write([]byte(dados))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(dados []byte) string {
+	soma := sha256.Sum256(dados)
+	return hex.EncodeToString(soma[:])
+}
+
+// Codificacao de caminho do SigV4: tudo menos A-Z a-z 0-9 - _ . ~ e /
+func escaparCaminho(caminho string) string {
+	var b strings.Builder
+	for _, c := range []byte(caminho) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func erroS3(resp *http.Response) error {
+	detalhe, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("S3 respondeu %s: %s", resp.Status, strings.TrimSpace(string(detalhe)))
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	accessKeyTeste = "AKIDEXEMPLO"
+	secretKeyTeste = "segredo/de+teste"
+	regiaoTeste    = "sa-east-1"
+)
+
+// Bucket S3 em memoria que confere a assinatura SigV4 de cada requisicao
+type s3Falso struct {
+	t        *testing.T
+	mu       sync.Mutex
+	objetos  map[string][]byte
+	tipos    map[string]string
+	caminhos []string // Caminho escapado de cada requisicao, como chegou
+}
+
+func novoS3Falso(t *testing.T) (*S3, *s3Falso) {
+	falso := &s3Falso{t: t, objetos: map[string][]byte{}, tipos: map[string]string{}}
+	servidor := httptest.NewServer(falso)
+	t.Cleanup(servidor.Close)
+	s3, err := NewS3(servidor.URL, regiaoTeste, "receitas", accessKeyTeste, secretKeyTeste, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s3, falso
+}
+
+func (falso *s3Falso) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	corpo, _ := io.ReadAll(r.Body)
+	if motivo := conferirAssinatura(r, corpo); motivo != "" {
+		falso.t.Errorf("%s %s: %s", r.Method, r.URL.EscapedPath(), motivo)
+		http.Error(w, motivo, http.StatusForbidden)
+		return
+	}
+
+	falso.mu.Lock()
+	defer falso.mu.Unlock()
+	falso.caminhos = append(falso.caminhos, r.URL.EscapedPath())
+	chave := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		falso.objetos[chave] = corpo
+		falso.tipos[chave] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		conteudo, ok := falso.objetos[chave]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", falso.tipos[chave])
+		w.Write(conteudo)
+	case http.MethodDelete:
+		delete(falso.objetos, chave)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "metodo", http.StatusMethodNotAllowed)
+	}
+}
+
+// Refaz a assinatura como o S3 faria, a partir do que chegou na requisicao.
+// Retorna o motivo da recusa, ou vazio se a assinatura confere.
+func conferirAssinatura(r *http.Request, corpo []byte) string {
+	soma := sha256.Sum256(corpo)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(soma[:]) {
+		return "X-Amz-Content-Sha256 nao e o hash do corpo"
+	}
+	momento, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return "X-Amz-Date invalido: " + r.Header.Get("X-Amz-Date")
+	}
+	if desvio := time.Since(momento); desvio > time.Minute || desvio < -time.Minute {
+		return "X-Amz-Date fora do horario"
+	}
+
+	data := momento.Format("20060102")
+	escopo := data + "/" + regiaoTeste + "/s3/aws4_request"
+	canonica := r.Method + "\n" + r.URL.EscapedPath() + "\n\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + r.Header.Get("X-Amz-Content-Sha256") + "\n" +
+		"x-amz-date:" + r.Header.Get("X-Amz-Date") + "\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		r.Header.Get("X-Amz-Content-Sha256")
+	somaCanonica := sha256.Sum256([]byte(canonica))
+	paraAssinar := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + escopo + "\n" + hex.EncodeToString(somaCanonica[:])
+
+	chave := []byte("AWS4" + secretKeyTeste)
+	for _, parte := range []string{data, regiaoTeste, "s3", "aws4_request", paraAssinar} {
+		mac := hmac.New(sha256.New, chave)
+		mac.Write([]byte(parte))
+		chave = mac.Sum(nil)
+	}
+	esperado := "AWS4-HMAC-SHA256 Credential=" + accessKeyTeste + "/" + escopo +
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" + hex.EncodeToString(chave)
+	if r.Header.Get("Authorization") != esperado {
+		return "Authorization = " + r.Header.Get("Authorization") + ", esperado " + esperado
+	}
+	return ""
+}
+
+func TestS3(t *testing.T) {
+	ctx := context.Background()
+	s3, falso := novoS3Falso(t)
+	chave := "receitas/a1/b2/media_1.jpg"
+
+	if err := s3.Salvar(ctx, chave, []byte("conteudo"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	leitor, tipo, err := s3.Abrir(ctx, chave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conteudo, _ := io.ReadAll(leitor)
+	leitor.Close()
+	if !bytes.Equal(conteudo, []byte("conteudo")) || tipo != "image/jpeg" {
+		t.Fatalf("Abrir = %q (%s)", conteudo, tipo)
+	}
+
+	if err := s3.Remover(ctx, chave); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s3.Abrir(ctx, chave); !errors.Is(err, ErrNaoEncontrado) {
+		t.Fatalf("Abrir depois de Remover: erro %v, esperado ErrNaoEncontrado", err)
+	}
+	// Remover de novo nao e erro
+	if err := s3.Remover(ctx, chave); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, caminho := range falso.caminhos {
+		if caminho != "/receitas/"+chave {
+			t.Errorf("caminho %q, esperado %q", caminho, "/receitas/"+chave)
+		}
+	}
+	if len(falso.caminhos) != 5 {
+		t.Fatalf("%d requisicoes, esperado 5", len(falso.caminhos))
+	}
+}
+
+func TestS3ChaveInvalida(t *testing.T) {
+	s3, falso := novoS3Falso(t)
+	if err := s3.Salvar(context.Background(), "../fora.jpg", []byte("x"), "image/jpeg"); err == nil {
+		t.Fatal("chave com .. aceita")
+	}
+	if len(falso.caminhos) != 0 {
+		t.Fatalf("chave invalida chegou ao S3: %v", falso.caminhos)
+	}
+}
+
+func TestS3Erro(t *testing.T) {
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer servidor.Close()
+	s3, err := NewS3(servidor.URL, "", "receitas", accessKeyTeste, secretKeyTeste, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s3.Salvar(context.Background(), "receitas/a.jpg", []byte("x"), "image/jpeg")
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("erro %v, esperado o status e o corpo da resposta", err)
+	}
+	if _, _, err := s3.Abrir(context.Background(), "receitas/a.jpg"); err == nil || errors.Is(err, ErrNaoEncontrado) {
+		t.Fatalf("Abrir com 403: erro %v", err)
+	}
+}
+
+func TestEscaparCaminho(t *testing.T) {
+	casos := []struct {
+		caminho, esperado string
+	}{
+		{"receitas/a1/media.jpg", "receitas/a1/media.jpg"},
+		{"A-Z_a.z~0/9", "A-Z_a.z~0/9"},
+		{"com espaco+mais", "com%20espaco%2Bmais"},
+		{"ç/ã", "%C3%A7/%C3%A3"},
+		{"a=b&c?d", "a%3Db%26c%3Fd"},
+	}
+	for _, caso := range casos {
+		if escapado := escaparCaminho(caso.caminho); escapado != caso.esperado {
+			t.Errorf("escaparCaminho(%q) = %q, esperado %q", caso.caminho, escapado, caso.esperado)
+		}
+	}
+}
+
+func TestNewS3(t *testing.T) {
+	casos := []struct {
+		endpoint, bucket string
+	}{
+		{"localhost:9000", "receitas"},
+		{"ftp://localhost", "receitas"},
+		{"http://", "receitas"},
+		{"http://localhost:9000", ""},
+	}
+	for _, caso := range casos {
+		if _, err := NewS3(caso.endpoint, "", caso.bucket, accessKeyTeste, secretKeyTeste, ""); err == nil {
+			t.Errorf("NewS3(%q, %q) aceito", caso.endpoint, caso.bucket)
+		}
+	}
+
+	s3, err := NewS3("http://localhost:9000/", "", "receitas", accessKeyTeste, secretKeyTeste, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3.Regiao != "us-east-1" || s3.Endpoint != "http://localhost:9000" {
+		t.Fatalf("regiao %q e endpoint %q", s3.Regiao, s3.Endpoint)
+	}
+	if url := s3.URL("receitas/a.jpg"); url != "/midia/receitas/a.jpg" {
+		t.Fatalf("URL sem URLPublica = %q", url)
+	}
+	s3.URLPublica = "https://cdn.exemplo.com/"
+	if url := s3.URL("receitas/a.jpg"); url != "https://cdn.exemplo.com/receitas/a.jpg" {
+		t.Fatalf("URL = %q", url)
+	}
+}
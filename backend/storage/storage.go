@@ -0,0 +1,42 @@
+// Package storage guarda os arquivos enviados pelos usuarios (as imagens das
+// receitas) em disco ou em um bucket compativel com S3.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var ErrNaoEncontrado = errors.New("arquivo não encontrado")
+
+// Backend de armazenamento. As chaves sao caminhos relativos como
+// "receitas/<id>/<imagem>/media.jpg", gerados pela aplicacao.
+type Storage interface {
+	Salvar(ctx context.Context, chave string, conteudo []byte, contentType string) error
+	// Retorna o conteudo e o content type; ErrNaoEncontrado se a chave nao existir
+	Abrir(ctx context.Context, chave string) (io.ReadCloser, string, error)
+	// Remover uma chave inexistente nao e erro
+	Remover(ctx context.Context, chave string) error
+	// URL publica do arquivo
+	URL(chave string) string
+}
+
+var reChave = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*(?:/[A-Za-z0-9][A-Za-z0-9._-]*)*$`)
+
+// Rejeita chaves vazias, absolutas ou com ".." antes de chegarem ao backend
+func ValidarChave(chave string) error {
+	if !reChave.MatchString(chave) || path.Clean(chave) != chave || strings.Contains(chave, "..") {
+		return fmt.Errorf("chave inválida: %q", chave)
+	}
+	return nil
+}
+
+// URL de uma chave servida pela propria API (ver handlers.MidiaHandler)
+func urlSobBase(base, chave string) string {
+	return strings.TrimRight(base, "/") + "/" + chave
+}
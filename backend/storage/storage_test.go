@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidarChave(t *testing.T) {
+	casos := []struct {
+		chave  string
+		valida bool
+	}{
+		{"receitas/a1/b2/media.jpg", true},
+		{"foto.png", true},
+		{"receitas/a-b_c.d/original.webp", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"../fora.jpg", false},
+		{"receitas/../../fora.jpg", false},
+		{"receitas/..", false},
+		{"receitas/a..b.jpg", false},
+		{"receitas//a.jpg", false},
+		{"receitas/a.jpg/", false},
+		{"receitas/./a.jpg", false},
+		{".oculto", false},
+		{"receitas\\..\\fora.jpg", false},
+		{"receitas/a b.jpg", false},
+		{"receitas/a%2F..%2Fb.jpg", false},
+		{"receitas/ç.jpg", false},
+	}
+	for _, caso := range casos {
+		err := ValidarChave(caso.chave)
+		if (err == nil) != caso.valida {
+			t.Errorf("ValidarChave(%q) = %v, esperado valida=%v", caso.chave, err, caso.valida)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	diretorio := filepath.Join(t.TempDir(), "midia")
+	local, err := NewLocal(diretorio, "/midia/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chave := "receitas/a1/media.jpg"
+
+	if err := local.Salvar(ctx, chave, []byte("conteudo"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if dados, err := os.ReadFile(filepath.Join(diretorio, "receitas", "a1", "media.jpg")); err != nil || string(dados) != "conteudo" {
+		t.Fatalf("arquivo gravado = %q, erro %v", dados, err)
+	}
+	// O arquivo temporario do upload nao fica no diretorio
+	if entradas, _ := os.ReadDir(filepath.Join(diretorio, "receitas", "a1")); len(entradas) != 1 {
+		t.Fatalf("%d arquivos no diretorio, esperado 1", len(entradas))
+	}
+
+	leitor, tipo, err := local.Abrir(ctx, chave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dados, _ := io.ReadAll(leitor)
+	leitor.Close()
+	if string(dados) != "conteudo" || tipo != "image/jpeg" {
+		t.Fatalf("Abrir = %q (%s)", dados, tipo)
+	}
+	if url := local.URL(chave); url != "/midia/receitas/a1/media.jpg" {
+		t.Fatalf("URL = %q", url)
+	}
+
+	if err := local.Remover(ctx, chave); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := local.Abrir(ctx, chave); !errors.Is(err, ErrNaoEncontrado) {
+		t.Fatalf("Abrir depois de Remover: erro %v, esperado ErrNaoEncontrado", err)
+	}
+	if err := local.Remover(ctx, chave); err != nil {
+		t.Fatalf("Remover chave inexistente: %v", err)
+	}
+}
+
+// Nenhuma operacao alcanca arquivos fora do diretorio
+func TestLocalForaDoDiretorio(t *testing.T) {
+	ctx := context.Background()
+	raiz := t.TempDir()
+	diretorio := filepath.Join(raiz, "midia")
+	local, err := NewLocal(diretorio, "/midia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	segredo := filepath.Join(raiz, "segredo.txt")
+	if err := os.WriteFile(segredo, []byte("segredo"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []string{"../segredo.txt", "receitas/../../segredo.txt", segredo, "/segredo.txt", "..", ""}
+	for _, chave := range casos {
+		if err := local.Salvar(ctx, chave, []byte("x"), "text/plain"); err == nil {
+			t.Errorf("Salvar(%q) aceito", chave)
+		}
+		if leitor, _, err := local.Abrir(ctx, chave); err == nil {
+			leitor.Close()
+			t.Errorf("Abrir(%q) aceito", chave)
+		} else if errors.Is(err, ErrNaoEncontrado) {
+			t.Errorf("Abrir(%q) chegou ao disco", chave)
+		}
+		if err := local.Remover(ctx, chave); err == nil {
+			t.Errorf("Remover(%q) aceito", chave)
+		}
+	}
+	if dados, err := os.ReadFile(segredo); err != nil || string(dados) != "segredo" {
+		t.Fatalf("arquivo fora do diretorio alterado: %q, erro %v", dados, err)
+	}
+}
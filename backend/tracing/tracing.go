@@ -0,0 +1,67 @@
+// Package tracing configura o OpenTelemetry da API: o exportador dos spans
+// (OTLP, stdout ou nenhum) e a propagacao do contexto pelo cabecalho
+// traceparent. Os spans sao criados pelo middleware.Tracing, um por requisicao,
+// e pela conexao do pacote database, um por consulta SQL.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Exportadores aceitos em TRACING_EXPORTER
+const (
+	ExportadorNenhum = "none"
+	ExportadorStdout = "stdout"
+	ExportadorOTLP   = "otlp"
+)
+
+// Configura o TracerProvider global e devolve a funcao que envia os spans
+// pendentes e o encerra, chamada no desligamento. Com ExportadorNenhum nada e
+// exportado, mas o traceparent recebido continua sendo propagado (e o trace_id
+// continua saindo no log).
+//
+// No OTLP (HTTP/protobuf), endpoint e a URL completa do coletor, ex.:
+// http://localhost:4318/v1/traces. Vazio, valem as variaveis padrao
+// OTEL_EXPORTER_OTLP_* (endpoint, cabecalhos, certificados). A amostragem
+// segue OTEL_TRACES_SAMPLER e OTEL_TRACES_SAMPLER_ARG; o padrao registra tudo.
+func Configurar(ctx context.Context, exportador, endpoint, nomeServico string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exportador {
+	case ExportadorNenhum:
+		return func(context.Context) error { return nil }, nil
+	case ExportadorStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExportadorOTLP:
+		var opcoes []otlptracehttp.Option
+		if endpoint != "" {
+			opcoes = append(opcoes, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opcoes...)
+	default:
+		return nil, fmt.Errorf("exportador de traces inválido: %q (use %s, %s ou %s)", exportador, ExportadorNenhum, ExportadorStdout, ExportadorOTLP)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar o exportador de traces %s: %w", exportador, err)
+	}
+
+	recurso, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(nomeServico)))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao montar o resource dos traces: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(recurso))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,67 @@
+package units
+
+import "math"
+
+// Diferenca maxima aceita entre a quantidade exata e a arredondada
+// para que uma unidade maior seja escolhida
+const toleranciaArredondamento = 0.03
+
+// Fracoes usadas na cozinha, do menor para o maior
+var FracoesComuns = []struct {
+	Valor float64
+	Texto string
+}{{0, ""}, {1.0 / 8, "1/8"}, {1.0 / 4, "1/4"}, {1.0 / 3, "1/3"}, {1.0 / 2, "1/2"}, {2.0 / 3, "2/3"}, {3.0 / 4, "3/4"}, {1, ""}}
+
+// Arredonda uma quantidade para valores praticos: fracoes comuns para xicaras
+// e colheres, meias unidades para itens contados e passos proporcionais ao
+// tamanho para gramas e mililitros. Nunca arredonda uma quantidade positiva
+// para zero. u pode ser nil (ingrediente sem unidade, como "3 ovos").
+func Arredondar(valor float64, u *Unidade) float64 {
+	if valor <= 0 {
+		return 0
+	}
+
+	switch {
+	case u != nil && u.Dimensao == Temperatura:
+		return math.Round(valor/5) * 5
+
+	case u != nil && !u.Fracoes:
+		passo := 10.0
+		switch {
+		case valor < 1:
+			passo = 0.05
+		case valor < 10:
+			passo = 0.1
+		case valor < 100:
+			passo = 1
+		case valor < 1000:
+			passo = 5
+		}
+		return math.Max(passo, Casas(math.Round(valor/passo)*passo, 2))
+
+	case (u == nil || u.Dimensao == Contagem) && valor >= 5:
+		return math.Round(valor)
+
+	case (u == nil || u.Dimensao == Contagem) && valor >= 1:
+		return math.Round(valor*2) / 2
+	}
+
+	inteiro := math.Floor(valor)
+	resto := valor - inteiro
+	melhor := 0.0
+	for _, f := range FracoesComuns {
+		if math.Abs(resto-f.Valor) < math.Abs(resto-melhor) {
+			melhor = f.Valor
+		}
+	}
+	if inteiro+melhor == 0 {
+		return 1.0 / 8
+	}
+	return Casas(inteiro+melhor, 4)
+}
+
+// Arredonda para o numero de casas decimais informado
+func Casas(valor float64, casas int) float64 {
+	p := math.Pow(10, float64(casas))
+	return math.Round(valor*p) / p
+}
@@ -0,0 +1,104 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func unidade(t *testing.T, nome string) *Unidade {
+	t.Helper()
+	u, ok := Buscar(nome)
+	if !ok {
+		t.Fatalf("unidade %q nao cadastrada", nome)
+	}
+	return u
+}
+
+func TestArredondar(t *testing.T) {
+	casos := []struct {
+		valor    float64
+		unidade  string // vazio: sem unidade, como "3 ovos"
+		esperado float64
+	}{
+		// Fracoes comuns para xicaras e colheres
+		{0.2, "xícara", 0.25},
+		{0.3, "xícara", 0.3333},
+		{0.45, "xícara", 0.5},
+		{0.6, "xícara", 0.6667},
+		{0.9, "xícara", 1},
+		{1.1, "xícara", 1.125},
+		{1.4, "xícara", 1.3333},
+		{2.55, "xícara", 2.5},
+		{0.01, "xícara", 0.125}, // Nunca zero
+		// Passos proporcionais ao tamanho para unidades sem fracoes
+		{0.333, "g", 0.35},
+		{3.37, "g", 3.4},
+		{12.3, "g", 12},
+		{123, "g", 125},
+		{1234, "g", 1230},
+		{0.001, "ml", 0.05}, // Nunca zero
+		// Itens contados: meias unidades ate 5, inteiros depois
+		{1.4, "", 1.5},
+		{4.5, "", 4.5},
+		{9.7, "", 10},
+		{25.5, "", 26},
+		{0.1, "", 0.125},
+		{2.2, "dente", 2},
+		// Temperaturas de 5 em 5 graus
+		{177, "°C", 175},
+		{182.2, "°C", 180},
+		{356, "°F", 355},
+		// Zero e negativos viram zero
+		{0, "xícara", 0},
+		{-1, "g", 0},
+		{-3, "", 0},
+	}
+	for _, caso := range casos {
+		var u *Unidade
+		if caso.unidade != "" {
+			u = unidade(t, caso.unidade)
+		}
+		if arredondado := Arredondar(caso.valor, u); arredondado != caso.esperado {
+			t.Errorf("Arredondar(%v, %q) = %v, esperado %v", caso.valor, caso.unidade, arredondado, caso.esperado)
+		}
+	}
+}
+
+// Quantidades positivas nunca somem e ficam perto do valor exato
+func TestArredondarLimites(t *testing.T) {
+	for _, nome := range []string{"", "xícara", "colher de chá", "g", "ml", "kg", "l", "cup", "oz", "pitada"} {
+		var u *Unidade
+		if nome != "" {
+			u = unidade(t, nome)
+		}
+		for valor := 0.001; valor < 5000; valor *= 1.37 {
+			arredondado := Arredondar(valor, u)
+			if arredondado <= 0 {
+				t.Fatalf("Arredondar(%v, %q) = %v", valor, nome, arredondado)
+			}
+			// O menor valor arredondado (1/8 ou o menor passo) pode estar longe de
+			// quantidades minusculas; acima dele o erro fica em ate 1/6 do valor
+			if valor >= 1 && math.Abs(arredondado-valor) > valor/6 {
+				t.Fatalf("Arredondar(%v, %q) = %v, longe demais", valor, nome, arredondado)
+			}
+		}
+	}
+}
+
+func TestCasas(t *testing.T) {
+	casos := []struct {
+		valor    float64
+		casas    int
+		esperado float64
+	}{
+		{1.0 / 3, 4, 0.3333},
+		{2.0 / 3, 2, 0.67},
+		{254.99999999999997, 0, 255},
+		{1.005, 1, 1},
+	}
+	for _, caso := range casos {
+		if valor := Casas(caso.valor, caso.casas); valor != caso.esperado {
+			t.Errorf("Casas(%v, %d) = %v, esperado %v", caso.valor, caso.casas, valor, caso.esperado)
+		}
+	}
+}
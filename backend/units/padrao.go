@@ -0,0 +1,104 @@
+package units
+
+// Unidades e densidades do registro padrao. Medidas caseiras seguem o padrao
+// brasileiro (xicara de 240 ml, colher de sopa de 15 ml); as imperiais seguem
+// o padrao americano.
+func init() {
+	for _, u := range []Unidade{
+		// Medidas caseiras
+		{Nome: "xícara", Plural: "xícaras", Fracoes: true, Dimensao: Volume, Sistema: Caseiro, Fator: 240, Automatica: true, Minimo: 0.25,
+			Aliases: []string{"xícara de chá", "xícaras de chá", "xícara (chá)", "xícaras (chá)", "xíc.", "xíc"}},
+		{Nome: "colher de sopa", Plural: "colheres de sopa", Fracoes: true, Dimensao: Volume, Sistema: Caseiro, Fator: 15, Automatica: true, Minimo: 1,
+			Aliases: []string{"colher (sopa)", "colheres (sopa)", "c. sopa", "c. de sopa", "csp"}},
+		{Nome: "colher de sobremesa", Plural: "colheres de sobremesa", Fracoes: true, Dimensao: Volume, Sistema: Caseiro, Fator: 10,
+			Aliases: []string{"colher (sobremesa)", "colheres (sobremesa)"}},
+		{Nome: "colher de chá", Plural: "colheres de chá", Fracoes: true, Dimensao: Volume, Sistema: Caseiro, Fator: 5, Automatica: true,
+			Aliases: []string{"colher (chá)", "colheres (chá)", "c. chá", "c. de chá", "cch"}},
+		{Nome: "colher", Plural: "colheres", Fracoes: true, Dimensao: Volume, Sistema: Caseiro, Fator: 15},
+		{Nome: "copo americano", Plural: "copos americanos", Fracoes: true, Dimensao: Volume, Sistema: Caseiro, Fator: 190,
+			Aliases: []string{"copo (americano)", "copos (americano)"}},
+		{Nome: "copo", Plural: "copos", Fracoes: true, Dimensao: Volume, Sistema: Caseiro, Fator: 250},
+
+		// Metrico
+		{Nome: "ml", Plural: "ml", Dimensao: Volume, Sistema: Metrico, Fator: 1, Automatica: true, Aliases: []string{"mililitro", "mililitros"}},
+		{Nome: "l", Plural: "l", Dimensao: Volume, Sistema: Metrico, Fator: 1000, Automatica: true, Minimo: 1, Aliases: []string{"litro", "litros", "lt"}},
+		{Nome: "mg", Plural: "mg", Dimensao: Massa, Sistema: Metrico, Fator: 0.001, Aliases: []string{"miligrama", "miligramas"}},
+		{Nome: "g", Plural: "g", Dimensao: Massa, Sistema: Metrico, Fator: 1, Automatica: true, Aliases: []string{"grama", "gramas", "gr"}},
+		{Nome: "kg", Plural: "kg", Fracoes: true, Dimensao: Massa, Sistema: Metrico, Fator: 1000, Automatica: true, Minimo: 1,
+			Aliases: []string{"quilo", "quilos", "quilograma", "quilogramas", "kilo", "kilos"}},
+
+		// Imperial (EUA)
+		{Nome: "cup", Plural: "cups", Fracoes: true, Dimensao: Volume, Sistema: Imperial, Fator: 236.588, Automatica: true, Minimo: 0.25},
+		{Nome: "tbsp", Plural: "tbsp", Fracoes: true, Dimensao: Volume, Sistema: Imperial, Fator: 14.787, Automatica: true, Minimo: 1,
+			Aliases: []string{"tablespoon", "tablespoons"}},
+		{Nome: "tsp", Plural: "tsp", Fracoes: true, Dimensao: Volume, Sistema: Imperial, Fator: 4.929, Automatica: true,
+			Aliases: []string{"teaspoon", "teaspoons"}},
+		{Nome: "fl oz", Plural: "fl oz", Fracoes: true, Dimensao: Volume, Sistema: Imperial, Fator: 29.5735, Aliases: []string{"fluid ounce", "fluid ounces"}},
+		{Nome: "pint", Plural: "pints", Fracoes: true, Dimensao: Volume, Sistema: Imperial, Fator: 473.176},
+		{Nome: "quart", Plural: "quarts", Fracoes: true, Dimensao: Volume, Sistema: Imperial, Fator: 946.353},
+		{Nome: "gallon", Plural: "gallons", Fracoes: true, Dimensao: Volume, Sistema: Imperial, Fator: 3785.41},
+		{Nome: "oz", Plural: "oz", Fracoes: true, Dimensao: Massa, Sistema: Imperial, Fator: 28.3495, Automatica: true,
+			Aliases: []string{"onça", "onças", "ounce", "ounces"}},
+		{Nome: "lb", Plural: "lb", Fracoes: true, Dimensao: Massa, Sistema: Imperial, Fator: 453.592, Automatica: true, Minimo: 1,
+			Aliases: []string{"libra", "libras", "lbs", "pound", "pounds"}},
+
+		// Temperatura, com base em °C
+		{Nome: "°C", Plural: "°C", Dimensao: Temperatura, Sistema: Metrico, Fator: 1, Automatica: true,
+			Aliases: []string{"ºC", "celsius", "graus celsius"}},
+		{Nome: "°F", Plural: "°F", Dimensao: Temperatura, Sistema: Imperial, Fator: 5.0 / 9, Deslocamento: -160.0 / 9, Automatica: true,
+			Aliases: []string{"ºF", "fahrenheit", "graus fahrenheit"}},
+
+		// Contagem
+		{Nome: "pitada", Plural: "pitadas", Fracoes: true},
+		{Nome: "dente", Plural: "dentes", Fracoes: true},
+		{Nome: "lata", Plural: "latas", Fracoes: true},
+		{Nome: "caixa", Plural: "caixas", Fracoes: true, Aliases: []string{"caixinha", "caixinhas"}},
+		{Nome: "pacote", Plural: "pacotes", Fracoes: true},
+		{Nome: "envelope", Plural: "envelopes", Fracoes: true},
+		{Nome: "tablete", Plural: "tabletes", Fracoes: true},
+		{Nome: "fatia", Plural: "fatias", Fracoes: true},
+		{Nome: "maço", Plural: "maços", Fracoes: true},
+		{Nome: "ramo", Plural: "ramos", Fracoes: true},
+		{Nome: "folha", Plural: "folhas", Fracoes: true},
+		{Nome: "unidade", Plural: "unidades", Fracoes: true, Aliases: []string{"un", "un."}},
+	} {
+		Padrao.Registrar(u)
+	}
+
+	// Densidades aproximadas em g/ml, a partir das medidas usuais por xicara
+	for nome, d := range map[string]Densidade{
+		"água":                  {1.0, true},
+		"leite":                 {1.03, true},
+		"óleo":                  {0.92, true},
+		"azeite":                {0.91, true},
+		"creme de leite":        {1.0, true},
+		"leite condensado":      {1.3, true},
+		"mel":                   {1.42, true},
+		"vinagre":               {1.01, true},
+		"suco":                  {1.04, true},
+		"caldo":                 {1.0, true},
+		"vinho":                 {0.99, true},
+		"farinha":               {0.5, false},
+		"farinha de trigo":      {0.5, false},
+		"farinha de rosca":      {0.45, false},
+		"amido de milho":        {0.5, false},
+		"fubá":                  {0.58, false},
+		"polvilho":              {0.6, false},
+		"açúcar":                {0.83, false},
+		"açúcar mascavo":        {0.75, false},
+		"açúcar de confeiteiro": {0.5, false},
+		"manteiga":              {0.9, false},
+		"margarina":             {0.9, false},
+		"sal":                   {1.2, false},
+		"arroz":                 {0.8, false},
+		"aveia":                 {0.35, false},
+		"chocolate em pó":       {0.38, false},
+		"cacau em pó":           {0.38, false},
+		"coco ralado":           {0.33, false},
+		"queijo ralado":         {0.4, false},
+		"fermento":              {0.9, false},
+		"bicarbonato":           {0.92, false},
+	} {
+		Padrao.RegistrarDensidade(nome, d)
+	}
+}
@@ -0,0 +1,58 @@
+package units
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// "180 °C", "350ºF", "180 graus", "180 graus celsius"
+var reTemperatura = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:[°º]\s*([CF])\b|graus(?:\s+(celsius|fahrenheit))?)`)
+
+// Reescreve as temperaturas de um texto livre (como as instrucoes de uma receita)
+// no sistema pedido. "Graus" sem escala e considerado Celsius.
+func ConverterTemperaturas(texto string, sistema Sistema) string {
+	if sistema == Caseiro {
+		sistema = Metrico
+	}
+	destino := Padrao.automaticas(Temperatura, sistema)
+	if len(destino) == 0 {
+		return texto
+	}
+
+	return reTemperatura.ReplaceAllStringFunc(texto, func(trecho string) string {
+		valor, origem, ok := lerTemperatura(reTemperatura.FindStringSubmatch(trecho))
+		if !ok || origem == destino[0] {
+			return trecho
+		}
+
+		convertido, err := Padrao.Converter(valor, origem, destino[0], "")
+		if err != nil {
+			return trecho
+		}
+		return strconv.FormatFloat(Arredondar(convertido, destino[0]), 'f', 0, 64) + " " + destino[0].Nome
+	})
+}
+
+// Primeira temperatura mencionada no texto, com a unidade (°C ou °F)
+func PrimeiraTemperatura(texto string) (float64, *Unidade, bool) {
+	m := reTemperatura.FindStringSubmatch(texto)
+	if m == nil {
+		return 0, nil, false
+	}
+	return lerTemperatura(m)
+}
+
+// Valor e escala de um resultado de reTemperatura
+func lerTemperatura(m []string) (float64, *Unidade, bool) {
+	valor, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, nil, false
+	}
+	escala := "°C"
+	if strings.EqualFold(m[2], "F") || strings.EqualFold(m[3], "fahrenheit") {
+		escala = "°F"
+	}
+	u, ok := Padrao.Buscar(escala)
+	return valor, u, ok
+}
@@ -0,0 +1,59 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConverterTemperaturas(t *testing.T) {
+	casos := []struct {
+		texto    string
+		sistema  Sistema
+		esperado string
+	}{
+		{"Asse a 180 °C por 40 minutos", Imperial, "Asse a 355 °F por 40 minutos"},
+		{"Asse a 180 °C por 40 minutos", Metrico, "Asse a 180 °C por 40 minutos"},
+		{"Forno a 350ºF", Metrico, "Forno a 175 °C"},
+		{"Forno a 350ºF", Caseiro, "Forno a 175 °C"},
+		{"Aqueça a 200 graus", Imperial, "Aqueça a 390 °F"},
+		{"a 180 graus celsius, depois a 400 graus fahrenheit", Metrico, "a 180 graus celsius, depois a 205 °C"},
+		{"Deixe por 2 horas", Imperial, "Deixe por 2 horas"},
+	}
+	for _, caso := range casos {
+		if texto := ConverterTemperaturas(caso.texto, caso.sistema); texto != caso.esperado {
+			t.Errorf("ConverterTemperaturas(%q, %s) = %q, esperado %q", caso.texto, caso.sistema, texto, caso.esperado)
+		}
+	}
+}
+
+// As temperaturas de forno, de 5 em 5 °C, voltam iguais depois de passar por °F.
+// No sentido contrario o arredondamento em °C pode mover o valor em um passo.
+func TestTemperaturaIdaEVolta(t *testing.T) {
+	celsius, fahrenheit := unidade(t, "°C"), unidade(t, "°F")
+	for c := 100.0; c <= 260; c += 5 {
+		f, _ := Padrao.Converter(c, celsius, fahrenheit, "")
+		f = Arredondar(f, fahrenheit)
+		volta, _ := Padrao.Converter(f, fahrenheit, celsius, "")
+		if volta = Arredondar(volta, celsius); volta != c {
+			t.Errorf("%v °C -> %v °F -> %v °C", c, f, volta)
+		}
+	}
+	for f := 200.0; f <= 500; f += 5 {
+		c, _ := Padrao.Converter(f, fahrenheit, celsius, "")
+		c = Arredondar(c, celsius)
+		volta, _ := Padrao.Converter(c, celsius, fahrenheit, "")
+		if volta = Arredondar(volta, fahrenheit); math.Abs(volta-f) > 5 {
+			t.Errorf("%v °F -> %v °C -> %v °F", f, c, volta)
+		}
+	}
+}
+
+func TestPrimeiraTemperatura(t *testing.T) {
+	valor, u, ok := PrimeiraTemperatura("Pré-aqueça o forno a 350 °F e asse a 180 °C")
+	if !ok || valor != 350 || u.Nome != "°F" {
+		t.Fatalf("PrimeiraTemperatura = %v %v %v", valor, u, ok)
+	}
+	if _, _, ok := PrimeiraTemperatura("Misture bem"); ok {
+		t.Fatal("temperatura encontrada em texto sem temperatura")
+	}
+}
@@ -0,0 +1,277 @@
+// Package units converte quantidades entre unidades de volume, massa e
+// temperatura, incluindo as medidas caseiras brasileiras (xicara, colher de sopa)
+// e a conversao volume <-> massa pela densidade de cada ingrediente.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Dimensao string
+
+const (
+	Contagem    Dimensao = "" // Lata, dente, pitada... nao convertem
+	Volume      Dimensao = "volume"
+	Massa       Dimensao = "massa"
+	Temperatura Dimensao = "temperatura"
+)
+
+type Sistema string
+
+const (
+	Metrico  Sistema = "metrico"
+	Imperial Sistema = "imperial"
+	Caseiro  Sistema = "caseiro" // Xicaras e colheres
+)
+
+// Valida o nome de um sistema vindo da API
+func ParseSistema(nome string) (Sistema, error) {
+	switch s := Sistema(strings.ToLower(strings.TrimSpace(nome))); s {
+	case Metrico, Imperial, Caseiro:
+		return s, nil
+	}
+	return "", fmt.Errorf("sistema de unidades inválido: %q (use metrico, imperial ou caseiro)", nome)
+}
+
+// Unidade de medida. O valor na unidade base da dimensao (ml, g ou °C)
+// e valor*Fator + Deslocamento.
+type Unidade struct {
+	Nome         string
+	Plural       string
+	Aliases      []string
+	Fracoes      bool // Quantidades exibidas como fracao (1/2) em vez de decimal (0,5)
+	Dimensao     Dimensao
+	Sistema      Sistema
+	Fator        float64
+	Deslocamento float64
+	// Unidades automaticas podem ser escolhidas nas conversoes e na escala,
+	// a partir da quantidade Minimo. As demais (copo, colher de sobremesa)
+	// so aparecem quando o usuario as escreve.
+	Automatica bool
+	Minimo     float64
+}
+
+func (u *Unidade) paraBase(valor float64) float64 {
+	return valor*u.Fator + u.Deslocamento
+}
+
+func (u *Unidade) daBase(valor float64) float64 {
+	return (valor - u.Deslocamento) / u.Fator
+}
+
+// Densidade de um ingrediente em g/ml
+type Densidade struct {
+	GPorMl  float64
+	Liquido bool // Liquidos continuam em volume no sistema metrico
+}
+
+// Registro de unidades e densidades
+type Registro struct {
+	unidades   []*Unidade
+	aliases    map[string]*Unidade
+	densidades map[string]Densidade
+}
+
+// Cria um registro vazio
+func NovoRegistro() *Registro {
+	return &Registro{aliases: map[string]*Unidade{}, densidades: map[string]Densidade{}}
+}
+
+// Adiciona uma unidade; o nome, o plural e os aliases passam a identifica-la
+func (reg *Registro) Registrar(u Unidade) {
+	unidade := &u
+	reg.unidades = append(reg.unidades, unidade)
+	for _, alias := range append([]string{u.Nome, u.Plural}, u.Aliases...) {
+		reg.aliases[Normalizar(alias)] = unidade
+	}
+}
+
+// Define a densidade de um ingrediente (nome sem acento e no singular)
+func (reg *Registro) RegistrarDensidade(ingrediente string, densidade Densidade) {
+	reg.densidades[Normalizar(ingrediente)] = densidade
+}
+
+// Busca uma unidade pelo nome, plural ou alias, sem diferenciar acentos e maiusculas
+func (reg *Registro) Buscar(nome string) (*Unidade, bool) {
+	u, ok := reg.aliases[Normalizar(nome)]
+	return u, ok
+}
+
+// Busca a densidade pelo trecho mais longo do nome que tenha densidade cadastrada,
+// assim "farinha de trigo peneirada" usa a densidade de "farinha de trigo"
+func (reg *Registro) Densidade(ingrediente string) (Densidade, bool) {
+	palavras := strings.Fields(Normalizar(ingrediente))
+	for n := len(palavras); n > 0; n-- {
+		for inicio := 0; inicio+n <= len(palavras); inicio++ {
+			trecho := strings.Join(palavras[inicio:inicio+n], " ")
+			if d, ok := reg.densidades[trecho]; ok {
+				return d, true
+			}
+			if d, ok := reg.densidades[Singular(trecho)]; ok {
+				return d, true
+			}
+		}
+	}
+	return Densidade{}, false
+}
+
+// Converte valor entre duas unidades. Entre volume e massa usa a densidade do ingrediente.
+func (reg *Registro) Converter(valor float64, de, para *Unidade, ingrediente string) (float64, error) {
+	if de.Dimensao == Contagem || para.Dimensao == Contagem {
+		return 0, fmt.Errorf("%s e %s não são conversíveis", de.Nome, para.Nome)
+	}
+	base := de.paraBase(valor)
+
+	if de.Dimensao != para.Dimensao {
+		d, ok := reg.Densidade(ingrediente)
+		switch {
+		case !ok:
+			return 0, fmt.Errorf("densidade de %q desconhecida para converter %s em %s", ingrediente, de.Nome, para.Nome)
+		case de.Dimensao == Volume && para.Dimensao == Massa:
+			base *= d.GPorMl
+		case de.Dimensao == Massa && para.Dimensao == Volume:
+			base /= d.GPorMl
+		default:
+			return 0, fmt.Errorf("%s e %s não são conversíveis", de.Nome, para.Nome)
+		}
+	}
+	return para.daBase(base), nil
+}
+
+// Unidades automaticas de uma dimensao e sistema, da menor para a maior
+func (reg *Registro) automaticas(dimensao Dimensao, sistema Sistema) []*Unidade {
+	var lista []*Unidade
+	for _, u := range reg.unidades {
+		if u.Automatica && u.Dimensao == dimensao && u.Sistema == sistema {
+			lista = append(lista, u)
+		}
+	}
+	// Poucas unidades por cadeia; insercao simples pelo tamanho
+	for i := 1; i < len(lista); i++ {
+		for j := i; j > 0 && lista[j].Fator < lista[j-1].Fator; j-- {
+			lista[j], lista[j-1] = lista[j-1], lista[j]
+		}
+	}
+	return lista
+}
+
+// Escolhe a maior unidade automatica do sistema em que a quantidade (dada na
+// unidade u) fica legivel depois de arredondada. Retorna nil se nao houver
+// unidades automaticas para a dimensao nesse sistema.
+func (reg *Registro) MelhorUnidade(valor float64, u *Unidade, sistema Sistema) *Unidade {
+	cadeia := reg.automaticas(u.Dimensao, sistema)
+	if len(cadeia) == 0 {
+		return nil
+	}
+	if u.Dimensao == Temperatura {
+		return cadeia[0]
+	}
+
+	base := u.paraBase(valor)
+	for i := len(cadeia) - 1; i > 0; i-- {
+		candidata := cadeia[i]
+		v := candidata.daBase(base)
+		if v >= candidata.Minimo && math.Abs(Arredondar(v, candidata)-v) <= toleranciaArredondamento*v {
+			return candidata
+		}
+	}
+	return cadeia[0]
+}
+
+// Converte uma quantidade para o sistema pedido escolhendo a unidade mais legivel.
+// No metrico, ingredientes secos com densidade conhecida vao para gramas; no caseiro,
+// massas com densidade conhecida vao para xicaras e colheres. Sem conversao possivel,
+// retorna a propria unidade.
+func (reg *Registro) ParaSistema(valor float64, u *Unidade, sistema Sistema, ingrediente string) (float64, *Unidade) {
+	if u.Dimensao == Contagem {
+		return valor, u
+	}
+	if u.Dimensao == Temperatura && sistema == Caseiro {
+		sistema = Metrico
+	}
+
+	dimensao := u.Dimensao
+	if d, ok := reg.Densidade(ingrediente); ok {
+		switch {
+		case sistema == Metrico && u.Dimensao == Volume && !d.Liquido:
+			dimensao = Massa
+		case sistema == Caseiro && u.Dimensao == Massa:
+			dimensao = Volume
+		}
+	}
+
+	cadeia := reg.automaticas(dimensao, sistema)
+	if len(cadeia) == 0 {
+		// O caseiro nao tem unidades de massa: sem densidade, usa o metrico
+		if sistema == Caseiro && dimensao == Massa {
+			return reg.ParaSistema(valor, u, Metrico, ingrediente)
+		}
+		return valor, u
+	}
+
+	convertido, err := reg.Converter(valor, u, cadeia[0], ingrediente)
+	if err != nil {
+		return valor, u
+	}
+	destino := reg.MelhorUnidade(convertido, cadeia[0], sistema)
+	final, err := reg.Converter(convertido, cadeia[0], destino, ingrediente)
+	if err != nil {
+		return convertido, cadeia[0]
+	}
+	return final, destino
+}
+
+// Registro usado pela aplicacao, com as unidades de padrao.go
+var Padrao = NovoRegistro()
+
+// Busca uma unidade no registro padrao
+func Buscar(nome string) (*Unidade, bool) {
+	return Padrao.Buscar(nome)
+}
+
+var removedorAcentos = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "î", "i", "ì", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+	"ú", "u", "û", "u", "ù", "u", "ü", "u",
+	"ç", "c",
+)
+
+// Minusculo, sem acentos e com espacos simples
+func Normalizar(texto string) string {
+	return strings.Join(strings.Fields(removedorAcentos.Replace(strings.ToLower(texto))), " ")
+}
+
+// Singular simples de um texto ja normalizado, palavra por palavra
+// ("ovos" -> "ovo", "limoes" -> "limao", "farinhas de trigo" -> "farinha de trigo")
+func Singular(texto string) string {
+	palavras := strings.Fields(texto)
+	for i, p := range palavras {
+		palavras[i] = singularPalavra(p)
+	}
+	return strings.Join(palavras, " ")
+}
+
+func singularPalavra(p string) string {
+	if len(p) <= 3 {
+		return p
+	}
+	switch {
+	case strings.HasSuffix(p, "oes"), strings.HasSuffix(p, "aes"):
+		return p[:len(p)-3] + "ao"
+	case strings.HasSuffix(p, "ais"):
+		return p[:len(p)-3] + "al"
+	case strings.HasSuffix(p, "eis"):
+		return p[:len(p)-3] + "el"
+	case strings.HasSuffix(p, "res"), strings.HasSuffix(p, "zes"):
+		return p[:len(p)-2]
+	case strings.HasSuffix(p, "ns"):
+		return p[:len(p)-2] + "m"
+	case strings.HasSuffix(p, "s") && !strings.HasSuffix(p, "ss") && !strings.HasSuffix(p, "is") && !strings.HasSuffix(p, "us"):
+		return p[:len(p)-1]
+	}
+	return p
+}
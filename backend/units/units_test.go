@@ -0,0 +1,143 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConverter(t *testing.T) {
+	casos := []struct {
+		valor       float64
+		de, para    string
+		ingrediente string
+		esperado    float64
+	}{
+		{16, "colher de sopa", "xícara", "", 1},
+		{3, "colher de chá", "colher de sopa", "", 1},
+		{1, "xícara", "ml", "", 240},
+		{1.5, "kg", "g", "", 1500},
+		{2500, "ml", "l", "", 2.5},
+		{1, "lb", "g", "", 453.592},
+		{1, "cup", "ml", "", 236.588},
+		// Entre volume e massa pela densidade do ingrediente
+		{1, "xícara", "g", "farinha de trigo", 120},
+		{1, "xícara", "g", "farinha de trigo peneirada", 120},
+		{200, "g", "ml", "açúcar", 200 / 0.83},
+		{100, "°C", "°F", "", 212},
+		{32, "°F", "°C", "", 0},
+	}
+	for _, caso := range casos {
+		convertido, err := Padrao.Converter(caso.valor, unidade(t, caso.de), unidade(t, caso.para), caso.ingrediente)
+		if err != nil {
+			t.Errorf("%v %s para %s: %v", caso.valor, caso.de, caso.para, err)
+			continue
+		}
+		if math.Abs(convertido-caso.esperado) > 1e-9 {
+			t.Errorf("%v %s de %q = %v %s, esperado %v", caso.valor, caso.de, caso.ingrediente, convertido, caso.para, caso.esperado)
+		}
+	}
+
+	erros := []struct{ de, para, ingrediente string }{
+		{"xícara", "g", "ingrediente sem densidade"},
+		{"dente", "g", "alho"},
+		{"xícara", "°C", "leite"},
+	}
+	for _, caso := range erros {
+		if _, err := Padrao.Converter(1, unidade(t, caso.de), unidade(t, caso.para), caso.ingrediente); err == nil {
+			t.Errorf("%s para %s de %q convertido sem erro", caso.de, caso.para, caso.ingrediente)
+		}
+	}
+}
+
+func TestMelhorUnidade(t *testing.T) {
+	casos := []struct {
+		valor    float64
+		unidade  string
+		esperado string
+	}{
+		{16, "colher de sopa", "xícara"},
+		{3, "colher de chá", "colher de sopa"},
+		{2, "colher de chá", "colher de chá"},
+		{1500, "g", "kg"},
+		{900, "g", "g"}, // Abaixo de 1 kg
+		{1500, "ml", "l"},
+		{48, "tsp", "cup"},
+		{20, "oz", "lb"},
+		{350, "°F", "°F"},
+	}
+	for _, caso := range casos {
+		u := unidade(t, caso.unidade)
+		if melhor := Padrao.MelhorUnidade(caso.valor, u, u.Sistema); melhor == nil || melhor.Nome != caso.esperado {
+			t.Errorf("MelhorUnidade(%v %s) = %v, esperado %s", caso.valor, caso.unidade, melhor, caso.esperado)
+		}
+	}
+}
+
+func TestParaSistema(t *testing.T) {
+	casos := []struct {
+		valor       float64
+		unidade     string
+		ingrediente string
+		sistema     Sistema
+		esperado    float64 // Depois de Arredondar
+		destino     string
+	}{
+		{1, "xícara", "leite", Metrico, 240, "ml"},
+		{2, "xícara", "farinha de trigo", Metrico, 240, "g"}, // Seco vai para gramas
+		{1, "xícara", "leite", Imperial, 1, "cup"},
+		{240, "ml", "leite", Caseiro, 1, "xícara"},
+		{240, "g", "farinha de trigo", Caseiro, 2, "xícara"},
+		{500, "g", "carne", Caseiro, 500, "g"}, // Sem densidade o caseiro usa o metrico
+		{1, "lb", "carne", Metrico, 455, "g"},
+		{500, "g", "carne", Imperial, 1.125, "lb"},
+		{180, "°C", "", Imperial, 355, "°F"},
+		{350, "°F", "", Caseiro, 175, "°C"},
+		{3, "dente", "alho", Imperial, 3, "dente"},
+	}
+	for _, caso := range casos {
+		valor, destino := Padrao.ParaSistema(caso.valor, unidade(t, caso.unidade), caso.sistema, caso.ingrediente)
+		if arredondado := Arredondar(valor, destino); destino.Nome != caso.destino || arredondado != caso.esperado {
+			t.Errorf("%v %s de %q no %s = %v %s, esperado %v %s", caso.valor, caso.unidade, caso.ingrediente, caso.sistema,
+				arredondado, destino.Nome, caso.esperado, caso.destino)
+		}
+	}
+}
+
+// Medidas caseiras levadas a outro sistema e trazidas de volta voltam iguais
+func TestParaSistemaIdaEVolta(t *testing.T) {
+	casos := []struct {
+		valor       float64
+		unidade     string
+		ingrediente string
+	}{
+		{1, "xícara", "leite"},
+		{0.5, "xícara", "leite"},
+		{2, "xícara", "farinha de trigo"},
+		{1, "xícara", "açúcar"},
+		{3, "colher de sopa", "azeite"},
+		{1, "colher de chá", "água"},
+	}
+	for _, caso := range casos {
+		for _, sistema := range []Sistema{Metrico, Imperial} {
+			u := unidade(t, caso.unidade)
+			ida, destino := Padrao.ParaSistema(caso.valor, u, sistema, caso.ingrediente)
+			ida = Arredondar(ida, destino)
+			volta, origem := Padrao.ParaSistema(ida, destino, Caseiro, caso.ingrediente)
+			if volta = Arredondar(volta, origem); origem != u || volta != caso.valor {
+				t.Errorf("%v %s de %q -> %v %s (%s) -> %v %s", caso.valor, caso.unidade, caso.ingrediente,
+					ida, destino.Nome, sistema, volta, origem.Nome)
+			}
+		}
+	}
+}
+
+func TestParseSistema(t *testing.T) {
+	for _, nome := range []string{"metrico", "imperial", "caseiro"} {
+		if sistema, err := ParseSistema(nome); err != nil || string(sistema) != nome {
+			t.Errorf("ParseSistema(%q) = %q, %v", nome, sistema, err)
+		}
+	}
+	if _, err := ParseSistema("nautico"); err == nil {
+		t.Error("sistema desconhecido aceito")
+	}
+}